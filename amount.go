@@ -0,0 +1,86 @@
+package webmoney
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	amountScale = 100
+)
+
+var (
+	amountRegex = regexp.MustCompile(`^([0-9]+)(\.([0-9]{1,2}))?$`)
+)
+
+// Amount is an exact money amount with two decimal places stored in minor units,
+// e.g. Amount(1050) is 10.50
+type Amount int64
+
+// ParseAmount parses amount in the WebMoney format: the dot separated number
+// with no more than two digits in the fractional part
+func ParseAmount(val string) (Amount, error) {
+	matches := amountRegex.FindStringSubmatch(val)
+
+	if matches == nil {
+		return 0, ErrorAmountIsIncorrect
+	}
+
+	units, err := strconv.ParseInt(matches[1], 10, 64)
+
+	if err != nil || units > (math.MaxInt64-amountScale)/amountScale {
+		return 0, ErrorAmountIsIncorrect
+	}
+
+	var cents int64
+
+	if matches[3] != "" {
+		cents, _ = strconv.ParseInt((matches[3] + "0")[:2], 10, 64)
+	}
+
+	return Amount(units*amountScale + cents), nil
+}
+
+// String returns amount in the WebMoney format without insignificant zeros in the fractional part
+func (m Amount) String() string {
+	val := int64(m)
+	sign := ""
+
+	if val < 0 {
+		sign = "-"
+		val = -val
+	}
+
+	out := sign + strconv.FormatInt(val/amountScale, 10)
+	cents := val % amountScale
+
+	if cents == 0 {
+		return out
+	}
+
+	return out + "." + strings.TrimRight(strconv.FormatInt(amountScale+cents, 10)[1:], "0")
+}
+
+func (m Amount) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Amount) UnmarshalText(data []byte) error {
+	val := strings.TrimSpace(string(data))
+
+	if val == "" {
+		*m = 0
+		return nil
+	}
+
+	amount, err := ParseAmount(val)
+
+	if err != nil {
+		return err
+	}
+
+	*m = amount
+	return nil
+}
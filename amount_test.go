@@ -0,0 +1,77 @@
+package webmoney
+
+import (
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAmount_ParseAmount_Ok(t *testing.T) {
+	cases := map[string]Amount{
+		"0":         0,
+		"10":        1000,
+		"10.5":      1050,
+		"10.50":     1050,
+		"10.05":     1005,
+		"0.8":       80,
+		"112345.45": 11234545,
+	}
+
+	for val, expected := range cases {
+		amount, err := ParseAmount(val)
+		assert.NoError(t, err, val)
+		assert.Equal(t, expected, amount, val)
+	}
+}
+
+func TestAmount_ParseAmount_Error(t *testing.T) {
+	cases := []string{"", "-10", "10,5", "10.", ".5", "10.123", "1e3", "abc", "99999999999999999999"}
+
+	for _, val := range cases {
+		amount, err := ParseAmount(val)
+		assert.Equal(t, ErrorAmountIsIncorrect, err, val)
+		assert.Zero(t, amount, val)
+	}
+}
+
+func TestAmount_String_Ok(t *testing.T) {
+	cases := map[Amount]string{
+		0:        "0",
+		1000:     "10",
+		1050:     "10.5",
+		1005:     "10.05",
+		80:       "0.8",
+		-1050:    "-10.5",
+		11234545: "112345.45",
+	}
+
+	for amount, expected := range cases {
+		assert.Equal(t, expected, amount.String())
+	}
+}
+
+func TestAmount_Xml_Ok(t *testing.T) {
+	type purse struct {
+		XMLName xml.Name `xml:"purse"`
+		Amount  Amount   `xml:"amount"`
+		Rest    Amount   `xml:"rest,attr"`
+	}
+
+	b, err := xml.Marshal(&purse{Amount: 1050, Rest: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, `<purse rest="1"><amount>10.5</amount></purse>`, string(b))
+
+	out := new(purse)
+	err = xml.Unmarshal([]byte(`<purse rest="0.01"><amount> 123456789.99 </amount></purse>`), out)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 12345678999, out.Amount)
+	assert.EqualValues(t, 1, out.Rest)
+
+	out = new(purse)
+	err = xml.Unmarshal([]byte(`<purse><amount></amount></purse>`), out)
+	assert.NoError(t, err)
+	assert.Zero(t, out.Amount)
+
+	err = xml.Unmarshal([]byte(`<purse><amount>10,5</amount></purse>`), out)
+	assert.Equal(t, ErrorAmountIsIncorrect, err)
+}
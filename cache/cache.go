@@ -0,0 +1,290 @@
+// Package cache caches the responses of read-only WebMoney XML interfaces
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/sidmal/webmoney"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultKeyPrefix = "webmoney:"
+	defaultTTLX9     = 30 * time.Second
+
+	keyResponse    = "response:"
+	keyInvalidated = "invalidated:"
+
+	// The difference of the clocks of the clients sharing the store, the response requested earlier than it after
+	// the invalidation of its purses may be stale
+	invalidationClockSkew = 5 * time.Second
+	// The invalidation time is kept longer than the longest ttl by the time of the request in flight
+	invalidationTTLMargin = time.Minute
+)
+
+// The responses of read-only interfaces which can be cached. X2 is never cached, it invalidates
+// the cached responses of its purses
+var responseTypes = map[string]func() interface{}{
+	webmoney.InterfaceX3: func() interface{} { return &webmoney.GetTransactionsHistoryResponse{} },
+	webmoney.InterfaceX9: func() interface{} { return &webmoney.GetBalanceResponse{} },
+}
+
+// Interceptor returns the cached responses of read-only interfaces keyed by the request content, so the repeated
+// requests do not reach WebMoney and its rate limits. X9 balances are cached for 30 seconds by default,
+// X3 history is cached only if its ttl is set with TTL option. The cached responses of purses are invalidated
+// after the transfer from or to them. The invalidation time of the purse is kept in the store, so the transfers
+// of all clients sharing the store invalidate the responses requested before them. The store errors are logged
+// and the request is sent to WebMoney. It is plugged to the client with webmoney.Interceptors option
+type Interceptor struct {
+	options *Options
+	maxTTL  time.Duration
+	now     func() time.Time
+}
+
+// cachedResponse is the stored response with the time of its request and the purses invalidating it
+type cachedResponse struct {
+	Requested time.Time
+	Purses    []webmoney.Purse
+	// The gob encoding keeps the descriptions not decoded from windows-1251 as they are
+	Response []byte
+}
+
+func NewInterceptor(opts ...Option) *Interceptor {
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.store == nil {
+		options.store = NewLRUStore(0)
+	}
+
+	if options.ttls == nil {
+		options.ttls = make(map[string]time.Duration)
+	}
+
+	if _, ok := options.ttls[webmoney.InterfaceX9]; !ok {
+		options.ttls[webmoney.InterfaceX9] = defaultTTLX9
+	}
+
+	if options.prefix == "" {
+		options.prefix = defaultKeyPrefix
+	}
+
+	if options.logger == nil {
+		options.logger = zap.NewNop()
+	}
+
+	interceptor := &Interceptor{options: options, now: time.Now}
+
+	for _, ttl := range options.ttls {
+		if ttl > interceptor.maxTTL {
+			interceptor.maxTTL = ttl
+		}
+	}
+
+	return interceptor
+}
+
+func (m *Interceptor) Intercept(ctx context.Context, invocation *webmoney.Invocation, next webmoney.Handler) error {
+	if in, ok := invocation.Request.(*webmoney.TransferMoneyRequest); ok {
+		err := next(ctx, invocation)
+
+		// The transfer may be completed even if the error is returned, e.g. on the network error
+		if invalidateErr := m.Invalidate(ctx, in.PurseSrc, in.PurseDest); invalidateErr != nil {
+			m.options.logger.Warn("webmoney responses cache invalidation failed", zap.Error(invalidateErr))
+		}
+
+		return err
+	}
+
+	newResponse, ok := responseTypes[invocation.Interface]
+	ttl := m.options.ttls[invocation.Interface]
+
+	if !ok || ttl <= 0 {
+		return next(ctx, invocation)
+	}
+
+	key, err := m.responseKey(invocation.Interface, invocation.Request)
+
+	if err != nil {
+		return next(ctx, invocation)
+	}
+
+	if out, ok := m.get(ctx, key, newResponse); ok {
+		invocation.Response = out
+		return nil
+	}
+
+	// The response requested before the invalidation of its purses may be stale, so the request time is stored
+	requested := m.now()
+
+	if err = next(ctx, invocation); err != nil {
+		return err
+	}
+
+	if err = m.set(ctx, key, ttl, requested, invocation); err != nil {
+		m.options.logger.Warn("webmoney response caching failed", zap.Error(err))
+	}
+
+	return nil
+}
+
+// Invalidate marks the cached responses of purses stale, e.g. when the payment to the purse is received
+func (m *Interceptor) Invalidate(ctx context.Context, purses ...webmoney.Purse) error {
+	value := []byte(strconv.FormatInt(m.now().UnixNano(), 10))
+
+	for _, purse := range purses {
+		if purse == "" {
+			continue
+		}
+
+		err := m.options.store.Set(ctx, m.invalidatedKey(purse), value, m.maxTTL+invalidationTTLMargin)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Interceptor) get(ctx context.Context, key string, newResponse func() interface{}) (interface{}, bool) {
+	value, ok, err := m.options.store.Get(ctx, key)
+
+	if err != nil {
+		m.options.logger.Warn("webmoney cached response reading failed", zap.Error(err))
+		return nil, false
+	}
+
+	if !ok {
+		return nil, false
+	}
+
+	cached := new(cachedResponse)
+	out := newResponse()
+
+	if err = gob.NewDecoder(bytes.NewReader(value)).Decode(cached); err == nil {
+		err = gob.NewDecoder(bytes.NewReader(cached.Response)).Decode(out)
+	}
+
+	if err != nil {
+		m.options.logger.Warn("webmoney cached response decoding failed", zap.Error(err))
+		return nil, false
+	}
+
+	invalidated, err := m.invalidated(ctx, cached)
+
+	if err != nil {
+		m.options.logger.Warn("webmoney cached response invalidation reading failed", zap.Error(err))
+		return nil, false
+	}
+
+	if invalidated {
+		return nil, false
+	}
+
+	return out, true
+}
+
+// set stores the response before reading the invalidation time of its purses, so the store evicting the least
+// recently used keys keeps the invalidation time longer than the response. The response requested before
+// the invalidation is removed
+func (m *Interceptor) set(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	requested time.Time,
+	invocation *webmoney.Invocation,
+) error {
+	var response, value bytes.Buffer
+
+	if err := gob.NewEncoder(&response).Encode(invocation.Response); err != nil {
+		return err
+	}
+
+	cached := &cachedResponse{Requested: requested, Purses: responsePurses(invocation), Response: response.Bytes()}
+
+	if err := gob.NewEncoder(&value).Encode(cached); err != nil {
+		return err
+	}
+
+	if err := m.options.store.Set(ctx, key, value.Bytes(), ttl); err != nil {
+		return err
+	}
+
+	invalidated, err := m.invalidated(ctx, cached)
+
+	if err != nil || invalidated {
+		return m.options.store.Delete(ctx, key)
+	}
+
+	return nil
+}
+
+// invalidated returns true if the purses of the response are invalidated after it is requested
+func (m *Interceptor) invalidated(ctx context.Context, cached *cachedResponse) (bool, error) {
+	for _, purse := range cached.Purses {
+		value, ok, err := m.options.store.Get(ctx, m.invalidatedKey(purse))
+
+		if err != nil {
+			return false, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		nanos, err := strconv.ParseInt(string(value), 10, 64)
+
+		if err != nil {
+			return false, err
+		}
+
+		if cached.Requested.Before(time.Unix(0, nanos).Add(invalidationClockSkew)) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (m *Interceptor) invalidatedKey(purse webmoney.Purse) string {
+	return m.options.prefix + keyInvalidated + string(purse)
+}
+
+// responseKey returns the store key of the request content, the request of X3 and X9 has no secret fields
+func (m *Interceptor) responseKey(iface string, request interface{}) (string, error) {
+	data, err := json.Marshal(request)
+
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+
+	return m.options.prefix + keyResponse + iface + ":" + hex.EncodeToString(hash[:]), nil
+}
+
+// responsePurses returns the purses whose cached response must be invalidated by the transfer
+func responsePurses(invocation *webmoney.Invocation) []webmoney.Purse {
+	var purses []webmoney.Purse
+
+	switch out := invocation.Response.(type) {
+	case *webmoney.GetTransactionsHistoryResponse:
+		purses = append(purses, invocation.Request.(*webmoney.GetTransactionsHistoryRequest).Purse)
+	case *webmoney.GetBalanceResponse:
+		for _, purse := range out.PurseList {
+			purses = append(purses, purse.PurseName)
+		}
+	}
+
+	return purses
+}
@@ -0,0 +1,370 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/sidmal/webmoney"
+	"github.com/sidmal/webmoney/signer"
+	"github.com/sidmal/webmoney/wmtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	TestWmId       = "405002833238"
+	TestPassword   = "FvGqPdAy8reVWw789"
+	TestPurse      = webmoney.Purse("Z405002833238")
+	TestPayeeWmId  = "123456789012"
+	TestPayeePurse = webmoney.Purse("Z123456789012")
+)
+
+type failingStore struct {
+	err error
+}
+
+func (m *failingStore) Get(_ context.Context, _ string) ([]byte, bool, error) {
+	return nil, false, m.err
+}
+
+func (m *failingStore) Set(_ context.Context, _ string, _ []byte, _ time.Duration) error {
+	return m.err
+}
+
+func (m *failingStore) Delete(_ context.Context, _ ...string) error {
+	return m.err
+}
+
+type CacheTestSuite struct {
+	suite.Suite
+	server *wmtest.Server
+	store  *LRUStore
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func Test_Cache(t *testing.T) {
+	suite.Run(t, new(CacheTestSuite))
+}
+
+func (suite *CacheTestSuite) SetupTest() {
+	suite.server = wmtest.NewServer()
+	suite.server.AddPurse(TestWmId, TestPurse, 10000)
+	suite.server.AddPurse(TestPayeeWmId, TestPayeePurse, 0)
+	suite.store = NewLRUStore(0)
+	suite.calls = make(map[string]int)
+}
+
+func (suite *CacheTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+// newClient returns the client with the cache interceptor and the interceptor counting the requests
+// reaching WebMoney behind the cache
+func (suite *CacheTestSuite) newClient(
+	interceptor *Interceptor,
+	interceptors ...webmoney.Interceptor,
+) webmoney.XMLInterface {
+	key, _, err := signer.GenerateKey(nil, TestWmId, TestPassword)
+
+	if err != nil {
+		suite.FailNow("Key generation failed", "%v", err)
+	}
+
+	counter := webmoney.InterceptorFunc(func(ctx context.Context, invocation *webmoney.Invocation, next webmoney.Handler) error {
+		suite.mu.Lock()
+		suite.calls[invocation.Interface]++
+		suite.mu.Unlock()
+
+		return next(ctx, invocation)
+	})
+	opts := []webmoney.Option{
+		webmoney.WmId(TestWmId),
+		webmoney.KeyBytes(key),
+		webmoney.Password(TestPassword),
+		webmoney.Endpoint(suite.server.URL),
+		webmoney.Interceptors(append([]webmoney.Interceptor{interceptor, counter}, interceptors...)...),
+	}
+	client, err := webmoney.NewWebMoney(opts...)
+
+	if err != nil {
+		suite.FailNow("WebMoney client initialization failed", "%v", err)
+	}
+
+	// The clients of the same WMID send reqn of the current millisecond, so the requests of the new client
+	// are spaced from the previous ones
+	time.Sleep(2 * time.Millisecond)
+
+	return client
+}
+
+func (suite *CacheTestSuite) balance(client webmoney.XMLInterface) webmoney.Amount {
+	out, err := client.GetBalance(&webmoney.GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), out.PurseList, 1)
+
+	return out.PurseList[0].Amount
+}
+
+func (suite *CacheTestSuite) transfer(client webmoney.XMLInterface, txnId int) {
+	in := &webmoney.TransferMoneyRequest{
+		TxnId:     txnId,
+		PurseSrc:  TestPurse,
+		PurseDest: TestPayeePurse,
+		Amount:    1000,
+		Desc:      "Order",
+	}
+	_, err := client.TransferMoney(in)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *CacheTestSuite) TestCache_GetBalance_Ok() {
+	client := suite.newClient(NewInterceptor(Backend(suite.store)))
+
+	assert.EqualValues(suite.T(), 10000, suite.balance(client))
+	assert.EqualValues(suite.T(), 10000, suite.balance(client))
+	assert.Equal(suite.T(), 1, suite.calls[webmoney.InterfaceX9])
+
+	// The cached response is decoded to the new value on each hit
+	out, err := client.GetBalance(&webmoney.GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+	out.PurseList[0].Amount = 0
+	assert.EqualValues(suite.T(), 10000, suite.balance(client))
+
+	// The other request content is cached by other key
+	_, err = client.GetBalance(&webmoney.GetBalanceRequest{Wmid: TestPayeeWmId})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, suite.calls[webmoney.InterfaceX9])
+}
+
+func (suite *CacheTestSuite) TestCache_TransferMoney_Invalidate_Ok() {
+	client := suite.newClient(NewInterceptor(Backend(suite.store)))
+
+	assert.EqualValues(suite.T(), 10000, suite.balance(client))
+	suite.transfer(client, 1)
+	assert.EqualValues(suite.T(), 9000, suite.balance(client))
+	assert.Equal(suite.T(), 2, suite.calls[webmoney.InterfaceX9])
+
+	// The transfer failed by WebMoney invalidates the purses too
+	suite.server.Script(webmoney.InterfaceX2, wmtest.Fault{Code: -100, Reason: "General error"})
+	_, err := client.TransferMoney(&webmoney.TransferMoneyRequest{
+		TxnId:     2,
+		PurseSrc:  TestPurse,
+		PurseDest: TestPayeePurse,
+		Amount:    1000,
+		Desc:      "Order",
+	})
+	assert.Error(suite.T(), err)
+	assert.EqualValues(suite.T(), 9000, suite.balance(client))
+	assert.Equal(suite.T(), 3, suite.calls[webmoney.InterfaceX9])
+}
+
+func (suite *CacheTestSuite) TestCache_GetTransactionsHistory_Ok() {
+	now := time.Now()
+	interceptor := NewInterceptor(Backend(suite.store), TTL(webmoney.InterfaceX3, time.Minute))
+	interceptor.now = func() time.Time {
+		return now.Add(-time.Minute)
+	}
+	client := suite.newClient(interceptor)
+	suite.transfer(client, 1)
+
+	interceptor.now = func() time.Time {
+		return now
+	}
+	in := &webmoney.GetTransactionsHistoryRequest{
+		Purse:      TestPurse,
+		DateStart:  webmoney.NewWMTime(now.Add(-time.Hour)),
+		DateFinish: webmoney.NewWMTime(now.Add(time.Hour)),
+	}
+	first, err := client.GetTransactionsHistory(in)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), first.OperationList, 1)
+
+	second, err := client.GetTransactionsHistory(in)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), second.OperationList, 1)
+	assert.Equal(suite.T(), first.OperationList[0].Id, second.OperationList[0].Id)
+	assert.Equal(suite.T(), first.OperationList[0].Amount, second.OperationList[0].Amount)
+	assert.Equal(suite.T(), first.OperationList[0].Desc, second.OperationList[0].Desc)
+	assert.True(suite.T(), first.OperationList[0].DateCrt.Equal(second.OperationList[0].DateCrt.Time))
+	assert.Equal(suite.T(), 1, suite.calls[webmoney.InterfaceX3])
+
+	suite.transfer(client, 2)
+	third, err := client.GetTransactionsHistory(in)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), third.OperationList, 2)
+	assert.Equal(suite.T(), 2, suite.calls[webmoney.InterfaceX3])
+}
+
+func (suite *CacheTestSuite) TestCache_TTL_Ok() {
+	client := suite.newClient(NewInterceptor(Backend(suite.store), TTL(webmoney.InterfaceX9, time.Minute)))
+	now := time.Now()
+	suite.store.now = func() time.Time {
+		return now
+	}
+
+	suite.balance(client)
+	suite.store.now = func() time.Time {
+		return now.Add(time.Minute)
+	}
+	suite.balance(client)
+	assert.Equal(suite.T(), 2, suite.calls[webmoney.InterfaceX9])
+
+	// X3 is not cached without ttl, X9 is not cached with zero ttl
+	client = suite.newClient(NewInterceptor(Backend(suite.store), TTL(webmoney.InterfaceX9, 0)))
+	suite.balance(client)
+	suite.balance(client)
+	assert.Equal(suite.T(), 4, suite.calls[webmoney.InterfaceX9])
+
+	in := &webmoney.GetTransactionsHistoryRequest{
+		Purse:      TestPurse,
+		DateStart:  webmoney.NewWMTime(now.Add(-time.Hour)),
+		DateFinish: webmoney.NewWMTime(now.Add(time.Hour)),
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetTransactionsHistory(in)
+		assert.NoError(suite.T(), err)
+	}
+
+	assert.Equal(suite.T(), 2, suite.calls[webmoney.InterfaceX3])
+}
+
+func (suite *CacheTestSuite) TestCache_Invalidate_Ok() {
+	now := time.Now()
+	interceptor := NewInterceptor(Backend(suite.store), KeyPrefix("test:"))
+	interceptor.now = func() time.Time {
+		return now.Add(-time.Minute)
+	}
+	client := suite.newClient(interceptor)
+
+	suite.balance(client)
+	assert.Equal(suite.T(), 1, suite.store.Len())
+
+	suite.server.AddPurse(TestWmId, TestPurse, 500)
+	interceptor.now = func() time.Time {
+		return now
+	}
+	assert.NoError(suite.T(), interceptor.Invalidate(context.Background(), TestPurse, "", TestPayeePurse))
+	assert.Equal(suite.T(), 3, suite.store.Len())
+
+	value, ok, err := suite.store.Get(context.Background(), "test:invalidated:"+string(TestPurse))
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), strconv.FormatInt(now.UnixNano(), 10), string(value))
+
+	assert.EqualValues(suite.T(), 500, suite.balance(client))
+	assert.Equal(suite.T(), 2, suite.calls[webmoney.InterfaceX9])
+
+	// The response requested within the clock skew after the invalidation is not cached
+	assert.EqualValues(suite.T(), 500, suite.balance(client))
+	assert.Equal(suite.T(), 3, suite.calls[webmoney.InterfaceX9])
+
+	interceptor.now = func() time.Time {
+		return now.Add(invalidationClockSkew)
+	}
+	suite.balance(client)
+	suite.balance(client)
+	assert.Equal(suite.T(), 4, suite.calls[webmoney.InterfaceX9])
+}
+
+func (suite *CacheTestSuite) TestCache_SharedStore_Invalidate_Ok() {
+	now := time.Now()
+	interceptor := NewInterceptor(Backend(suite.store))
+	interceptor.now = func() time.Time {
+		return now.Add(-time.Minute)
+	}
+	client := suite.newClient(interceptor)
+	assert.EqualValues(suite.T(), 10000, suite.balance(client))
+
+	// The transfer by other client sharing the store, e.g. other process, invalidates the cached balance
+	suite.transfer(suite.newClient(NewInterceptor(Backend(suite.store))), 1)
+	time.Sleep(2 * time.Millisecond)
+	interceptor.now = func() time.Time {
+		return now.Add(time.Minute)
+	}
+	assert.EqualValues(suite.T(), 9000, suite.balance(client))
+	assert.EqualValues(suite.T(), 9000, suite.balance(client))
+	assert.Equal(suite.T(), 2, suite.calls[webmoney.InterfaceX9])
+}
+
+func (suite *CacheTestSuite) TestCache_ConcurrentTransfer_NotCached() {
+	interceptor := NewInterceptor(Backend(suite.store))
+	received, release := make(chan struct{}), make(chan struct{})
+
+	// The balance is received from WebMoney before the transfer and returned to the cache after it
+	delay := webmoney.InterceptorFunc(func(ctx context.Context, invocation *webmoney.Invocation, next webmoney.Handler) error {
+		err := next(ctx, invocation)
+
+		if invocation.Interface == webmoney.InterfaceX9 {
+			close(received)
+			<-release
+		}
+
+		return err
+	})
+	client := suite.newClient(interceptor, delay)
+	done := make(chan webmoney.Amount)
+
+	go func() {
+		done <- suite.balance(client)
+	}()
+
+	<-received
+	transfers := suite.newClient(interceptor)
+	suite.transfer(transfers, 1)
+	close(release)
+
+	assert.EqualValues(suite.T(), 10000, <-done)
+	key, err := interceptor.responseKey(webmoney.InterfaceX9, &webmoney.GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+	_, ok, err := suite.store.Get(context.Background(), key)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), ok)
+	assert.EqualValues(suite.T(), 9000, suite.balance(transfers))
+	assert.Equal(suite.T(), 2, suite.calls[webmoney.InterfaceX9])
+}
+
+func (suite *CacheTestSuite) TestCache_StoreError_Ok() {
+	err := errors.New("TestCache_StoreError")
+	client := suite.newClient(NewInterceptor(Backend(&failingStore{err: err})))
+
+	assert.EqualValues(suite.T(), 10000, suite.balance(client))
+	assert.EqualValues(suite.T(), 10000, suite.balance(client))
+	assert.Equal(suite.T(), 2, suite.calls[webmoney.InterfaceX9])
+
+	suite.transfer(client, 1)
+	assert.EqualValues(suite.T(), 9000, suite.balance(client))
+
+	interceptor := NewInterceptor(Backend(&failingStore{err: err}))
+	assert.EqualError(suite.T(), interceptor.Invalidate(context.Background(), TestPurse), "TestCache_StoreError")
+}
+
+func (suite *CacheTestSuite) TestCache_BrokenValue_Ok() {
+	interceptor := NewInterceptor(Backend(suite.store))
+	client := suite.newClient(interceptor)
+	key, err := interceptor.responseKey(webmoney.InterfaceX9, &webmoney.GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.store.Set(context.Background(), key, []byte("broken"), time.Minute))
+
+	assert.EqualValues(suite.T(), 10000, suite.balance(client))
+	assert.Equal(suite.T(), 1, suite.calls[webmoney.InterfaceX9])
+	assert.EqualValues(suite.T(), 10000, suite.balance(client))
+	assert.Equal(suite.T(), 1, suite.calls[webmoney.InterfaceX9])
+}
+
+func (suite *CacheTestSuite) TestCache_NewInterceptor_Defaults() {
+	interceptor := NewInterceptor()
+	assert.IsType(suite.T(), &LRUStore{}, interceptor.options.store)
+	assert.Equal(suite.T(), map[string]time.Duration{webmoney.InterfaceX9: defaultTTLX9}, interceptor.options.ttls)
+	assert.Equal(suite.T(), defaultKeyPrefix, interceptor.options.prefix)
+	assert.NotNil(suite.T(), interceptor.options.logger)
+	assert.Equal(suite.T(), defaultTTLX9, interceptor.maxTTL)
+
+	interceptor = NewInterceptor(TTL(webmoney.InterfaceX3, time.Hour))
+	assert.Equal(suite.T(), time.Hour, interceptor.maxTTL)
+}
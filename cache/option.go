@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"go.uber.org/zap"
+	"time"
+)
+
+type Options struct {
+	// The store of cached responses, the in-memory LRU store is used if not set
+	store Store
+	// The time to live of cached responses per interface, the interfaces without ttl are not cached
+	ttls map[string]time.Duration
+	// The prefix of the store keys, e.g. to share the store with other applications
+	prefix string
+	// The logger of the store errors
+	logger *zap.Logger
+}
+
+type Option func(*Options)
+
+func Backend(val Store) Option {
+	return func(opts *Options) {
+		opts.store = val
+	}
+}
+
+// TTL sets the time to live of the interface responses, zero ttl disables caching of the interface
+func TTL(iface string, val time.Duration) Option {
+	return func(opts *Options) {
+		if opts.ttls == nil {
+			opts.ttls = make(map[string]time.Duration)
+		}
+
+		opts.ttls[iface] = val
+	}
+}
+
+func KeyPrefix(val string) Option {
+	return func(opts *Options) {
+		opts.prefix = val
+	}
+}
+
+func Logger(val *zap.Logger) Option {
+	return func(opts *Options) {
+		opts.logger = val
+	}
+}
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"github.com/sidmal/webmoney"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestCacheOptions_Setters(t *testing.T) {
+	store := NewLRUStore(10)
+	logger := zap.NewNop()
+
+	opts := []Option{
+		Backend(store),
+		TTL(webmoney.InterfaceX9, time.Second),
+		TTL(webmoney.InterfaceX3, time.Minute),
+		KeyPrefix("app:"),
+		Logger(logger),
+	}
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	assert.Equal(t, store, options.store)
+	assert.Equal(
+		t,
+		map[string]time.Duration{webmoney.InterfaceX9: time.Second, webmoney.InterfaceX3: time.Minute},
+		options.ttls,
+	)
+	assert.Equal(t, "app:", options.prefix)
+	assert.Equal(t, logger, options.logger)
+}
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultLRUStoreSize = 1000
+
+// Store keeps the cached responses. It is implemented by the in-memory LRU store and can be implemented
+// for Redis-like stores shared by several clients
+type Store interface {
+	// Get returns the value of the key and false if the key does not exist or it is expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores the value of the key for the ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the keys, the missing keys are ignored
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// LRUStore is the in-memory store of limited size, the least recently used entry is evicted
+// when the store is full
+type LRUStore struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+	now     func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRUStore returns the in-memory store of up to size entries, the default size is used if size is not positive
+func NewLRUStore(size int) *LRUStore {
+	if size <= 0 {
+		size = defaultLRUStoreSize
+	}
+
+	return &LRUStore{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (m *LRUStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*lruEntry)
+
+	if !m.now().Before(entry.expires) {
+		m.remove(element)
+		return nil, false, nil
+	}
+
+	m.order.MoveToFront(element)
+
+	return append([]byte(nil), entry.value...), true, nil
+}
+
+func (m *LRUStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := &lruEntry{key: key, value: append([]byte(nil), value...), expires: m.now().Add(ttl)}
+
+	if element, ok := m.entries[key]; ok {
+		element.Value = entry
+		m.order.MoveToFront(element)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(entry)
+
+	for m.order.Len() > m.size {
+		m.remove(m.order.Back())
+	}
+
+	return nil
+}
+
+func (m *LRUStore) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := m.entries[key]; ok {
+			m.remove(element)
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of entries including the expired ones not evicted yet
+func (m *LRUStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.order.Len()
+}
+
+func (m *LRUStore) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*lruEntry).key)
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLRUStore_Ok(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	store := NewLRUStore(2)
+	store.now = func() time.Time {
+		return now
+	}
+
+	_, ok, err := store.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	value := []byte("1")
+	assert.NoError(t, store.Set(ctx, "a", value, time.Minute))
+	value[0] = '0'
+
+	out, ok, err := store.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), out)
+
+	// The least recently used entry is evicted
+	assert.NoError(t, store.Set(ctx, "b", []byte("2"), time.Minute))
+	_, _, _ = store.Get(ctx, "a")
+	assert.NoError(t, store.Set(ctx, "c", []byte("3"), time.Minute))
+	assert.Equal(t, 2, store.Len())
+
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok)
+	_, ok, _ = store.Get(ctx, "a")
+	assert.True(t, ok)
+
+	// The existing entry is replaced
+	assert.NoError(t, store.Set(ctx, "a", []byte("4"), time.Hour))
+	out, _, _ = store.Get(ctx, "a")
+	assert.Equal(t, []byte("4"), out)
+	assert.Equal(t, 2, store.Len())
+
+	// The expired entry is removed on reading
+	now = now.Add(time.Minute)
+	_, ok, _ = store.Get(ctx, "c")
+	assert.False(t, ok)
+	assert.Equal(t, 1, store.Len())
+
+	_, ok, _ = store.Get(ctx, "a")
+	assert.True(t, ok)
+
+	assert.NoError(t, store.Delete(ctx, "a", "unknown"))
+	assert.Zero(t, store.Len())
+}
+
+func TestLRUStore_DefaultSize(t *testing.T) {
+	assert.Equal(t, defaultLRUStoreSize, NewLRUStore(0).size)
+	assert.Equal(t, defaultLRUStoreSize, NewLRUStore(-1).size)
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/sidmal/webmoney"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04:05"
+)
+
+var (
+	errorPurseRequired     = errors.New("the purse is required")
+	errorTransferIncorrect = errors.New("the source and destination purses, amount and tranid are required")
+	errorTransferCancelled = errors.New("the transfer is cancelled")
+	errorDateIncorrect     = errors.New("the date must be in YYYY-MM-DD or YYYY-MM-DD HH:MM:SS format")
+)
+
+// table is the output of the command, it is printed as the aligned columns or as JSON list of objects
+type table struct {
+	columns []string
+	rows    [][]string
+}
+
+func (m *command) balance(args []string) error {
+	flags := flag.NewFlagSet("balance", flag.ContinueOnError)
+	wmId := flags.String("wmid", m.cfg.wmId, "the WMID of the purses")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := m.env.newClient(m.cfg.clientOptions()...)
+
+	if err != nil {
+		return err
+	}
+
+	rsp, err := client.GetBalance(&webmoney.GetBalanceRequest{Wmid: *wmId})
+
+	if err != nil {
+		return err
+	}
+
+	out := &table{columns: []string{"purse", "amount", "desc"}}
+
+	for _, purse := range rsp.PurseList {
+		out.rows = append(out.rows, []string{purse.PurseName.String(), purse.Amount.String(), purse.Desc})
+	}
+
+	return m.print(out)
+}
+
+func (m *command) history(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	purse := flags.String("purse", "", "the purse of the operations")
+	from := flags.String("from", "", "the start of the period, 24 hours ago by default")
+	to := flags.String("to", "", "the end of the period inclusive, now by default")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *purse == "" {
+		return errorPurseRequired
+	}
+
+	finish, err := parseDate(*to, time.Now(), true)
+
+	if err != nil {
+		return err
+	}
+
+	start, err := parseDate(*from, finish.Add(-24*time.Hour), false)
+
+	if err != nil {
+		return err
+	}
+
+	client, err := m.env.newClient(m.cfg.clientOptions()...)
+
+	if err != nil {
+		return err
+	}
+
+	out := &table{columns: []string{"id", "tranid", "date", "pursesrc", "pursedest", "amount", "comiss", "desc"}}
+	cursor := webmoney.IterateTransactions(ctx, client, webmoney.Purse(*purse), start, finish)
+
+	for cursor.Next() {
+		out.rows = append(out.rows, operationRow(cursor.Operation()))
+	}
+
+	if err = cursor.Err(); err != nil {
+		return err
+	}
+
+	return m.print(out)
+}
+
+func (m *command) transfer(args []string) error {
+	flags := flag.NewFlagSet("transfer", flag.ContinueOnError)
+	purseSrc := flags.String("from", "", "the source purse")
+	purseDest := flags.String("to", "", "the destination purse")
+	amount := flags.String("amount", "", "the amount, e.g. 10.50")
+	txnId := flags.Int("tranid", 0, "the unique transfer number of the source purse")
+	desc := flags.String("desc", "", "the description of the transfer")
+	dryRun := flags.Bool("dry-run", false, "print the transfer without sending it")
+	yes := flags.Bool("yes", false, "transfer without confirmation")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *purseSrc == "" || *purseDest == "" || *amount == "" || *txnId <= 0 {
+		return errorTransferIncorrect
+	}
+
+	req := &webmoney.TransferMoneyRequest{
+		TxnId:     *txnId,
+		PurseSrc:  webmoney.Purse(*purseSrc),
+		PurseDest: webmoney.Purse(*purseDest),
+		Desc:      *desc,
+	}
+	var err error
+
+	if req.Amount, err = webmoney.ParseAmount(*amount); err != nil {
+		return err
+	}
+
+	summary := fmt.Sprintf(
+		"Transfer %s %s from %s to %s, tranid %d: %q",
+		req.Amount, req.PurseSrc.Currency(), req.PurseSrc, req.PurseDest, req.TxnId, req.Desc,
+	)
+
+	if *dryRun {
+		_, err = fmt.Fprintln(m.env.stdout, summary+" (dry run, not sent)")
+		return err
+	}
+
+	if !*yes && !m.confirm(summary) {
+		return errorTransferCancelled
+	}
+
+	client, err := m.env.newClient(m.cfg.clientOptions()...)
+
+	if err != nil {
+		return err
+	}
+
+	rsp, err := client.TransferMoney(req)
+
+	if err != nil {
+		return err
+	}
+
+	out := &table{
+		columns: []string{"id", "tranid", "date", "pursesrc", "pursedest", "amount", "comiss", "desc"},
+		rows:    [][]string{operationRow(rsp)},
+	}
+
+	return m.print(out)
+}
+
+func (m *command) sign(args []string) error {
+	flags := flag.NewFlagSet("sign", flag.ContinueOnError)
+	data := flags.String("data", "", "the data to sign, the standard input is signed if it is not set")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	str := *data
+
+	if str == "" {
+		b, err := ioutil.ReadAll(m.env.stdin)
+
+		if err != nil {
+			return err
+		}
+
+		str = strings.TrimRight(string(b), "\r\n")
+	}
+
+	sig, err := m.env.newSigner(m.cfg.signerOptions()...)
+
+	if err != nil {
+		return err
+	}
+
+	signature, err := sig.Sign(str)
+
+	if err != nil {
+		return err
+	}
+
+	return m.print(&table{columns: []string{"signature"}, rows: [][]string{{signature}}})
+}
+
+// confirm asks to confirm the operation and reads the answer from the standard input
+func (m *command) confirm(summary string) bool {
+	_, _ = fmt.Fprint(m.env.stdout, summary+"? [y/N] ")
+	scanner := bufio.NewScanner(m.env.stdin)
+
+	if !scanner.Scan() {
+		return false
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+
+	return answer == "y" || answer == "yes"
+}
+
+func (m *command) print(out *table) error {
+	if m.output == outputJson {
+		list := make([]map[string]string, 0, len(out.rows))
+
+		for _, row := range out.rows {
+			item := make(map[string]string, len(out.columns))
+
+			for i, column := range out.columns {
+				item[column] = row[i]
+			}
+
+			list = append(list, item)
+		}
+
+		encoder := json.NewEncoder(m.env.stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(list)
+	}
+
+	w := tabwriter.NewWriter(m.env.stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, strings.ToUpper(strings.Join(out.columns, "\t")))
+
+	for _, row := range out.rows {
+		_, _ = fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return w.Flush()
+}
+
+func operationRow(operation *webmoney.TransferMoneyResponse) []string {
+	return []string{
+		operation.Id,
+		strconv.FormatInt(operation.TxnId, 10),
+		operation.DateCrt.Format(dateTimeLayout),
+		operation.PurseSrc.String(),
+		operation.PurseDest.String(),
+		operation.Amount.String(),
+		operation.Commission.String(),
+		operation.Desc,
+	}
+}
+
+// parseDate parses the date or the date with time in the local time zone, the date is the end of the day
+// if endOfDay is set. The default is returned for empty value
+func parseDate(val string, def time.Time, endOfDay bool) (time.Time, error) {
+	if val == "" {
+		return def, nil
+	}
+
+	if t, err := time.ParseInLocation(dateTimeLayout, val, time.Local); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation(dateLayout, val, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Second)
+		}
+
+		return t, nil
+	}
+
+	return time.Time{}, errorDateIncorrect
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWmCtl_ParseDate_Ok(t *testing.T) {
+	def := time.Now()
+
+	date, err := parseDate("", def, true)
+	assert.NoError(t, err)
+	assert.Equal(t, def, date)
+
+	date, err = parseDate("2020-09-01", def, false)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 9, 1, 0, 0, 0, 0, time.Local), date)
+
+	date, err = parseDate("2020-09-01", def, true)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 9, 1, 23, 59, 59, 0, time.Local), date)
+
+	date, err = parseDate("2020-09-01 12:30:00", def, true)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 9, 1, 12, 30, 0, 0, time.Local), date)
+
+	_, err = parseDate("01.09.2020", def, false)
+	assert.Equal(t, errorDateIncorrect, err)
+}
+
+func TestWmCtl_Print_Ok(t *testing.T) {
+	out := &table{columns: []string{"purse", "amount"}, rows: [][]string{{"Z123456789012", "1.5"}}}
+	stdout := new(bytes.Buffer)
+	cmd := &command{output: outputTable, env: &environment{stdout: stdout}}
+
+	assert.NoError(t, cmd.print(out))
+	assert.Equal(t, "PURSE          AMOUNT\nZ123456789012  1.5\n", stdout.String())
+
+	stdout.Reset()
+	cmd.output = outputJson
+	assert.NoError(t, cmd.print(&table{columns: []string{"purse"}}))
+	assert.Equal(t, "[]\n", stdout.String())
+}
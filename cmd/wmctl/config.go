@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"github.com/sidmal/webmoney"
+	"github.com/sidmal/webmoney/signer"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	envWmId     = "WEBMONEY_WMID"
+	envKey      = "WEBMONEY_KEY"
+	envKeyFile  = "WEBMONEY_KEY_FILE"
+	envPassword = "WEBMONEY_KEY_PASSWORD"
+	envEndpoint = "WEBMONEY_ENDPOINT"
+)
+
+var (
+	errorConfigLineIncorrect = errors.New("the config line must be NAME=value")
+)
+
+// config is the configuration of WMID and its key. The values are read from the environment variables
+// and from the config file of NAME=value lines with the same names, the environment takes precedence
+type config struct {
+	wmId     string
+	key      string
+	keyFile  string
+	password string
+	endpoint string
+}
+
+func loadConfig(path string, getenv func(string) string) (*config, error) {
+	values := make(map[string]string)
+
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if values, err = parseConfig(string(b)); err != nil {
+			return nil, err
+		}
+	}
+
+	get := func(name string) string {
+		if val := getenv(name); val != "" {
+			return val
+		}
+
+		return values[name]
+	}
+
+	cfg := &config{
+		wmId:     get(envWmId),
+		key:      get(envKey),
+		keyFile:  get(envKeyFile),
+		password: get(envPassword),
+		endpoint: get(envEndpoint),
+	}
+
+	return cfg, nil
+}
+
+// parseConfig parses NAME=value lines skipping empty lines and # comments, the values may be quoted
+func parseConfig(content string) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, errorConfigLineIncorrect
+		}
+
+		val := strings.TrimSpace(parts[1])
+
+		if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+			val = val[1 : len(val)-1]
+		}
+
+		values[strings.TrimSpace(parts[0])] = val
+	}
+
+	return values, scanner.Err()
+}
+
+func (m *config) clientOptions() []webmoney.Option {
+	opts := []webmoney.Option{
+		webmoney.WmId(m.wmId),
+		webmoney.Key(m.key),
+		webmoney.Password(m.password),
+	}
+
+	if m.keyFile != "" {
+		opts = append(opts, webmoney.KeyFile(m.keyFile))
+	}
+
+	if m.endpoint != "" {
+		opts = append(opts, webmoney.Endpoint(m.endpoint))
+	}
+
+	return opts
+}
+
+func (m *config) signerOptions() []signer.Option {
+	opts := []signer.Option{
+		signer.WmId(m.wmId),
+		signer.Key(m.key),
+		signer.Password(m.password),
+	}
+
+	if m.keyFile != "" {
+		opts = append(opts, signer.KeyFile(m.keyFile))
+	}
+
+	return opts
+}
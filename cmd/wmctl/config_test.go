@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func getenv(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestWmCtl_LoadConfig_Ok(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wmctl.env")
+	content := "# WebMoney\n\nWEBMONEY_WMID=405002833238\nWEBMONEY_KEY_FILE = '/etc/webmoney/key.kwm'\n" +
+		"WEBMONEY_KEY_PASSWORD=\"pass=word\"\nWEBMONEY_ENDPOINT=http://file\n"
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+
+	cfg, err := loadConfig(path, getenv(map[string]string{envEndpoint: "http://env"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "405002833238", cfg.wmId)
+	assert.Equal(t, "", cfg.key)
+	assert.Equal(t, "/etc/webmoney/key.kwm", cfg.keyFile)
+	assert.Equal(t, "pass=word", cfg.password)
+	assert.Equal(t, "http://env", cfg.endpoint)
+	assert.Len(t, cfg.clientOptions(), 5)
+	assert.Len(t, cfg.signerOptions(), 4)
+}
+
+func TestWmCtl_LoadConfig_Error(t *testing.T) {
+	_, err := loadConfig(filepath.Join(t.TempDir(), "unknown.env"), getenv(nil))
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "wmctl.env")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("WEBMONEY_WMID\n"), 0600))
+
+	_, err = loadConfig(path, getenv(nil))
+	assert.Equal(t, errorConfigLineIncorrect, err)
+}
@@ -0,0 +1,114 @@
+// Command wmctl runs everyday operations with WebMoney XML interfaces.
+//
+// Usage:
+//
+//	wmctl [-config path] [-output table|json] <command> [flags]
+//
+// The commands are:
+//
+//	balance   prints the purses of WMID with their balances (X9)
+//	history   prints the operations of the purse for the period (X3)
+//	transfer  transfers money between purses after confirmation (X2)
+//	sign      prints the signature of the data for debugging
+//
+// WMID, the key and the password are read from WEBMONEY_WMID, WEBMONEY_KEY (base64) or WEBMONEY_KEY_FILE
+// and WEBMONEY_KEY_PASSWORD environment variables or from the config file of NAME=value lines.
+// WEBMONEY_ENDPOINT overrides the base URL of WebMoney XML interfaces.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/sidmal/webmoney"
+	"github.com/sidmal/webmoney/signer"
+	"io"
+	"os"
+	"os/signal"
+)
+
+const (
+	outputTable = "table"
+	outputJson  = "json"
+)
+
+var (
+	errorCommandRequired = errors.New("the command is required: balance, history, transfer or sign")
+	errorCommandUnknown  = errors.New("the command is unknown")
+	errorOutputUnknown   = errors.New("the output format must be table or json")
+)
+
+// environment is the input and output of the command and the factories of WebMoney client and signer
+type environment struct {
+	stdin     io.Reader
+	stdout    io.Writer
+	getenv    func(string) string
+	newClient func(opts ...webmoney.Option) (webmoney.XMLInterface, error)
+	newSigner func(opts ...signer.Option) (signer.WebMoneySignerInterface, error)
+}
+
+type command struct {
+	cfg    *config
+	output string
+	env    *environment
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	env := &environment{
+		stdin:     os.Stdin,
+		stdout:    os.Stdout,
+		getenv:    os.Getenv,
+		newClient: webmoney.NewWebMoney,
+		newSigner: signer.NewSigner,
+	}
+
+	if err := run(ctx, os.Args[1:], env); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "wmctl:", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, env *environment) error {
+	flags := flag.NewFlagSet("wmctl", flag.ContinueOnError)
+	configPath := flags.String("config", "", "the path to the config file of NAME=value lines")
+	output := flags.String("output", outputTable, "the output format: table or json")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *output != outputTable && *output != outputJson {
+		return errorOutputUnknown
+	}
+
+	if flags.NArg() == 0 {
+		return errorCommandRequired
+	}
+
+	cfg, err := loadConfig(*configPath, env.getenv)
+
+	if err != nil {
+		return err
+	}
+
+	cmd := &command{cfg: cfg, output: *output, env: env}
+	name, args := flags.Arg(0), flags.Args()[1:]
+
+	switch name {
+	case "balance":
+		return cmd.balance(args)
+	case "history":
+		return cmd.history(ctx, args)
+	case "transfer":
+		return cmd.transfer(args)
+	case "sign":
+		return cmd.sign(args)
+	}
+
+	return fmt.Errorf("%w: %s", errorCommandUnknown, name)
+}
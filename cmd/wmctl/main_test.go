@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/sidmal/webmoney"
+	"github.com/sidmal/webmoney/signer"
+	"github.com/sidmal/webmoney/wmtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+)
+
+const (
+	TestWmId      = "405002833238"
+	TestPassword  = "FvGqPdAy8reVWw789"
+	TestPurseSrc  = "Z405002833238"
+	TestPurseDest = "Z123456789012"
+)
+
+type WmCtlTestSuite struct {
+	suite.Suite
+	server    *wmtest.Server
+	client    webmoney.XMLInterface
+	env       map[string]string
+	stdin     *bytes.Buffer
+	stdout    *bytes.Buffer
+	publicKey *signer.PublicKey
+}
+
+func Test_WmCtl(t *testing.T) {
+	suite.Run(t, new(WmCtlTestSuite))
+}
+
+func (suite *WmCtlTestSuite) SetupTest() {
+	key, publicKey, err := signer.GenerateKey(nil, TestWmId, TestPassword)
+
+	if err != nil {
+		suite.FailNow("Key generation failed", "%v", err)
+	}
+
+	suite.server = wmtest.NewServer()
+	suite.server.AddPurse(TestWmId, TestPurseSrc, 10000)
+	suite.server.AddPurse("123456789012", TestPurseDest, 0)
+
+	suite.publicKey = publicKey
+	suite.stdin, suite.stdout = new(bytes.Buffer), new(bytes.Buffer)
+	suite.env = map[string]string{
+		envWmId:     TestWmId,
+		envKey:      base64.StdEncoding.EncodeToString(key),
+		envPassword: TestPassword,
+		envEndpoint: suite.server.URL,
+	}
+}
+
+func (suite *WmCtlTestSuite) TearDownTest() {
+	suite.server.Close()
+	suite.client = nil
+}
+
+// newClient reuses the client of the first command, the commands of the test run within the same millisecond
+// and the new client would send the reqn not greater than the previous one
+func (suite *WmCtlTestSuite) newClient(opts ...webmoney.Option) (webmoney.XMLInterface, error) {
+	if suite.client != nil {
+		return suite.client, nil
+	}
+
+	client, err := webmoney.NewWebMoney(opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	suite.client = client
+
+	return client, nil
+}
+
+func (suite *WmCtlTestSuite) run(args ...string) error {
+	env := &environment{
+		stdin:     suite.stdin,
+		stdout:    suite.stdout,
+		getenv:    getenv(suite.env),
+		newClient: suite.newClient,
+		newSigner: signer.NewSigner,
+	}
+
+	return run(context.Background(), args, env)
+}
+
+func (suite *WmCtlTestSuite) TestWmCtl_Balance_Table_Ok() {
+	assert.NoError(suite.T(), suite.run("balance"))
+
+	lines := strings.Split(strings.TrimSpace(suite.stdout.String()), "\n")
+	assert.Len(suite.T(), lines, 2)
+	assert.Regexp(suite.T(), `^PURSE\s+AMOUNT\s+DESC$`, lines[0])
+	assert.Regexp(suite.T(), `^Z405002833238\s+100`, lines[1])
+}
+
+func (suite *WmCtlTestSuite) TestWmCtl_Transfer_History_Json_Ok() {
+	suite.stdin.WriteString("yes\n")
+	err := suite.run("-output", "json", "transfer", "-from", TestPurseSrc, "-to", TestPurseDest, "-amount", "10.5",
+		"-tranid", "1", "-desc", "Test payment")
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), suite.stdout.String(), "Transfer 10.5 WMZ from Z405002833238 to Z123456789012, tranid 1")
+	assert.Contains(suite.T(), suite.stdout.String(), `"amount": "10.5"`)
+
+	balance, _ := suite.server.Balance(TestPurseDest)
+	assert.EqualValues(suite.T(), 1050, balance)
+
+	suite.stdout.Reset()
+	assert.NoError(suite.T(), suite.run("-output", "json", "history", "-purse", TestPurseSrc))
+	assert.Contains(suite.T(), suite.stdout.String(), `"tranid": "1"`)
+	assert.Contains(suite.T(), suite.stdout.String(), `"desc": "Test payment"`)
+}
+
+func (suite *WmCtlTestSuite) TestWmCtl_Transfer_DryRun_Ok() {
+	err := suite.run("transfer", "-from", TestPurseSrc, "-to", TestPurseDest, "-amount", "1", "-tranid", "1", "-dry-run")
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), suite.stdout.String(), "dry run, not sent")
+	assert.Empty(suite.T(), suite.server.Operations())
+}
+
+func (suite *WmCtlTestSuite) TestWmCtl_Transfer_Cancelled_Error() {
+	suite.stdin.WriteString("n\n")
+	err := suite.run("transfer", "-from", TestPurseSrc, "-to", TestPurseDest, "-amount", "1", "-tranid", "1")
+	assert.Equal(suite.T(), errorTransferCancelled, err)
+	assert.Empty(suite.T(), suite.server.Operations())
+
+	err = suite.run("transfer", "-from", TestPurseSrc, "-to", TestPurseDest, "-amount", "1", "-tranid", "1")
+	assert.Equal(suite.T(), errorTransferCancelled, err)
+}
+
+func (suite *WmCtlTestSuite) TestWmCtl_Transfer_Yes_Error() {
+	err := suite.run("transfer", "-from", TestPurseSrc, "-to", TestPurseDest, "-amount", "1000", "-tranid", "1", "-yes")
+
+	var rspErr *webmoney.ResponseError
+	assert.True(suite.T(), errors.As(err, &rspErr))
+	assert.Equal(suite.T(), wmtest.CodeInsufficientFunds, rspErr.Code)
+
+	err = suite.run("transfer", "-from", TestPurseSrc, "-to", TestPurseDest, "-amount", "1")
+	assert.Equal(suite.T(), errorTransferIncorrect, err)
+
+	err = suite.run("transfer", "-from", TestPurseSrc, "-to", TestPurseDest, "-amount", "abc", "-tranid", "1")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *WmCtlTestSuite) TestWmCtl_Sign_Ok() {
+	verifier, err := suite.publicKey.Verifier()
+	assert.NoError(suite.T(), err)
+
+	assert.NoError(suite.T(), suite.run("-output", "json", "sign", "-data", "405002833238"))
+	assert.Contains(suite.T(), suite.stdout.String(), `"signature"`)
+
+	suite.stdout.Reset()
+	suite.stdin.WriteString("405002833238\n")
+	assert.NoError(suite.T(), suite.run("sign"))
+
+	lines := strings.Split(strings.TrimSpace(suite.stdout.String()), "\n")
+	assert.Len(suite.T(), lines, 2)
+	assert.NoError(suite.T(), verifier.Verify("405002833238", strings.TrimSpace(lines[1])))
+}
+
+func (suite *WmCtlTestSuite) TestWmCtl_Run_Error() {
+	assert.Equal(suite.T(), errorCommandRequired, suite.run())
+	assert.Equal(suite.T(), errorOutputUnknown, suite.run("-output", "xml", "balance"))
+	assert.True(suite.T(), errors.Is(suite.run("unknown"), errorCommandUnknown))
+	assert.Error(suite.T(), suite.run("-unknown"))
+	assert.Error(suite.T(), suite.run("-config", "/unknown/wmctl.env", "balance"))
+	assert.Equal(suite.T(), errorPurseRequired, suite.run("history"))
+	assert.Equal(suite.T(), errorDateIncorrect, suite.run("history", "-purse", TestPurseSrc, "-from", "yesterday"))
+
+	suite.env[envPassword] = ""
+	assert.Equal(suite.T(), signer.ErrorPasswordNotConfigured, suite.run("balance"))
+	assert.Equal(suite.T(), signer.ErrorPasswordNotConfigured, suite.run("sign", "-data", "1"))
+}
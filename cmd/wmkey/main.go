@@ -0,0 +1,116 @@
+// Command wmkey re-encrypts WebMoney Pro *.kwm key with the new password.
+//
+// Usage:
+//
+//	wmkey -wmid 123456789012 -in old.kwm -out new.kwm [-format kwm|base64]
+//
+// The input key may be *.kwm file or its base64 encoding. The current and the new passwords
+// are read from WEBMONEY_KEY_PASSWORD and WEBMONEY_KEY_NEW_PASSWORD environment variables
+// or from the first two lines of the standard input if the variables are not set.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/sidmal/webmoney/signer"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	formatKwm    = "kwm"
+	formatBase64 = "base64"
+
+	envPassword    = "WEBMONEY_KEY_PASSWORD"
+	envNewPassword = "WEBMONEY_KEY_NEW_PASSWORD"
+)
+
+var (
+	errorFormatUnknown = errors.New("the output format must be kwm or base64")
+	errorPathsRequired = errors.New("the input and output paths are required")
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Getenv); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "wmkey:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, getenv func(string) string) error {
+	flags := flag.NewFlagSet("wmkey", flag.ContinueOnError)
+	wmId := flags.String("wmid", "", "the WMID of the key")
+	in := flags.String("in", "", "the path to the current *.kwm or base64 key")
+	out := flags.String("out", "", "the path to write the re-encrypted key")
+	format := flags.String("format", formatKwm, "the output format: kwm or base64")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *in == "" || *out == "" {
+		return errorPathsRequired
+	}
+
+	if *format != formatKwm && *format != formatBase64 {
+		return errorFormatUnknown
+	}
+
+	key, err := readKey(*in)
+
+	if err != nil {
+		return err
+	}
+
+	password, newPassword := getenv(envPassword), getenv(envNewPassword)
+
+	if password == "" || newPassword == "" {
+		password, newPassword = readPasswords(stdin, password, newPassword)
+	}
+
+	result, err := signer.ReEncryptKey(key, *wmId, password, newPassword)
+
+	if err != nil {
+		return err
+	}
+
+	if *format == formatBase64 {
+		result = []byte(base64.StdEncoding.EncodeToString(result) + "\n")
+	}
+
+	return ioutil.WriteFile(*out, result, 0600)
+}
+
+// readKey reads *.kwm key as is or decodes it from base64 if the file is not the binary key
+func readKey(path string) ([]byte, error) {
+	b, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(b))); err == nil {
+		return decoded, nil
+	}
+
+	return b, nil
+}
+
+// readPasswords reads the not set passwords from the lines of the reader
+func readPasswords(r io.Reader, password, newPassword string) (string, string) {
+	scanner := bufio.NewScanner(r)
+
+	for _, val := range []*string{&password, &newPassword} {
+		if *val == "" && scanner.Scan() {
+			*val = strings.TrimRight(scanner.Text(), "\r")
+		}
+	}
+
+	return password, newPassword
+}
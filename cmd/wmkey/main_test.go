@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/base64"
+	"github.com/sidmal/webmoney/signer"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	TestWmId     = "405002833238"
+	TestKey      = "gQABADCWZW2w1EMgHCYswfVPdf6MAAAAAAAAAEIADHN9yDTlBIQnJd4W/Rk+UDGhrYiYoC5yVGjSkV9GFSkLFKgMk2r2bJDnFUAub2sc9vjXbpkcUlS8QX60Ti83ECQXbomCybZS4zN/pO0IJU77H3FBeFOvjh32PLswJaEqKGCIgU7lydVsT7KBJd9vfNhYaRNVnbH5NQdF+nmDv373G+Ovt9Y="
+	TestPassword = "FvGqPdAy8reVWw789"
+)
+
+func getenv(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestWmKey_Run_Kwm_Ok(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "old.kwm"), filepath.Join(dir, "new.kwm")
+	key, err := base64.StdEncoding.DecodeString(TestKey)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(in, key, 0600))
+
+	env := map[string]string{envPassword: TestPassword, envNewPassword: "NewPassword"}
+	err = run([]string{"-wmid", TestWmId, "-in", in, "-out", out}, strings.NewReader(""), getenv(env))
+	assert.NoError(t, err)
+
+	_, err = signer.NewSigner(signer.WmId(TestWmId), signer.KeyFile(out), signer.Password("NewPassword"))
+	assert.NoError(t, err)
+}
+
+func TestWmKey_Run_Base64_Stdin_Ok(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "old.txt"), filepath.Join(dir, "new.txt")
+	assert.NoError(t, ioutil.WriteFile(in, []byte(TestKey+"\n"), 0600))
+
+	stdin := strings.NewReader(TestPassword + "\r\nNewPassword\n")
+	err := run([]string{"-wmid", TestWmId, "-in", in, "-out", out, "-format", "base64"}, stdin, getenv(nil))
+	assert.NoError(t, err)
+
+	b, err := ioutil.ReadFile(out)
+	assert.NoError(t, err)
+
+	_, err = signer.NewSigner(
+		signer.WmId(TestWmId),
+		signer.Key(strings.TrimSpace(string(b))),
+		signer.Password("NewPassword"),
+	)
+	assert.NoError(t, err)
+}
+
+func TestWmKey_Run_Error(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "old.txt"), filepath.Join(dir, "new.txt")
+	assert.NoError(t, ioutil.WriteFile(in, []byte(TestKey), 0600))
+	env := map[string]string{envPassword: "password", envNewPassword: "NewPassword"}
+
+	err := run([]string{"-wmid", TestWmId, "-in", in}, strings.NewReader(""), getenv(env))
+	assert.Equal(t, errorPathsRequired, err)
+
+	err = run([]string{"-wmid", TestWmId, "-in", in, "-out", out, "-format", "pem"}, strings.NewReader(""), getenv(env))
+	assert.Equal(t, errorFormatUnknown, err)
+
+	err = run([]string{"-wmid", TestWmId, "-in", filepath.Join(dir, "unknown"), "-out", out}, strings.NewReader(""), getenv(env))
+	assert.Error(t, err)
+
+	err = run([]string{"-wmid", TestWmId, "-in", in, "-out", out}, strings.NewReader(""), getenv(env))
+	assert.Equal(t, signer.ErrorKeyPasswordIncorrect, err)
+
+	err = run([]string{"-wmid", TestWmId, "-in", in, "-out", out}, strings.NewReader(""), getenv(nil))
+	assert.Equal(t, signer.ErrorPasswordNotConfigured, err)
+
+	err = run([]string{"-unknown"}, strings.NewReader(""), getenv(nil))
+	assert.Error(t, err)
+}
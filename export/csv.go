@@ -0,0 +1,73 @@
+package export
+
+import (
+	"encoding/csv"
+	"github.com/sidmal/webmoney"
+	"io"
+	"strconv"
+)
+
+var (
+	csvHeader = []string{
+		"id", "tranid", "date", "direction", "pursesrc", "pursedest", "corrwm", "amount", "comiss", "rest", "desc",
+		"wminvid", "period", "opertype",
+	}
+)
+
+// CSVExporter writes the operations as CSV with the header, the amount of outgoing operations is negative
+type CSVExporter struct {
+	purse  webmoney.Purse
+	writer *csv.Writer
+	header bool
+}
+
+func NewCSVExporter(w io.Writer, purse webmoney.Purse) Exporter {
+	return &CSVExporter{purse: purse, writer: csv.NewWriter(w)}
+}
+
+func (m *CSVExporter) Write(operation *webmoney.TransferMoneyResponse) error {
+	if err := m.writeHeader(); err != nil {
+		return err
+	}
+
+	e := newEntry(m.purse, operation)
+	row := []string{
+		e.Id,
+		strconv.FormatInt(e.TxnId, 10),
+		e.Date,
+		e.Direction,
+		e.PurseSrc,
+		e.PurseDest,
+		e.CorrWm,
+		e.Amount,
+		e.Commission,
+		e.Rest,
+		e.Desc,
+		strconv.Itoa(e.WmInvId),
+		strconv.Itoa(e.Period),
+		e.OperationType,
+	}
+
+	return m.writer.Write(row)
+}
+
+// Close writes the header if there are no operations and flushes the output
+func (m *CSVExporter) Close() error {
+	if err := m.writeHeader(); err != nil {
+		return err
+	}
+
+	m.writer.Flush()
+
+	return m.writer.Error()
+}
+
+func (m *CSVExporter) writeHeader() error {
+	if m.header {
+		return nil
+	}
+
+	m.header = true
+
+	return m.writer.Write(csvHeader)
+}
@@ -0,0 +1,35 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExport_CSVExporter_Ok(t *testing.T) {
+	out := new(bytes.Buffer)
+	assert.NoError(t, Export(NewCSVExporter(out, TestPurse), newTestOperations()))
+
+	rows, err := csv.NewReader(out).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, csvHeader, rows[0])
+	assert.Equal(
+		t,
+		[]string{
+			"1002", "2", "2020-09-02T12:00:00+03:00", "out", "Z123456789012", "Z210987654321", "210987654321",
+			"-10.5", "0.09", "89.41", "Возврат", "7", "0", "",
+		},
+		rows[2],
+	)
+}
+
+func TestExport_CSVExporter_Empty_Ok(t *testing.T) {
+	out := new(bytes.Buffer)
+	assert.NoError(t, Export(NewCSVExporter(out, TestPurse), nil))
+
+	rows, err := csv.NewReader(out).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{csvHeader}, rows)
+}
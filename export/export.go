@@ -0,0 +1,118 @@
+// Package export writes WebMoney transactions history in the formats of accounting tools: CSV, JSON Lines and OFX 2.x
+package export
+
+import (
+	"github.com/sidmal/webmoney"
+	"golang.org/x/text/encoding/charmap"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	DirectionIn  = "in"
+	DirectionOut = "out"
+)
+
+// Exporter writes the operations of the purse, Close completes the output and must be called after the last operation
+type Exporter interface {
+	Write(operation *webmoney.TransferMoneyResponse) error
+	Close() error
+}
+
+// Iterator is the source of the operations, e.g. webmoney.TransactionsCursor
+type Iterator interface {
+	Next() bool
+	Operation() *webmoney.TransferMoneyResponse
+	Err() error
+}
+
+// entry is the operation from the purse point of view
+type entry struct {
+	Id            string `json:"id"`
+	TxnId         int64  `json:"tranid"`
+	Date          string `json:"date"`
+	Direction     string `json:"direction"`
+	PurseSrc      string `json:"pursesrc"`
+	PurseDest     string `json:"pursedest"`
+	CorrWm        string `json:"corrwm"`
+	Amount        string `json:"amount"`
+	Commission    string `json:"comiss"`
+	Rest          string `json:"rest"`
+	Desc          string `json:"desc"`
+	WmInvId       int    `json:"wminvid"`
+	Period        int    `json:"period"`
+	OperationType string `json:"opertype"`
+
+	operation *webmoney.TransferMoneyResponse
+	outgoing  bool
+}
+
+// Export writes the operations and closes the exporter
+func Export(exporter Exporter, operations []*webmoney.TransferMoneyResponse) error {
+	for _, operation := range operations {
+		if err := exporter.Write(operation); err != nil {
+			return err
+		}
+	}
+
+	return exporter.Close()
+}
+
+// ExportIterator writes the operations of the iterator and closes the exporter
+func ExportIterator(exporter Exporter, iterator Iterator) error {
+	for iterator.Next() {
+		if err := exporter.Write(iterator.Operation()); err != nil {
+			return err
+		}
+	}
+
+	if err := iterator.Err(); err != nil {
+		return err
+	}
+
+	return exporter.Close()
+}
+
+func newEntry(purse webmoney.Purse, operation *webmoney.TransferMoneyResponse) *entry {
+	e := &entry{
+		Id:            operation.Id,
+		TxnId:         operation.TxnId,
+		Date:          operation.DateCrt.Format(time.RFC3339),
+		Direction:     DirectionIn,
+		PurseSrc:      operation.PurseSrc.String(),
+		PurseDest:     operation.PurseDest.String(),
+		CorrWm:        operation.CorrWm,
+		Amount:        operation.Amount.String(),
+		Commission:    operation.Commission.String(),
+		Rest:          operation.Rest.String(),
+		Desc:          decodeDesc(operation.Desc),
+		WmInvId:       operation.WmInvId,
+		Period:        operation.Period,
+		OperationType: operation.OperationType,
+		operation:     operation,
+		outgoing:      operation.PurseSrc == purse,
+	}
+
+	if e.outgoing {
+		e.Direction = DirectionOut
+		e.Amount = (-operation.Amount).String()
+	}
+
+	return e
+}
+
+// decodeDesc returns the description in UTF-8. The description is decoded from windows-1251 by XML decoder
+// of the response, but it is kept as is if the response has no encoding declaration
+func decodeDesc(desc string) string {
+	if utf8.ValidString(desc) {
+		return desc
+	}
+
+	decoded, err := charmap.Windows1251.NewDecoder().String(desc)
+
+	if err != nil {
+		return desc
+	}
+
+	return decoded
+}
@@ -0,0 +1,129 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"github.com/sidmal/webmoney"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/charmap"
+	"testing"
+	"time"
+)
+
+const (
+	TestPurse = webmoney.Purse("Z123456789012")
+)
+
+type sliceIterator struct {
+	operations []*webmoney.TransferMoneyResponse
+	current    *webmoney.TransferMoneyResponse
+	err        error
+}
+
+func (m *sliceIterator) Next() bool {
+	if len(m.operations) == 0 {
+		return false
+	}
+
+	m.current, m.operations = m.operations[0], m.operations[1:]
+
+	return true
+}
+
+func (m *sliceIterator) Operation() *webmoney.TransferMoneyResponse {
+	return m.current
+}
+
+func (m *sliceIterator) Err() error {
+	return m.err
+}
+
+type failingExporter struct {
+	Exporter
+}
+
+func (m *failingExporter) Write(_ *webmoney.TransferMoneyResponse) error {
+	return errors.New("failingExporter")
+}
+
+func newTestOperations() []*webmoney.TransferMoneyResponse {
+	location := time.FixedZone("MSK", 3*3600)
+
+	return []*webmoney.TransferMoneyResponse{
+		{
+			Id:         "1001",
+			TxnId:      1,
+			PurseSrc:   "Z210987654321",
+			PurseDest:  TestPurse,
+			Amount:     10000,
+			Commission: 80,
+			Rest:       10000,
+			CorrWm:     "210987654321",
+			Desc:       "Invoice & payment",
+			DateCrt:    webmoney.NewWMTime(time.Date(2020, 9, 1, 12, 0, 0, 0, location)),
+		},
+		{
+			Id:         "1002",
+			TxnId:      2,
+			PurseSrc:   TestPurse,
+			PurseDest:  "Z210987654321",
+			Amount:     1050,
+			Commission: 9,
+			Rest:       8941,
+			CorrWm:     "210987654321",
+			Desc:       "Возврат",
+			WmInvId:    7,
+			DateCrt:    webmoney.NewWMTime(time.Date(2020, 9, 2, 12, 0, 0, 0, location)),
+		},
+	}
+}
+
+func TestExport_NewEntry_Ok(t *testing.T) {
+	operations := newTestOperations()
+
+	in := newEntry(TestPurse, operations[0])
+	assert.Equal(t, DirectionIn, in.Direction)
+	assert.Equal(t, "100", in.Amount)
+	assert.Equal(t, "2020-09-01T12:00:00+03:00", in.Date)
+
+	out := newEntry(TestPurse, operations[1])
+	assert.Equal(t, DirectionOut, out.Direction)
+	assert.Equal(t, "-10.5", out.Amount)
+	assert.Equal(t, "0.09", out.Commission)
+	assert.Equal(t, "89.41", out.Rest)
+	assert.Equal(t, "210987654321", out.CorrWm)
+}
+
+func TestExport_DecodeDesc_Ok(t *testing.T) {
+	win, err := charmap.Windows1251.NewEncoder().String("Оплата заказа")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Оплата заказа", decodeDesc(win))
+	assert.Equal(t, "Оплата заказа", decodeDesc("Оплата заказа"))
+	assert.Equal(t, "Payment", decodeDesc("Payment"))
+}
+
+func TestExport_ExportIterator_Ok(t *testing.T) {
+	out := new(bytes.Buffer)
+	iterator := &sliceIterator{operations: newTestOperations()}
+
+	assert.NoError(t, ExportIterator(NewJSONLinesExporter(out, TestPurse), iterator))
+	assert.Equal(t, 2, bytes.Count(out.Bytes(), []byte("\n")))
+}
+
+func TestExport_Error(t *testing.T) {
+	out := new(bytes.Buffer)
+	exporter := &failingExporter{Exporter: NewCSVExporter(out, TestPurse)}
+
+	assert.EqualError(t, Export(exporter, newTestOperations()), "failingExporter")
+	assert.EqualError(
+		t,
+		ExportIterator(exporter, &sliceIterator{operations: newTestOperations()}),
+		"failingExporter",
+	)
+	assert.EqualError(
+		t,
+		ExportIterator(NewCSVExporter(out, TestPurse), &sliceIterator{err: errors.New("iterator")}),
+		"iterator",
+	)
+}
@@ -0,0 +1,25 @@
+package export
+
+import (
+	"encoding/json"
+	"github.com/sidmal/webmoney"
+	"io"
+)
+
+// JSONLinesExporter writes each operation as JSON object on its own line
+type JSONLinesExporter struct {
+	purse   webmoney.Purse
+	encoder *json.Encoder
+}
+
+func NewJSONLinesExporter(w io.Writer, purse webmoney.Purse) Exporter {
+	return &JSONLinesExporter{purse: purse, encoder: json.NewEncoder(w)}
+}
+
+func (m *JSONLinesExporter) Write(operation *webmoney.TransferMoneyResponse) error {
+	return m.encoder.Encode(newEntry(m.purse, operation))
+}
+
+func (m *JSONLinesExporter) Close() error {
+	return nil
+}
@@ -0,0 +1,28 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestExport_JSONLinesExporter_Ok(t *testing.T) {
+	out := new(bytes.Buffer)
+	assert.NoError(t, Export(NewJSONLinesExporter(out, TestPurse), newTestOperations()))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var item map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &item))
+	assert.Equal(t, "1002", item["id"])
+	assert.EqualValues(t, 2, item["tranid"])
+	assert.Equal(t, "out", item["direction"])
+	assert.Equal(t, "-10.5", item["amount"])
+	assert.Equal(t, "0.09", item["comiss"])
+	assert.Equal(t, "89.41", item["rest"])
+	assert.Equal(t, "210987654321", item["corrwm"])
+	assert.Equal(t, "Возврат", item["desc"])
+}
@@ -0,0 +1,192 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/sidmal/webmoney"
+	"io"
+	"time"
+)
+
+const (
+	ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+		`<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	ofxBankId   = "WEBMONEY"
+	ofxTimeMask = "20060102150405"
+
+	ofxTypeCredit        = "CREDIT"
+	ofxTypeDebit         = "DEBIT"
+	ofxTypeServiceCharge = "SRVCHG"
+)
+
+var (
+	// The ISO 4217 codes of WebMoney title units, the title unit name is used for others
+	ofxCurrencies = map[webmoney.Currency]string{
+		webmoney.CurrencyWMZ: "USD",
+		webmoney.CurrencyWME: "EUR",
+		webmoney.CurrencyWMR: "RUB",
+		webmoney.CurrencyWMU: "UAH",
+		webmoney.CurrencyWMB: "BYN",
+		webmoney.CurrencyWMK: "KZT",
+		webmoney.CurrencyWMG: "XAU",
+	}
+)
+
+// OFXExporter writes the operations as OFX 2.1.1 bank statement of the purse. The statement is written on Close,
+// the commission of the outgoing operation is the separate service charge transaction, the ledger balance
+// is the rest of the purse after the latest operation
+type OFXExporter struct {
+	w          io.Writer
+	purse      webmoney.Purse
+	now        func() time.Time
+	operations []*webmoney.TransferMoneyResponse
+}
+
+type ofx struct {
+	XMLName xml.Name             `xml:"OFX"`
+	SignOn  ofxSignOn            `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank    ofxStatementResponse `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	DtServer string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxStatementResponse struct {
+	TrnUid    string       `xml:"TRNUID"`
+	Status    ofxStatus    `xml:"STATUS"`
+	Statement ofxStatement `xml:"STMTRS"`
+}
+
+type ofxStatement struct {
+	Currency  string            `xml:"CURDEF"`
+	BankId    string            `xml:"BANKACCTFROM>BANKID"`
+	AccountId string            `xml:"BANKACCTFROM>ACCTID"`
+	AccType   string            `xml:"BANKACCTFROM>ACCTTYPE"`
+	DtStart   string            `xml:"BANKTRANLIST>DTSTART"`
+	DtEnd     string            `xml:"BANKTRANLIST>DTEND"`
+	Trans     []*ofxTransaction `xml:"BANKTRANLIST>STMTTRN"`
+	Balance   string            `xml:"LEDGERBAL>BALAMT"`
+	DtAsOf    string            `xml:"LEDGERBAL>DTASOF"`
+}
+
+type ofxTransaction struct {
+	Type     string `xml:"TRNTYPE"`
+	DtPosted string `xml:"DTPOSTED"`
+	Amount   string `xml:"TRNAMT"`
+	FitId    string `xml:"FITID"`
+	CheckNum int64  `xml:"CHECKNUM,omitempty"`
+	Name     string `xml:"NAME,omitempty"`
+	Memo     string `xml:"MEMO,omitempty"`
+}
+
+func NewOFXExporter(w io.Writer, purse webmoney.Purse) Exporter {
+	return &OFXExporter{w: w, purse: purse, now: time.Now}
+}
+
+func (m *OFXExporter) Write(operation *webmoney.TransferMoneyResponse) error {
+	m.operations = append(m.operations, operation)
+	return nil
+}
+
+// Close writes the statement of the written operations
+func (m *OFXExporter) Close() error {
+	now := m.now()
+	status := ofxStatus{Code: 0, Severity: "INFO"}
+	statement := ofxStatement{
+		Currency:  string(m.purse.Currency()),
+		BankId:    ofxBankId,
+		AccountId: m.purse.String(),
+		AccType:   "CHECKING",
+		DtStart:   ofxTime(now),
+		DtEnd:     ofxTime(now),
+		Balance:   "0",
+		DtAsOf:    ofxTime(now),
+	}
+
+	if currency, ok := ofxCurrencies[m.purse.Currency()]; ok {
+		statement.Currency = currency
+	}
+
+	var start, end time.Time
+
+	for _, operation := range m.operations {
+		e := newEntry(m.purse, operation)
+		date := operation.DateCrt.Time
+		transaction := &ofxTransaction{
+			Type:     ofxTypeCredit,
+			DtPosted: ofxTime(date),
+			Amount:   e.Amount,
+			FitId:    operation.Id,
+			CheckNum: operation.TxnId,
+			Name:     operation.CorrWm,
+			Memo:     e.Desc,
+		}
+
+		if e.outgoing {
+			transaction.Type = ofxTypeDebit
+		}
+
+		statement.Trans = append(statement.Trans, transaction)
+
+		if e.outgoing && operation.Commission > 0 {
+			fee := &ofxTransaction{
+				Type:     ofxTypeServiceCharge,
+				DtPosted: ofxTime(date),
+				Amount:   (-operation.Commission).String(),
+				FitId:    operation.Id + "-comiss",
+				Memo:     "WebMoney commission",
+			}
+			statement.Trans = append(statement.Trans, fee)
+		}
+
+		if start.IsZero() || date.Before(start) {
+			start = date
+		}
+
+		// The rest of the latest operation is the current balance
+		if end.IsZero() || !date.Before(end) {
+			end = date
+			statement.Balance = operation.Rest.String()
+			statement.DtAsOf = ofxTime(date)
+		}
+	}
+
+	if !start.IsZero() {
+		statement.DtStart, statement.DtEnd = ofxTime(start), ofxTime(end)
+	}
+
+	doc := &ofx{
+		SignOn: ofxSignOn{Status: status, DtServer: ofxTime(now), Language: "ENG"},
+		Bank:   ofxStatementResponse{TrnUid: "0", Status: status, Statement: statement},
+	}
+	b, err := xml.MarshalIndent(doc, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = m.w.Write(append(append([]byte(ofxHeader), b...), '\n'))
+
+	return err
+}
+
+// ofxTime returns OFX datetime with the time zone offset, e.g. 20200901120000.000[+3:MSK]
+func ofxTime(t time.Time) string {
+	name, offset := t.Zone()
+	hours := float64(offset) / 3600
+	zone := fmt.Sprintf("%+g", hours)
+
+	if name == "" || name[0] == '+' || name[0] == '-' {
+		return t.Format(ofxTimeMask) + ".000[" + zone + "]"
+	}
+
+	return t.Format(ofxTimeMask) + ".000[" + zone + ":" + name + "]"
+}
@@ -0,0 +1,63 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExport_OFXExporter_Ok(t *testing.T) {
+	out := new(bytes.Buffer)
+	assert.NoError(t, Export(NewOFXExporter(out, TestPurse), newTestOperations()))
+	assert.True(t, strings.HasPrefix(out.String(), ofxHeader))
+
+	doc := new(ofx)
+	assert.NoError(t, xml.Unmarshal(out.Bytes(), doc))
+
+	statement := doc.Bank.Statement
+	assert.Equal(t, "USD", statement.Currency)
+	assert.Equal(t, "Z123456789012", statement.AccountId)
+	assert.Equal(t, "20200901120000.000[+3:MSK]", statement.DtStart)
+	assert.Equal(t, "20200902120000.000[+3:MSK]", statement.DtEnd)
+	assert.Equal(t, "89.41", statement.Balance)
+	assert.Len(t, statement.Trans, 3)
+
+	assert.Equal(t, &ofxTransaction{
+		Type:     ofxTypeCredit,
+		DtPosted: "20200901120000.000[+3:MSK]",
+		Amount:   "100",
+		FitId:    "1001",
+		CheckNum: 1,
+		Name:     "210987654321",
+		Memo:     "Invoice & payment",
+	}, statement.Trans[0])
+	assert.Equal(t, ofxTypeDebit, statement.Trans[1].Type)
+	assert.Equal(t, "-10.5", statement.Trans[1].Amount)
+	assert.Equal(t, "Возврат", statement.Trans[1].Memo)
+	assert.Equal(t, ofxTypeServiceCharge, statement.Trans[2].Type)
+	assert.Equal(t, "-0.09", statement.Trans[2].Amount)
+	assert.Equal(t, "1002-comiss", statement.Trans[2].FitId)
+}
+
+func TestExport_OFXExporter_Empty_Ok(t *testing.T) {
+	out := new(bytes.Buffer)
+	exporter := NewOFXExporter(out, "X123456789012").(*OFXExporter)
+	exporter.now = func() time.Time {
+		return time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC)
+	}
+	assert.NoError(t, exporter.Close())
+
+	doc := new(ofx)
+	assert.NoError(t, xml.Unmarshal(out.Bytes(), doc))
+	assert.Equal(t, "WMX", doc.Bank.Statement.Currency)
+	assert.Equal(t, "20200901120000.000[+0:UTC]", doc.Bank.Statement.DtStart)
+	assert.Empty(t, doc.Bank.Statement.Trans)
+}
+
+func TestExport_OfxTime_Ok(t *testing.T) {
+	assert.Equal(t, "20200901120000.000[+5.5]", ofxTime(time.Date(2020, 9, 1, 12, 0, 0, 0, time.FixedZone("", 19800))))
+	assert.Equal(t, "20200901120000.000[-3:BRT]", ofxTime(time.Date(2020, 9, 1, 12, 0, 0, 0, time.FixedZone("BRT", -10800))))
+}
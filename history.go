@@ -0,0 +1,161 @@
+package webmoney
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// TransactionsIterator is implemented by the clients iterating over transactions history by themselves,
+// e.g. with the history window option. Use IterateTransactions to iterate over the history of any XMLInterface
+type TransactionsIterator interface {
+	IterateTransactions(ctx context.Context, purse Purse, from, to time.Time) *TransactionsCursor
+}
+
+// TransactionsCursor iterates over transactions history of the purse for the arbitrary period.
+// The period is split to windows allowed by X3 interface, the operations returned on windows
+// boundaries twice are skipped
+type TransactionsCursor struct {
+	ctx         context.Context
+	fetchFn     func(ctx context.Context, in *GetTransactionsHistoryRequest) (*GetTransactionsHistoryResponse, error)
+	window      time.Duration
+	purse       Purse
+	windowStart time.Time
+	to          time.Time
+	buffer      []*TransferMoneyResponse
+	current     *TransferMoneyResponse
+	seen        map[string]struct{}
+	done        bool
+	err         error
+}
+
+// IterateTransactions returns cursor over purse operations of the client created from "from" to "to" inclusive
+// in chronological order. The client implementing TransactionsIterator iterates by itself, the history of other
+// clients is requested with GetTransactionsHistory by the default windows
+func IterateTransactions(ctx context.Context, client XMLInterface, purse Purse, from, to time.Time) *TransactionsCursor {
+	if iterator, ok := client.(TransactionsIterator); ok {
+		return iterator.IterateTransactions(ctx, purse, from, to)
+	}
+
+	fetchFn := func(_ context.Context, in *GetTransactionsHistoryRequest) (*GetTransactionsHistoryResponse, error) {
+		return client.GetTransactionsHistory(in)
+	}
+
+	return newTransactionsCursor(ctx, fetchFn, defaultHistoryWindow, purse, from, to)
+}
+
+// IterateTransactions returns cursor over purse operations created from "from" to "to" inclusive in chronological order
+func (m *WebMoney) IterateTransactions(ctx context.Context, purse Purse, from, to time.Time) *TransactionsCursor {
+	return newTransactionsCursor(ctx, m.getTransactionsHistory, m.options.historyWindow, purse, from, to)
+}
+
+func newTransactionsCursor(
+	ctx context.Context,
+	fetchFn func(ctx context.Context, in *GetTransactionsHistoryRequest) (*GetTransactionsHistoryResponse, error),
+	window time.Duration,
+	purse Purse,
+	from, to time.Time,
+) *TransactionsCursor {
+	return &TransactionsCursor{
+		ctx:         ctx,
+		fetchFn:     fetchFn,
+		window:      window,
+		purse:       purse,
+		windowStart: from,
+		to:          to,
+		seen:        make(map[string]struct{}),
+	}
+}
+
+// Next advances cursor to the next operation. It returns false when operations are exhausted or the error occurred
+func (m *TransactionsCursor) Next() bool {
+	if m.err != nil {
+		return false
+	}
+
+	for len(m.buffer) == 0 {
+		if m.done || m.windowStart.After(m.to) {
+			m.current = nil
+			return false
+		}
+
+		if m.err = m.ctx.Err(); m.err != nil {
+			m.current = nil
+			return false
+		}
+
+		if m.err = m.fetch(); m.err != nil {
+			m.current = nil
+			return false
+		}
+	}
+
+	m.current = m.buffer[0]
+	m.buffer = m.buffer[1:]
+
+	return true
+}
+
+// Operation returns the operation the cursor currently points to
+func (m *TransactionsCursor) Operation() *TransferMoneyResponse {
+	return m.current
+}
+
+// Err returns the error occurred during iteration
+func (m *TransactionsCursor) Err() error {
+	return m.err
+}
+
+func (m *TransactionsCursor) fetch() error {
+	windowFinish := m.windowStart.Add(m.window)
+
+	if windowFinish.After(m.to) {
+		windowFinish = m.to
+	}
+
+	in := &GetTransactionsHistoryRequest{
+		Purse:      m.purse,
+		DateStart:  NewWMTime(m.windowStart),
+		DateFinish: NewWMTime(windowFinish),
+	}
+	rsp, err := m.fetchFn(m.ctx, in)
+
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]struct{}, len(rsp.OperationList))
+
+	for _, operation := range rsp.OperationList {
+		if _, ok := m.seen[operation.Id]; ok {
+			continue
+		}
+
+		if _, ok := seen[operation.Id]; ok {
+			continue
+		}
+
+		seen[operation.Id] = struct{}{}
+		m.buffer = append(m.buffer, operation)
+	}
+
+	sort.SliceStable(m.buffer, func(i, j int) bool {
+		if !m.buffer[i].DateCrt.Equal(m.buffer[j].DateCrt.Time) {
+			return m.buffer[i].DateCrt.Before(m.buffer[j].DateCrt.Time)
+		}
+
+		if len(m.buffer[i].Id) != len(m.buffer[j].Id) {
+			return len(m.buffer[i].Id) < len(m.buffer[j].Id)
+		}
+
+		return m.buffer[i].Id < m.buffer[j].Id
+	})
+
+	// The next window starts from the finish of current one, so operations created
+	// exactly on the boundary are not lost and they are de-duplicated by the seen set
+	m.seen = seen
+	m.windowStart = windowFinish
+	m.done = !windowFinish.Before(m.to)
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package webmoney
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"github.com/sidmal/webmoney/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type historyTransport struct {
+	operations []*TransferMoneyResponse
+	requests   []*GetTransactionsHistoryRequest
+}
+
+type historyTransportRequest struct {
+	XMLName xml.Name                      `xml:"w3s.request"`
+	Request GetTransactionsHistoryRequest `xml:"getoperations"`
+}
+
+func (m *historyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	in := new(historyTransportRequest)
+	b, _ := ioutil.ReadAll(req.Body)
+
+	if err := xml.Unmarshal(b, in); err != nil {
+		return nil, err
+	}
+
+	m.requests = append(m.requests, &in.Request)
+	out := &GetTransactionsHistoryResponse{}
+
+	// WebMoney returns the newest operations first
+	for i := len(m.operations) - 1; i >= 0; i-- {
+		operation := m.operations[i]
+
+		if operation.DateCrt.Before(in.Request.DateStart.Time) || operation.DateCrt.After(in.Request.DateFinish.Time) {
+			continue
+		}
+
+		out.OperationList = append(out.OperationList, operation)
+	}
+
+	out.Count = int64(len(out.OperationList))
+	b, _ = xml.Marshal(&BaseResponse{RequestNumber: "1", Reason: "Ok", Response: out})
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		Header:     make(http.Header),
+	}, nil
+}
+
+type HistoryTestSuite struct {
+	suite.Suite
+	webmoney  *WebMoney
+	transport *historyTransport
+	from      time.Time
+}
+
+func Test_History(t *testing.T) {
+	suite.Run(t, new(HistoryTestSuite))
+}
+
+func (suite *HistoryTestSuite) SetupTest() {
+	suite.from = time.Date(2020, 9, 1, 0, 0, 0, 0, moscowLocation)
+	suite.transport = &historyTransport{}
+
+	for i := 0; i < 5; i++ {
+		suite.transport.operations = append(suite.transport.operations, &TransferMoneyResponse{
+			Id:        strconv.Itoa(100 + i),
+			TxnId:     int64(i),
+			PurseSrc:  "Z123456789012",
+			PurseDest: "Z098765432109",
+			Amount:    Amount(100 * (i + 1)),
+			DateCrt:   NewWMTime(suite.from.Add(time.Duration(i) * 12 * time.Hour)),
+		})
+	}
+
+	opts := []Option{
+		WmId(TestWmId),
+		Key(TestKey),
+		Password(TestPassword),
+		HistoryWindow(24 * time.Hour),
+		httpClient(&http.Client{Transport: suite.transport}),
+	}
+	wm, err := NewWebMoney(opts...)
+
+	if err != nil {
+		suite.FailNow("WebMoney handler initialization failed", "%v", err)
+	}
+
+	suite.webmoney = wm.(*WebMoney)
+}
+
+func (suite *HistoryTestSuite) TestHistory_IterateTransactions_Ok() {
+	cursor := suite.webmoney.IterateTransactions(
+		context.Background(),
+		"Z123456789012",
+		suite.from,
+		suite.from.Add(60*time.Hour),
+	)
+
+	var ids []string
+
+	for cursor.Next() {
+		ids = append(ids, cursor.Operation().Id)
+	}
+
+	assert.NoError(suite.T(), cursor.Err())
+	assert.Nil(suite.T(), cursor.Operation())
+	assert.Equal(suite.T(), []string{"100", "101", "102", "103", "104"}, ids)
+	assert.Len(suite.T(), suite.transport.requests, 3)
+
+	for i, req := range suite.transport.requests {
+		assert.EqualValues(suite.T(), "Z123456789012", req.Purse)
+		assert.True(suite.T(), req.DateStart.Equal(suite.from.Add(time.Duration(i)*24*time.Hour)))
+		assert.False(suite.T(), req.DateFinish.After(suite.from.Add(60*time.Hour)))
+	}
+
+	assert.False(suite.T(), cursor.Next())
+}
+
+func (suite *HistoryTestSuite) TestHistory_IterateTransactions_XMLInterface_Ok() {
+	// The client implementing XMLInterface only is iterated with GetTransactionsHistory by the default windows
+	client := struct{ XMLInterface }{XMLInterface: suite.webmoney}
+	cursor := IterateTransactions(context.Background(), client, "Z123456789012", suite.from, suite.from.Add(60*time.Hour))
+
+	var ids []string
+
+	for cursor.Next() {
+		ids = append(ids, cursor.Operation().Id)
+	}
+
+	assert.NoError(suite.T(), cursor.Err())
+	assert.Equal(suite.T(), []string{"100", "101", "102", "103", "104"}, ids)
+	assert.Len(suite.T(), suite.transport.requests, 1)
+
+	// The client implementing TransactionsIterator iterates by itself
+	suite.transport.requests = nil
+	cursor = IterateTransactions(context.Background(), suite.webmoney, "Z123456789012", suite.from, suite.from.Add(60*time.Hour))
+
+	for cursor.Next() {
+	}
+
+	assert.NoError(suite.T(), cursor.Err())
+	assert.Len(suite.T(), suite.transport.requests, 3)
+}
+
+func (suite *HistoryTestSuite) TestHistory_IterateTransactions_EmptyPeriod_Ok() {
+	cursor := suite.webmoney.IterateTransactions(
+		context.Background(),
+		"Z123456789012",
+		suite.from.Add(-48*time.Hour),
+		suite.from.Add(-time.Hour),
+	)
+	assert.False(suite.T(), cursor.Next())
+	assert.NoError(suite.T(), cursor.Err())
+	assert.Len(suite.T(), suite.transport.requests, 2)
+}
+
+func (suite *HistoryTestSuite) TestHistory_IterateTransactions_FromAfterTo_Ok() {
+	cursor := suite.webmoney.IterateTransactions(context.Background(), "Z123456789012", suite.from, suite.from.Add(-time.Second))
+	assert.False(suite.T(), cursor.Next())
+	assert.NoError(suite.T(), cursor.Err())
+	assert.Empty(suite.T(), suite.transport.requests)
+}
+
+func (suite *HistoryTestSuite) TestHistory_IterateTransactions_ContextCanceled_Error() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cursor := suite.webmoney.IterateTransactions(ctx, "Z123456789012", suite.from, suite.from.Add(60*time.Hour))
+
+	for i := 0; i < 3; i++ {
+		assert.True(suite.T(), cursor.Next())
+	}
+
+	cancel()
+	assert.False(suite.T(), cursor.Next())
+	assert.True(suite.T(), errors.Is(cursor.Err(), context.Canceled))
+	assert.Len(suite.T(), suite.transport.requests, 1)
+}
+
+func (suite *HistoryTestSuite) TestHistory_IterateTransactions_PurseIsIncorrect_Error() {
+	cursor := suite.webmoney.IterateTransactions(context.Background(), "Z1234", suite.from, suite.from.Add(time.Hour))
+	assert.False(suite.T(), cursor.Next())
+	assert.Equal(suite.T(), ErrorPurseIsIncorrect, cursor.Err())
+}
+
+func (suite *HistoryTestSuite) TestHistory_IterateTransactions_WebMoneyError() {
+	suite.webmoney.httpClient = mocks.NewTransportStatusWmError()
+	cursor := suite.webmoney.IterateTransactions(context.Background(), "Z123456789012", suite.from, suite.from.Add(time.Hour))
+	assert.False(suite.T(), cursor.Next())
+	assert.EqualError(suite.T(), cursor.Err(), "Mock error")
+	assert.False(suite.T(), cursor.Next())
+}
+
+func (suite *HistoryTestSuite) TestHistory_IterateTransactions_SameTimeOrderedById_Ok() {
+	for _, operation := range suite.transport.operations {
+		operation.DateCrt = NewWMTime(suite.from)
+	}
+
+	suite.transport.operations[0].Id = "1000"
+	cursor := suite.webmoney.IterateTransactions(context.Background(), "Z123456789012", suite.from, suite.from)
+
+	var ids []string
+
+	for cursor.Next() {
+		ids = append(ids, cursor.Operation().Id)
+	}
+
+	assert.NoError(suite.T(), cursor.Err())
+	assert.Equal(suite.T(), []string{"101", "102", "103", "104", "1000"}, ids)
+}
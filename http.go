@@ -5,7 +5,6 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"go.uber.org/zap"
 	"io/ioutil"
 	"net/http"
 	"time"
@@ -13,23 +12,22 @@ import (
 
 type httpTransport struct {
 	transport      http.RoundTripper
-	logger         *zap.Logger
+	logger         LogSink
 	clearRequestFn func(req *http.Request) *http.Request
-	getTransportFn func() http.RoundTripper
+	redactionRules map[string]RedactMode
+	logLevels      map[LogEvent]LogLevel
 }
 
 type httpContextKey struct {
 	name string
 }
 
-func newHttpTransport(
-	logger *zap.Logger,
-	clearRequestFn func(req *http.Request) *http.Request,
-	caCertPool *x509.CertPool,
-) *httpTransport {
+func newHttpTransport(options *Options, caCertPool *x509.CertPool) *httpTransport {
 	return &httpTransport{
-		logger:         logger,
-		clearRequestFn: clearRequestFn,
+		logger:         options.logSink(),
+		clearRequestFn: options.logClearFn,
+		redactionRules: options.redactionRules,
+		logLevels:      options.logLevels,
 		transport:      getTransport(caCertPool),
 	}
 }
@@ -45,7 +43,8 @@ func getTransport(caCertPool *x509.CertPool) http.RoundTripper {
 }
 
 func (m *httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	ctx := context.WithValue(req.Context(), &httpContextKey{name: "webmoneyRequestStart"}, time.Now())
+	start := time.Now()
+	ctx := context.WithValue(req.Context(), &httpContextKey{name: "webmoneyRequestStart"}, start)
 	req = req.WithContext(ctx)
 
 	var reqBody []byte
@@ -63,7 +62,12 @@ func (m *httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	rsp, err = m.transport.RoundTrip(req)
 
-	if err != nil || m.logger == nil {
+	if m.logger == nil {
+		return rsp, err
+	}
+
+	if err != nil {
+		m.log(req, reqBody, nil, nil, time.Since(start), err)
 		return rsp, err
 	}
 
@@ -71,28 +75,78 @@ func (m *httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	if rsp.Body != nil {
 		rspBody, err = ioutil.ReadAll(rsp.Body)
+
 		if err != nil {
+			m.log(req, reqBody, rsp, nil, time.Since(start), err)
 			return nil, err
 		}
 	}
 
 	rsp.Body = ioutil.NopCloser(bytes.NewBuffer(rspBody))
+	m.log(req, reqBody, rsp, rspBody, time.Since(start), nil)
+
+	return rsp, err
+}
 
+// log writes the exchange to the log as the parsed and redacted fields of request and response.
+// The raw bodies are never logged because they contain the signature and the personal data
+func (m *httpTransport) log(
+	req *http.Request,
+	reqBody []byte,
+	rsp *http.Response,
+	rspBody []byte,
+	duration time.Duration,
+	err error,
+) {
 	if m.clearRequestFn != nil {
 		req = m.clearRequestFn(req)
 	}
 
-	m.logger.Info(
-		req.URL.String(),
-		zap.String("request_method", req.Method),
-		zap.Any("request_headers", req.Header),
-		zap.ByteString("request_body", reqBody),
-		zap.Int("response_status", rsp.StatusCode),
-		zap.Any("response_headers", rsp.Header),
-		zap.ByteString("response_body", rspBody),
-	)
+	event := LogEventExchange
+	fields := []LogField{
+		{Key: "url", Value: req.URL.String()},
+		{Key: "request_method", Value: req.Method},
+		{Key: "duration", Value: duration},
+	}
+	reqFields, _ := m.bodyLogFields("request", reqBody)
+	fields = append(fields, reqFields...)
 
-	return rsp, err
+	if rsp != nil {
+		fields = append(fields, LogField{Key: "response_status", Value: rsp.StatusCode})
+	}
+
+	if err != nil {
+		event = LogEventTransportError
+		fields = append(fields, LogField{Key: "error", Value: err.Error()})
+	} else {
+		rspFields, rspValues := m.bodyLogFields("response", rspBody)
+
+		if retval, ok := rspValues["response.retval"]; ok && retval != "0" {
+			event = LogEventResponseError
+		}
+
+		fields = append(fields, rspFields...)
+	}
+
+	fields = append(fields, LogField{Key: "event", Value: string(event)})
+	m.logger.Log(m.logLevels[event], "webmoney "+string(event), fields...)
+}
+
+func (m *httpTransport) bodyLogFields(prefix string, body []byte) ([]LogField, map[string]string) {
+	if len(body) == 0 {
+		return nil, nil
+	}
+
+	fields, values, err := parseLogFields(prefix, body, m.redactionRules)
+
+	if err != nil {
+		fields = []LogField{
+			{Key: prefix + "_length", Value: len(body)},
+			{Key: prefix + "_parse_error", Value: err.Error()},
+		}
+	}
+
+	return fields, values
 }
 
 // Root ca for webmoney requests
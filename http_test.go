@@ -1,17 +1,25 @@
 package webmoney
 
 import (
+	"bytes"
+	"encoding/json"
 	"github.com/sidmal/webmoney/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/text/encoding/charmap"
+	"log/slog"
 	"net/http"
 	"strings"
 	"testing"
 )
 
+var (
+	TestWinDesc, _ = charmap.Windows1251.NewEncoder().String("Тестовая операция")
+)
+
 type HttpTestSuite struct {
 	suite.Suite
 	httpTransport *httpTransport
@@ -30,13 +38,21 @@ func (suite *HttpTestSuite) SetupTest() {
 	core, suite.zapRecorder = observer.New(lvl)
 	suite.logObserver = zap.New(core)
 
-	suite.httpTransport = newHttpTransport(
-		suite.logObserver,
-		func(req *http.Request) *http.Request {
+	options, err := executeOptions(
+		WmId(TestWmId),
+		Key(TestKey),
+		Password(TestPassword),
+		Logger(suite.logObserver),
+		LogClearFn(func(req *http.Request) *http.Request {
 			return req
-		},
-		nil,
+		}),
 	)
+
+	if err != nil {
+		suite.FailNow("Options initialization failed", "%v", err)
+	}
+
+	suite.httpTransport = newHttpTransport(options, nil)
 }
 
 func (suite *HttpTestSuite) TestHttpTransport_RoundTrip_WithoutLog_Ok() {
@@ -79,5 +95,174 @@ func (suite *HttpTestSuite) TestHttpTransport_RoundTrip_ReadResponseBody_Error()
 	_, err = suite.httpTransport.RoundTrip(req)
 	assert.Error(suite.T(), err)
 	assert.EqualError(suite.T(), err, "SomeError")
+
+	logs := suite.zapRecorder.All()
+	assert.Len(suite.T(), logs, 1)
+	assert.Equal(suite.T(), zapcore.ErrorLevel, logs[0].Level)
+	assert.Equal(suite.T(), "webmoney transport_error", logs[0].Message)
+	assert.Equal(suite.T(), "SomeError", logs[0].ContextMap()["error"])
+	assert.EqualValues(suite.T(), http.StatusBadRequest, logs[0].ContextMap()["response_status"])
+}
+
+func (suite *HttpTestSuite) TestHttpTransport_RoundTrip_TransportError() {
+	req, err := http.NewRequest(http.MethodPost, "http://localhost", strings.NewReader(`{}`))
+	assert.NoError(suite.T(), err)
+
+	suite.httpTransport.transport = &mocks.TransportStatusError{}
+
+	_, err = suite.httpTransport.RoundTrip(req)
+	assert.EqualError(suite.T(), err, "TransportStatusError")
+
+	logs := suite.zapRecorder.All()
+	assert.Len(suite.T(), logs, 1)
+	assert.Equal(suite.T(), zapcore.ErrorLevel, logs[0].Level)
+	assert.Equal(suite.T(), "TransportStatusError", logs[0].ContextMap()["error"])
+}
+
+func (suite *HttpTestSuite) TestHttpTransport_RoundTrip_RedactedFields_Ok() {
+	body := `<w3s.request><reqn>20200901000000123</reqn><wmid>405002833238</wmid><sign>abcdef0123456789</sign>` +
+		`<trans><tranid>1234567890</tranid><pursesrc>Z123456789012</pursesrc><pursedest>Z098765432109</pursedest>` +
+		`<amount>10</amount><period>0</period><desc>` + TestWinDesc + `</desc><pcode>secret</pcode></trans></w3s.request>`
+	req, err := http.NewRequest(http.MethodPost, "https://w3s.webmoney.ru/asp/XMLTrans.asp", strings.NewReader(body))
+	assert.NoError(suite.T(), err)
+
+	suite.httpTransport.transport = &mocks.TransportStatusOk{}
+
+	_, err = suite.httpTransport.RoundTrip(req)
+	assert.NoError(suite.T(), err)
+
+	logs := suite.zapRecorder.All()
+	assert.Len(suite.T(), logs, 1)
+	assert.Equal(suite.T(), zapcore.InfoLevel, logs[0].Level)
+	assert.Equal(suite.T(), "webmoney exchange", logs[0].Message)
+
+	fields := logs[0].ContextMap()
+	assert.Equal(suite.T(), "20200901000000123", fields["request.reqn"])
+	assert.Equal(suite.T(), "4*******3238", fields["request.wmid"])
+	assert.Equal(suite.T(), "[REDACTED]", fields["request.sign"])
+	assert.Equal(suite.T(), "Z********9012", fields["request.trans.pursesrc"])
+	assert.Equal(suite.T(), "Z********2109", fields["request.trans.pursedest"])
+	assert.Equal(suite.T(), "10", fields["request.trans.amount"])
+	assert.Equal(suite.T(), "[REDACTED]", fields["request.trans.desc"])
+	assert.Equal(suite.T(), "[REDACTED]", fields["request.trans.pcode"])
+	assert.Equal(suite.T(), "0", fields["response.retval"])
+	assert.Equal(suite.T(), "123", fields["response.operation.id"])
+	assert.Equal(suite.T(), "100.00", fields["response.operation.amount"])
+	assert.Equal(suite.T(), "Z********9012", fields["response.operation.pursesrc"])
+	assert.Equal(suite.T(), "[REDACTED]", fields["response.operation.desc"])
+
+	for _, field := range logs[0].Context {
+		assert.NotContains(suite.T(), field.String, "Z123456789012")
+		assert.NotContains(suite.T(), field.String, "abcdef0123456789")
+		assert.NotContains(suite.T(), field.String, "secret")
+	}
+}
+
+func (suite *HttpTestSuite) TestHttpTransport_RoundTrip_CustomRulesAndLevels_Ok() {
+	suite.httpTransport.redactionRules["amount"] = RedactHide
+	suite.httpTransport.redactionRules["sign"] = RedactDrop
+	suite.httpTransport.redactionRules["desc"] = RedactNone
+	suite.httpTransport.logLevels[LogEventResponseError] = LogLevelError
+
+	body := `<w3s.request><sign>abcdef</sign><trans><amount>10</amount><desc>` + TestWinDesc + `</desc></trans></w3s.request>`
+	req, err := http.NewRequest(http.MethodPost, "https://w3s.webmoney.ru/asp/XMLTrans.asp", strings.NewReader(body))
+	assert.NoError(suite.T(), err)
+
+	suite.httpTransport.transport = &mocks.TransportStatusWmError{}
+
+	_, err = suite.httpTransport.RoundTrip(req)
+	assert.NoError(suite.T(), err)
+
+	logs := suite.zapRecorder.All()
+	assert.Len(suite.T(), logs, 1)
+	assert.Equal(suite.T(), zapcore.ErrorLevel, logs[0].Level)
+	assert.Equal(suite.T(), "webmoney response_error", logs[0].Message)
+
+	fields := logs[0].ContextMap()
+	assert.NotContains(suite.T(), fields, "request.sign")
+	assert.Equal(suite.T(), "[REDACTED]", fields["request.trans.amount"])
+	assert.Equal(suite.T(), "Тестовая операция", fields["request.trans.desc"])
+	assert.Equal(suite.T(), "-999", fields["response.retval"])
+	assert.Equal(suite.T(), "Mock error", fields["response.retdesc"])
+}
+
+func (suite *HttpTestSuite) TestHttpTransport_RoundTrip_NotXmlBody_Ok() {
+	req, err := http.NewRequest(http.MethodPost, "http://localhost", strings.NewReader(`{"sign": "abcdef"}`))
+	assert.NoError(suite.T(), err)
+
+	suite.httpTransport.transport = &mocks.TransportStatusOk{}
+
+	_, err = suite.httpTransport.RoundTrip(req)
+	assert.NoError(suite.T(), err)
+
+	logs := suite.zapRecorder.All()
+	assert.Len(suite.T(), logs, 1)
+
+	fields := logs[0].ContextMap()
+	assert.EqualValues(suite.T(), 18, fields["request_length"])
+	assert.NotEmpty(suite.T(), fields["request_parse_error"])
+
+	for _, field := range logs[0].Context {
+		assert.NotContains(suite.T(), field.String, "abcdef")
+	}
+}
+
+func (suite *HttpTestSuite) TestHttpTransport_RoundTrip_RepeatedElements_Ok() {
+	fields, values, err := parseLogFields(
+		"response",
+		[]byte(`<w3s.response><retval>0</retval><purses cnt="2"><purse id="1"><pursename>Z123456789012</pursename>`+
+			`<amount>10</amount></purse><purse id="2"><pursename>E123456789012</pursename><amount>20</amount></purse>`+
+			`</purses></w3s.response>`),
+		defaultRedactionRules,
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "0", values["response.retval"])
+	assert.Equal(suite.T(), "Z123456789012", values["response.purses.purse.pursename"])
+	assert.Equal(
+		suite.T(),
+		[]LogField{
+			{Key: "response.retval", Value: "0"},
+			{Key: "response.purses.cnt", Value: "2"},
+			{Key: "response.purses.purse.id", Value: []string{"1", "2"}},
+			{Key: "response.purses.purse.pursename", Value: []string{"Z********9012", "E********9012"}},
+			{Key: "response.purses.purse.amount", Value: []string{"10", "20"}},
+		},
+		fields,
+	)
+}
+
+func (suite *HttpTestSuite) TestHttpTransport_SlogLogger_Ok() {
+	buffer := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	options, err := executeOptions(
+		WmId(TestWmId),
+		Key(TestKey),
+		Password(TestPassword),
+		SlogLogger(logger),
+		EventLogLevel(LogEventExchange, LogLevelDebug),
+	)
+	assert.NoError(suite.T(), err)
+
+	httpTransport := newHttpTransport(options, nil)
+	httpTransport.transport = &mocks.TransportStatusOk{}
+
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"https://w3s.webmoney.ru/asp/XMLPurses.asp",
+		strings.NewReader(`<w3s.request><reqn>1</reqn><wmid>405002833238</wmid><sign>abcdef</sign></w3s.request>`),
+	)
+	assert.NoError(suite.T(), err)
+
+	_, err = httpTransport.RoundTrip(req)
+	assert.NoError(suite.T(), err)
+
+	entry := make(map[string]interface{})
+	assert.NoError(suite.T(), json.Unmarshal(buffer.Bytes(), &entry))
+	assert.Equal(suite.T(), "DEBUG", entry["level"])
+	assert.Equal(suite.T(), "webmoney exchange", entry["msg"])
+	assert.Equal(suite.T(), "4*******3238", entry["request.wmid"])
+	assert.Equal(suite.T(), "[REDACTED]", entry["request.sign"])
+	assert.Equal(suite.T(), "Z********9012", entry["response.purses.purse.pursename"])
+	assert.Equal(suite.T(), "[REDACTED]", entry["response.purses.purse.desc"])
 	assert.Empty(suite.T(), suite.zapRecorder.All())
 }
@@ -0,0 +1,93 @@
+package webmoney
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	InterfaceX2 = "X2"
+	InterfaceX3 = "X3"
+	InterfaceX9 = "X9"
+)
+
+var (
+	ErrorInvocationResponseEmpty = errors.New("the invocation completed without response")
+	ErrorInvocationResponseType  = errors.New("the invocation completed with the response of unexpected type")
+)
+
+// Invocation describes the single logical operation with WebMoney XML interface
+type Invocation struct {
+	// The WebMoney XML interface name, e.g. X2
+	Interface string
+	// The typed request, e.g. *TransferMoneyRequest
+	Request interface{}
+	// The typed response, e.g. *TransferMoneyResponse. It is filled when the operation completed successfully
+	Response interface{}
+	// The duration of the operation execution
+	Latency time.Duration
+}
+
+// Handler executes the invocation. The interceptor calls it to pass the invocation down the chain
+type Handler func(ctx context.Context, invocation *Invocation) error
+
+// Interceptor wraps each operation with WebMoney XML interfaces, e.g. to collect metrics,
+// audit operations or inject faults. The interceptor may return without calling the next handler,
+// in this case it must fill the invocation response or return an error
+type Interceptor interface {
+	Intercept(ctx context.Context, invocation *Invocation, next Handler) error
+}
+
+// InterceptorFunc is an adapter to use ordinary function as the Interceptor
+type InterceptorFunc func(ctx context.Context, invocation *Invocation, next Handler) error
+
+func (fn InterceptorFunc) Intercept(ctx context.Context, invocation *Invocation, next Handler) error {
+	return fn(ctx, invocation, next)
+}
+
+func (m *WebMoney) invoke(
+	ctx context.Context,
+	iface string,
+	in interface{},
+	fn func(ctx context.Context) (interface{}, error),
+) (interface{}, error) {
+	handler := func(ctx context.Context, invocation *Invocation) error {
+		start := time.Now()
+		out, err := fn(ctx)
+		invocation.Latency = time.Since(start)
+
+		if err != nil {
+			return err
+		}
+
+		invocation.Response = out
+		return nil
+	}
+
+	for i := len(m.interceptors) - 1; i >= 0; i-- {
+		handler = chainInterceptor(m.interceptors[i], handler)
+	}
+
+	invocation := &Invocation{
+		Interface: iface,
+		Request:   in,
+	}
+	err := handler(ctx, invocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if invocation.Response == nil {
+		return nil, ErrorInvocationResponseEmpty
+	}
+
+	return invocation.Response, nil
+}
+
+func chainInterceptor(interceptor Interceptor, next Handler) Handler {
+	return func(ctx context.Context, invocation *Invocation) error {
+		return interceptor.Intercept(ctx, invocation, next)
+	}
+}
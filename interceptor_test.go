@@ -0,0 +1,199 @@
+package webmoney
+
+import (
+	"context"
+	"errors"
+	"github.com/sidmal/webmoney/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type InterceptorTestSuite struct {
+	suite.Suite
+	defaultOptions []Option
+}
+
+func Test_Interceptor(t *testing.T) {
+	suite.Run(t, new(InterceptorTestSuite))
+}
+
+func (suite *InterceptorTestSuite) SetupTest() {
+	suite.defaultOptions = []Option{
+		WmId(TestWmId),
+		Key(TestKey),
+		Password(TestPassword),
+		httpClient(mocks.NewTransportStatusOk()),
+	}
+}
+
+func (suite *InterceptorTestSuite) newWebMoney(interceptors ...Interceptor) XMLInterface {
+	wm, err := NewWebMoney(append(suite.defaultOptions, Interceptors(interceptors...))...)
+
+	if err != nil {
+		suite.FailNow("WebMoney handler initialization failed", "%v", err)
+	}
+
+	return wm
+}
+
+func (suite *InterceptorTestSuite) TestInterceptor_Chain_Ok() {
+	var calls []string
+
+	newInterceptor := func(name string) Interceptor {
+		return InterceptorFunc(func(ctx context.Context, invocation *Invocation, next Handler) error {
+			calls = append(calls, name+":before:"+invocation.Interface)
+			assert.Nil(suite.T(), invocation.Response)
+			err := next(ctx, invocation)
+			calls = append(calls, name+":after:"+invocation.Interface)
+			assert.NoError(suite.T(), err)
+			assert.NotNil(suite.T(), invocation.Response)
+			assert.NotZero(suite.T(), invocation.Latency)
+			return err
+		})
+	}
+
+	wm := suite.newWebMoney(newInterceptor("first"), newInterceptor("second"))
+	in := &GetBalanceRequest{Wmid: TestWmId}
+	result, err := wm.GetBalance(in)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), result)
+	assert.Equal(
+		suite.T(),
+		[]string{"first:before:X9", "second:before:X9", "second:after:X9", "first:after:X9"},
+		calls,
+	)
+}
+
+func (suite *InterceptorTestSuite) TestInterceptor_TypedRequestResponse_Ok() {
+	var invocations []*Invocation
+
+	interceptor := InterceptorFunc(func(ctx context.Context, invocation *Invocation, next Handler) error {
+		err := next(ctx, invocation)
+		invocations = append(invocations, invocation)
+		return err
+	})
+	wm := suite.newWebMoney(interceptor)
+
+	transferMoneyRequest := &TransferMoneyRequest{
+		TxnId:     1234567890,
+		PurseSrc:  "Z123456789012",
+		PurseDest: "Z098765432109",
+		Amount:    1000,
+	}
+	_, err := wm.TransferMoney(transferMoneyRequest)
+	assert.NoError(suite.T(), err)
+
+	getTransactionsHistoryRequest := &GetTransactionsHistoryRequest{Purse: "Z123456789012"}
+	_, err = wm.GetTransactionsHistory(getTransactionsHistoryRequest)
+	assert.NoError(suite.T(), err)
+
+	assert.Len(suite.T(), invocations, 2)
+	assert.Equal(suite.T(), InterfaceX2, invocations[0].Interface)
+	assert.Equal(suite.T(), transferMoneyRequest, invocations[0].Request)
+	assert.IsType(suite.T(), &TransferMoneyResponse{}, invocations[0].Response)
+	assert.Equal(suite.T(), InterfaceX3, invocations[1].Interface)
+	assert.Equal(suite.T(), getTransactionsHistoryRequest, invocations[1].Request)
+	assert.IsType(suite.T(), &GetTransactionsHistoryResponse{}, invocations[1].Response)
+}
+
+func (suite *InterceptorTestSuite) TestInterceptor_OperationError_Ok() {
+	var interceptedErr error
+
+	interceptor := InterceptorFunc(func(ctx context.Context, invocation *Invocation, next Handler) error {
+		interceptedErr = next(ctx, invocation)
+		assert.Nil(suite.T(), invocation.Response)
+		return interceptedErr
+	})
+	wm := suite.newWebMoney(interceptor)
+	wm.(*WebMoney).httpClient = mocks.NewTransportStatusWmError()
+
+	result, err := wm.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.EqualError(suite.T(), err, "Mock error")
+	assert.Equal(suite.T(), err, interceptedErr)
+	assert.Nil(suite.T(), result)
+}
+
+func (suite *InterceptorTestSuite) TestInterceptor_FaultInjection_Error() {
+	interceptor := InterceptorFunc(func(_ context.Context, _ *Invocation, _ Handler) error {
+		return errors.New("TestInterceptor_FaultInjection_Error")
+	})
+	wm := suite.newWebMoney(interceptor)
+	wm.(*WebMoney).httpClient = mocks.NewTransportStatusError()
+
+	result, err := wm.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.EqualError(suite.T(), err, "TestInterceptor_FaultInjection_Error")
+	assert.Nil(suite.T(), result)
+}
+
+func (suite *InterceptorTestSuite) TestInterceptor_ShortCircuit_Ok() {
+	response := &GetBalanceResponse{Count: "0"}
+	interceptor := InterceptorFunc(func(_ context.Context, invocation *Invocation, _ Handler) error {
+		invocation.Response = response
+		return nil
+	})
+	wm := suite.newWebMoney(interceptor)
+	wm.(*WebMoney).httpClient = mocks.NewTransportStatusError()
+
+	result, err := wm.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), response, result)
+}
+
+func (suite *InterceptorTestSuite) TestInterceptor_ShortCircuit_ResponseEmpty_Error() {
+	interceptor := InterceptorFunc(func(_ context.Context, _ *Invocation, _ Handler) error {
+		return nil
+	})
+	wm := suite.newWebMoney(interceptor)
+
+	result, err := wm.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.Equal(suite.T(), ErrorInvocationResponseEmpty, err)
+	assert.Nil(suite.T(), result)
+}
+
+func (suite *InterceptorTestSuite) TestInterceptor_ShortCircuit_ResponseType_Error() {
+	interceptor := InterceptorFunc(func(_ context.Context, invocation *Invocation, _ Handler) error {
+		invocation.Response = &GetTransactionsHistoryResponse{}
+		return nil
+	})
+	wm := suite.newWebMoney(interceptor)
+
+	result, err := wm.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.True(suite.T(), errors.Is(err, ErrorInvocationResponseType))
+	assert.EqualError(
+		suite.T(),
+		err,
+		"the invocation completed with the response of unexpected type: *webmoney.GetTransactionsHistoryResponse",
+	)
+	assert.Nil(suite.T(), result)
+
+	_, err = wm.TransferMoney(&TransferMoneyRequest{TxnId: 1, PurseSrc: "Z123456789012", PurseDest: "Z098765432109", Amount: 100})
+	assert.True(suite.T(), errors.Is(err, ErrorInvocationResponseType))
+
+	interceptor = InterceptorFunc(func(_ context.Context, invocation *Invocation, _ Handler) error {
+		invocation.Response = &GetBalanceResponse{}
+		return nil
+	})
+	wm = suite.newWebMoney(interceptor)
+
+	_, err = wm.GetTransactionsHistory(&GetTransactionsHistoryRequest{Purse: "Z123456789012"})
+	assert.True(suite.T(), errors.Is(err, ErrorInvocationResponseType))
+}
+
+func (suite *InterceptorTestSuite) TestInterceptor_Metrics_Innermost_Ok() {
+	var calls []string
+
+	newInterceptor := func(name string) Interceptor {
+		return InterceptorFunc(func(ctx context.Context, invocation *Invocation, next Handler) error {
+			calls = append(calls, name)
+			return next(ctx, invocation)
+		})
+	}
+
+	suite.defaultOptions = append(suite.defaultOptions, Metrics(newInterceptor("metrics")))
+	wm := suite.newWebMoney(newInterceptor("first"), newInterceptor("second"))
+
+	_, err := wm.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"first", "second", "metrics"}, calls)
+}
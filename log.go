@@ -0,0 +1,284 @@
+package webmoney
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/charmap"
+	"io"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+const (
+	// LogEventExchange is the successful exchange with WebMoney XML interface
+	LogEventExchange LogEvent = "exchange"
+	// LogEventResponseError is the exchange completed with non zero retval code
+	LogEventResponseError LogEvent = "response_error"
+	// LogEventTransportError is the exchange failed on the transport level
+	LogEventTransportError LogEvent = "transport_error"
+)
+
+const (
+	// RedactNone logs the field value as is
+	RedactNone RedactMode = iota
+	// RedactMask logs the first and four last characters of the field value, e.g. Z********9012
+	RedactMask
+	// RedactHide replaces the field value with the placeholder
+	RedactHide
+	// RedactDrop removes the field from the log
+	RedactDrop
+)
+
+const (
+	redactedPlaceholder = "[REDACTED]"
+	maskedTailLength    = 4
+)
+
+var (
+	defaultRedactionRules = map[string]RedactMode{
+		"sign":      RedactHide,
+		"pcode":     RedactHide,
+		"desc":      RedactHide,
+		"purse":     RedactMask,
+		"pursesrc":  RedactMask,
+		"pursedest": RedactMask,
+		"pursename": RedactMask,
+		"wmid":      RedactMask,
+		"corrwm":    RedactMask,
+	}
+
+	errorLogBodyNotXml = errors.New("the body is not XML document")
+
+	defaultLogLevels = map[LogEvent]LogLevel{
+		LogEventExchange:       LogLevelInfo,
+		LogEventResponseError:  LogLevelWarn,
+		LogEventTransportError: LogLevelError,
+	}
+)
+
+// LogLevel is the severity of the log entry
+type LogLevel int8
+
+// LogEvent is the kind of the logged exchange with WebMoney XML interface
+type LogEvent string
+
+// RedactMode defines how the field value is written to the log
+type RedactMode int8
+
+// LogField is the key-value pair of the structured log entry
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// LogSink is the structured logger the exchanges with WebMoney XML interfaces are written to
+type LogSink interface {
+	Log(level LogLevel, msg string, fields ...LogField)
+}
+
+type zapLogSink struct {
+	logger *zap.Logger
+}
+
+type slogLogSink struct {
+	logger *slog.Logger
+}
+
+// NewZapLogSink returns LogSink writing to the zap logger
+func NewZapLogSink(logger *zap.Logger) LogSink {
+	return &zapLogSink{logger: logger}
+}
+
+// NewSlogLogSink returns LogSink writing to the slog logger
+func NewSlogLogSink(logger *slog.Logger) LogSink {
+	return &slogLogSink{logger: logger}
+}
+
+func (m *zapLogSink) Log(level LogLevel, msg string, fields ...LogField) {
+	zapLevel := zapcore.InfoLevel
+
+	switch level {
+	case LogLevelDebug:
+		zapLevel = zapcore.DebugLevel
+	case LogLevelWarn:
+		zapLevel = zapcore.WarnLevel
+	case LogLevelError:
+		zapLevel = zapcore.ErrorLevel
+	}
+
+	ce := m.logger.Check(zapLevel, msg)
+
+	if ce == nil {
+		return
+	}
+
+	zapFields := make([]zap.Field, 0, len(fields))
+
+	for _, field := range fields {
+		zapFields = append(zapFields, zap.Any(field.Key, field.Value))
+	}
+
+	ce.Write(zapFields...)
+}
+
+func (m *slogLogSink) Log(level LogLevel, msg string, fields ...LogField) {
+	slogLevel := slog.LevelInfo
+
+	switch level {
+	case LogLevelDebug:
+		slogLevel = slog.LevelDebug
+	case LogLevelWarn:
+		slogLevel = slog.LevelWarn
+	case LogLevelError:
+		slogLevel = slog.LevelError
+	}
+
+	attrs := make([]slog.Attr, 0, len(fields))
+
+	for _, field := range fields {
+		attrs = append(attrs, slog.Any(field.Key, field.Value))
+	}
+
+	m.logger.LogAttrs(context.Background(), slogLevel, msg, attrs...)
+}
+
+// redact returns the field value according to the redaction mode of the field and false if the field must be dropped
+func redact(rules map[string]RedactMode, name, value string) (string, bool) {
+	switch rules[name] {
+	case RedactMask:
+		return mask(value), true
+	case RedactHide:
+		return redactedPlaceholder, true
+	case RedactDrop:
+		return "", false
+	}
+
+	return value, true
+}
+
+func mask(value string) string {
+	runes := []rune(value)
+	length := len(runes)
+
+	if length < 2*maskedTailLength {
+		return strings.Repeat("*", length)
+	}
+
+	return string(runes[0]) + strings.Repeat("*", length-maskedTailLength-1) + string(runes[length-maskedTailLength:])
+}
+
+// parseLogFields parses XML body of request or response to the flat list of redacted fields, the field key
+// is the path of the element or attribute under the root element, e.g. "request.trans.amount".
+// The values of repeated elements are collected to the list. The first not redacted value
+// of each field is returned in the map to make decisions on the logged exchange
+func parseLogFields(prefix string, body []byte, rules map[string]RedactMode) ([]LogField, map[string]string, error) {
+	// The request description is encoded to windows-1251 without XML declaration
+	if !utf8.Valid(body) && !bytes.HasPrefix(body, []byte("<?xml")) {
+		body, _ = charmap.Windows1251.NewDecoder().Bytes(body)
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	var (
+		keys     []string
+		stack    []string
+		text     []string
+		children []bool
+		root     bool
+	)
+
+	values := make(map[string][]string)
+	raw := make(map[string]string)
+
+	add := func(path []string, name, value string) {
+		key := strings.Join(append([]string{prefix}, path...), ".")
+
+		if _, ok := raw[key]; !ok {
+			raw[key] = value
+		}
+
+		value, ok := redact(rules, name, value)
+
+		if !ok {
+			return
+		}
+
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+
+		values[key] = append(values[key], value)
+	}
+
+	for {
+		token, err := decoder.Token()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(children) > 0 {
+				children[len(children)-1] = true
+			}
+
+			root = true
+			stack = append(stack, t.Name.Local)
+			text = append(text, "")
+			children = append(children, false)
+
+			for _, attr := range t.Attr {
+				add(append(stack[1:len(stack):len(stack)], attr.Name.Local), attr.Name.Local, attr.Value)
+			}
+		case xml.CharData:
+			if len(text) > 0 {
+				text[len(text)-1] += string(t)
+			}
+		case xml.EndElement:
+			last := len(stack) - 1
+			value := strings.TrimSpace(text[last])
+
+			if last > 0 && !children[last] {
+				add(stack[1:], stack[last], value)
+			}
+
+			stack, text, children = stack[:last], text[:last], children[:last]
+		}
+	}
+
+	if !root {
+		return nil, nil, errorLogBodyNotXml
+	}
+
+	fields := make([]LogField, 0, len(keys))
+
+	for _, key := range keys {
+		if len(values[key]) == 1 {
+			fields = append(fields, LogField{Key: key, Value: values[key][0]})
+			continue
+		}
+
+		fields = append(fields, LogField{Key: key, Value: values[key]})
+	}
+
+	return fields, raw, nil
+}
@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sidmal/webmoney"
+	"net/url"
+	"strconv"
+)
+
+const (
+	defaultNamespace = "webmoney"
+
+	labelInterface = "interface"
+	labelCode      = "code"
+
+	codeOk    = "0"
+	codeError = "error"
+)
+
+var (
+	defaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+)
+
+// Collector collects Prometheus metrics of operations with WebMoney XML interfaces.
+// It is plugged to the client with webmoney.Metrics option
+type Collector struct {
+	requests        *prometheus.CounterVec
+	latency         *prometheus.HistogramVec
+	inFlight        *prometheus.GaugeVec
+	transportErrors *prometheus.CounterVec
+}
+
+// NewCollector creates metrics collector and registers its metrics in the registerer
+func NewCollector(registerer prometheus.Registerer, opts ...Option) (*Collector, error) {
+	options := executeOptions(opts...)
+
+	collector := &Collector{
+		requests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: options.namespace,
+				Name:      "requests_total",
+				Help:      "The number of requests to WebMoney XML interfaces by interface and retval code.",
+			},
+			[]string{labelInterface, labelCode},
+		),
+		latency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: options.namespace,
+				Name:      "request_duration_seconds",
+				Help:      "The latency of requests to WebMoney XML interfaces.",
+				Buckets:   options.buckets,
+			},
+			[]string{labelInterface},
+		),
+		inFlight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: options.namespace,
+				Name:      "requests_in_flight",
+				Help:      "The number of requests to WebMoney XML interfaces being processed.",
+			},
+			[]string{labelInterface},
+		),
+		transportErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: options.namespace,
+				Name:      "transport_errors_total",
+				Help:      "The number of requests to WebMoney XML interfaces failed on the transport level.",
+			},
+			[]string{labelInterface},
+		),
+	}
+
+	cs := []prometheus.Collector{
+		collector.requests,
+		collector.latency,
+		collector.inFlight,
+		collector.transportErrors,
+	}
+
+	for _, c := range cs {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return collector, nil
+}
+
+func executeOptions(opts ...Option) *Options {
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.namespace == "" {
+		options.namespace = defaultNamespace
+	}
+
+	if len(options.buckets) == 0 {
+		options.buckets = defaultBuckets
+	}
+
+	return options
+}
+
+func (m *Collector) Intercept(ctx context.Context, invocation *webmoney.Invocation, next webmoney.Handler) error {
+	inFlight := m.inFlight.WithLabelValues(invocation.Interface)
+	inFlight.Inc()
+	defer inFlight.Dec()
+
+	err := next(ctx, invocation)
+
+	m.latency.WithLabelValues(invocation.Interface).Observe(invocation.Latency.Seconds())
+	m.requests.WithLabelValues(invocation.Interface, code(err)).Inc()
+
+	var urlErr *url.Error
+
+	if errors.As(err, &urlErr) {
+		m.transportErrors.WithLabelValues(invocation.Interface).Inc()
+	}
+
+	return err
+}
+
+func code(err error) string {
+	if err == nil {
+		return codeOk
+	}
+
+	var rspErr *webmoney.ResponseError
+
+	if errors.As(err, &rspErr) {
+		return strconv.Itoa(rspErr.Code)
+	}
+
+	return codeError
+}
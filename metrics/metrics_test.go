@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sidmal/webmoney"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type MetricsTestSuite struct {
+	suite.Suite
+	registry  *prometheus.Registry
+	collector *Collector
+}
+
+func Test_Metrics(t *testing.T) {
+	suite.Run(t, new(MetricsTestSuite))
+}
+
+func (suite *MetricsTestSuite) SetupTest() {
+	var err error
+
+	suite.registry = prometheus.NewRegistry()
+	suite.collector, err = NewCollector(suite.registry)
+
+	if err != nil {
+		suite.FailNow("Metrics collector initialization failed", "%v", err)
+	}
+}
+
+func (suite *MetricsTestSuite) intercept(iface string, err error) error {
+	invocation := &webmoney.Invocation{Interface: iface}
+	return suite.collector.Intercept(context.Background(), invocation, func(_ context.Context, invocation *webmoney.Invocation) error {
+		assert.EqualValues(suite.T(), 1, testutil.ToFloat64(suite.collector.inFlight.WithLabelValues(iface)))
+		invocation.Latency = 150 * time.Millisecond
+		return err
+	})
+}
+
+func (suite *MetricsTestSuite) TestMetrics_Intercept_Ok() {
+	assert.NoError(suite.T(), suite.intercept(webmoney.InterfaceX2, nil))
+	assert.NoError(suite.T(), suite.intercept(webmoney.InterfaceX2, nil))
+	assert.NoError(suite.T(), suite.intercept(webmoney.InterfaceX9, nil))
+
+	assert.EqualValues(suite.T(), 2, testutil.ToFloat64(suite.collector.requests.WithLabelValues("X2", "0")))
+	assert.EqualValues(suite.T(), 1, testutil.ToFloat64(suite.collector.requests.WithLabelValues("X9", "0")))
+	assert.EqualValues(suite.T(), 0, testutil.ToFloat64(suite.collector.inFlight.WithLabelValues("X2")))
+	assert.EqualValues(suite.T(), 0, testutil.ToFloat64(suite.collector.transportErrors.WithLabelValues("X2")))
+	assert.Equal(suite.T(), 2, testutil.CollectAndCount(suite.collector.latency))
+
+	families, err := suite.registry.Gather()
+	assert.NoError(suite.T(), err)
+
+	for _, family := range families {
+		if family.GetName() != "webmoney_request_duration_seconds" {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			assert.InDelta(suite.T(), 0.15*float64(metric.GetHistogram().GetSampleCount()), metric.GetHistogram().GetSampleSum(), 0.001)
+		}
+	}
+}
+
+func (suite *MetricsTestSuite) TestMetrics_Intercept_ResponseError() {
+	rspErr := &webmoney.ResponseError{Code: -100, Reason: "Mock error"}
+	err := suite.intercept(webmoney.InterfaceX3, rspErr)
+	assert.Equal(suite.T(), rspErr, err)
+
+	assert.EqualValues(suite.T(), 1, testutil.ToFloat64(suite.collector.requests.WithLabelValues("X3", "-100")))
+	assert.EqualValues(suite.T(), 0, testutil.ToFloat64(suite.collector.transportErrors.WithLabelValues("X3")))
+}
+
+func (suite *MetricsTestSuite) TestMetrics_Intercept_TransportError() {
+	transportErr := &url.Error{Op: "Post", URL: "https://w3s.webmoney.ru", Err: errors.New("TransportStatusError")}
+	err := suite.intercept(webmoney.InterfaceX9, transportErr)
+	assert.Equal(suite.T(), transportErr, err)
+
+	assert.EqualValues(suite.T(), 1, testutil.ToFloat64(suite.collector.requests.WithLabelValues("X9", "error")))
+	assert.EqualValues(suite.T(), 1, testutil.ToFloat64(suite.collector.transportErrors.WithLabelValues("X9")))
+}
+
+func (suite *MetricsTestSuite) TestMetrics_Intercept_OtherError() {
+	err := suite.intercept(webmoney.InterfaceX2, webmoney.ErrorAmountIsIncorrect)
+	assert.Equal(suite.T(), webmoney.ErrorAmountIsIncorrect, err)
+
+	assert.EqualValues(suite.T(), 1, testutil.ToFloat64(suite.collector.requests.WithLabelValues("X2", "error")))
+	assert.EqualValues(suite.T(), 0, testutil.ToFloat64(suite.collector.transportErrors.WithLabelValues("X2")))
+}
+
+func (suite *MetricsTestSuite) TestMetrics_NewCollector_AlreadyRegistered_Error() {
+	collector, err := NewCollector(suite.registry)
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), collector)
+}
+
+func (suite *MetricsTestSuite) TestMetrics_NewCollector_Options_Ok() {
+	registry := prometheus.NewRegistry()
+	collector, err := NewCollector(registry, Namespace("payouts"), Buckets([]float64{1, 2}))
+	assert.NoError(suite.T(), err)
+
+	invocation := &webmoney.Invocation{Interface: webmoney.InterfaceX9}
+	_ = collector.Intercept(context.Background(), invocation, func(_ context.Context, _ *webmoney.Invocation) error {
+		return nil
+	})
+
+	families, err := registry.Gather()
+	assert.NoError(suite.T(), err)
+
+	var names []string
+
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+
+	assert.Contains(suite.T(), names, "payouts_requests_total")
+	assert.Contains(suite.T(), names, "payouts_request_duration_seconds")
+}
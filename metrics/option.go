@@ -0,0 +1,22 @@
+package metrics
+
+type Options struct {
+	// The metrics namespace
+	namespace string
+	// The buckets of operations latency histogram in seconds
+	buckets []float64
+}
+
+type Option func(*Options)
+
+func Namespace(val string) Option {
+	return func(opts *Options) {
+		opts.namespace = val
+	}
+}
+
+func Buckets(val []float64) Option {
+	return func(opts *Options) {
+		opts.buckets = val
+	}
+}
@@ -0,0 +1,16 @@
+package metrics
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMetricsOptions_Setters(t *testing.T) {
+	options := executeOptions(Namespace("payouts"), Buckets([]float64{1, 2}))
+	assert.Equal(t, "payouts", options.namespace)
+	assert.Equal(t, []float64{1, 2}, options.buckets)
+
+	options = executeOptions()
+	assert.Equal(t, defaultNamespace, options.namespace)
+	assert.Equal(t, defaultBuckets, options.buckets)
+}
@@ -45,13 +45,13 @@ func (m *TransportStatusOk) RoundTrip(req *http.Request) (*http.Response, error)
 
 	switch req.URL.Path {
 	case "/asp/XMLTrans.asp":
-		body = `<w3s.response><reqn>1234567890</reqn><retval>0</retval><retdesc>Ok</retdesc><operation id="123" ts="456"><tranid>1234567890</tranid><pursesrc>Z123456789012</pursesrc><pursedest>Z0987654321098</pursedest><amount>100.00</amount><comiss>0.8</comiss><opertype>0</opertype><period>0</period><wminvid>0</wminvid><orderid>0</orderid><desc>Mock test</desc><datecrt>` + t + `</datecrt><dateupd>` + t + `</dateupd></operation></w3s.response>`
+		body = `<w3s.response><reqn>1234567890</reqn><retval>0</retval><retdesc>Ok</retdesc><operation id="123" ts="456"><tranid>1234567890</tranid><pursesrc>Z123456789012</pursesrc><pursedest>Z098765432109</pursedest><amount>100.00</amount><comiss>0.8</comiss><opertype>0</opertype><period>0</period><wminvid>0</wminvid><orderid>0</orderid><desc>Mock test</desc><datecrt>` + t + `</datecrt><dateupd>` + t + `</dateupd></operation></w3s.response>`
 		break
 	case "/asp/XMLOperations.asp":
-		body = `<w3s.response><reqn>1234567890</reqn><retval>0</retval><retdesc>Ok</retdesc><operations cnt="1"><operation id="123" ts="456"><tranid>1234567890</tranid><pursesrc>Z123456789012</pursesrc><pursedest>Z0987654321098</pursedest><amount>100.00</amount><comiss>0.8</comiss><opertype>0</opertype><period>0</period><wminvid>0</wminvid><orderid>0</orderid><desc>Mock test</desc><datecrt>` + t + `</datecrt><dateupd>` + t + `</dateupd></operation></operations></w3s.response>`
+		body = `<w3s.response><reqn>1234567890</reqn><retval>0</retval><retdesc>Ok</retdesc><operations cnt="1"><operation id="123" ts="456"><tranid>1234567890</tranid><pursesrc>Z123456789012</pursesrc><pursedest>Z098765432109</pursedest><amount>100.00</amount><comiss>0.8</comiss><opertype>0</opertype><period>0</period><wminvid>0</wminvid><orderid>0</orderid><desc>Mock test</desc><datecrt>` + t + `</datecrt><dateupd>` + t + `</dateupd></operation></operations></w3s.response>`
 		break
 	case "/asp/XMLPurses.asp":
-		body = `<w3s.response><reqn>1234567890</reqn><retval>0</retval><retdesc>Ok</retdesc><purses cnt="1"><purse id="Z123456789012"><pursename>Mock purse</pursename><amount>112345.45</amount><desc>Тестовый кошелек</desc><outsideopen>0</outsideopen><lastintr>123</lastintr><lastouttr>321</lastouttr></purse></purses></w3s.response>`
+		body = `<w3s.response><reqn>1234567890</reqn><retval>0</retval><retdesc>Ok</retdesc><purses cnt="1"><purse id="Z123456789012"><pursename>Z123456789012</pursename><amount>112345.45</amount><desc>Тестовый кошелек</desc><outsideopen>0</outsideopen><lastintr>123</lastintr><lastouttr>321</lastouttr></purse></purses></w3s.response>`
 		break
 	default:
 		return &http.Response{
@@ -0,0 +1,292 @@
+// Package monitor checks the balances of WebMoney purses and raises alerts when they run low
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sidmal/webmoney"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	AlertLow       AlertKind = "low"
+	AlertRecovered AlertKind = "recovered"
+
+	defaultInterval    = 5 * time.Minute
+	defaultHistorySize = 288
+)
+
+var (
+	ErrorWmIdsNotConfigured      = errors.New("the WMIDs to check are not configured")
+	ErrorThresholdsNotConfigured = errors.New("the purses thresholds are not configured")
+	ErrorNotifiersNotConfigured  = errors.New("the notifiers of alerts are not configured")
+	ErrorPurseNotFound           = errors.New("the purse is not found in the balances of WMIDs")
+	ErrorHistorySizeIsIncorrect  = errors.New("the history size must be at least 2 samples")
+)
+
+type AlertKind string
+
+// Threshold is the balance below which the alert is raised and the balance at which it is resolved
+type Threshold struct {
+	Low  webmoney.Amount `json:"low"`
+	High webmoney.Amount `json:"high"`
+}
+
+// Alert notifies that the purse balance fell below the threshold or recovered
+type Alert struct {
+	Kind    AlertKind       `json:"kind"`
+	Purse   webmoney.Purse  `json:"purse"`
+	WmId    string          `json:"wmid"`
+	Balance webmoney.Amount `json:"balance"`
+	Threshold
+	// The average spending per hour over the history, zero if the balance is not spent
+	BurnRate webmoney.Amount `json:"burn_rate"`
+	// The estimated time when the purse runs dry at the burn rate, nil if the balance is not spent
+	Depletion *time.Time `json:"depletion,omitempty"`
+	Date      time.Time  `json:"date"`
+}
+
+// Sample is the purse balance at the check time
+type Sample struct {
+	Amount webmoney.Amount
+	Date   time.Time
+}
+
+// BalanceMonitor checks the balances of purses with GetBalance and notifies when they fall below
+// the thresholds. The alert is raised once and resolved once the balance is back to the high level,
+// so the balance swinging around the low level does not flood the notifiers
+type BalanceMonitor struct {
+	client  webmoney.XMLInterface
+	options *Options
+	now     func() time.Time
+
+	mu       sync.Mutex
+	history  map[webmoney.Purse][]Sample
+	alerting map[webmoney.Purse]bool
+}
+
+func NewBalanceMonitor(client webmoney.XMLInterface, opts ...Option) (*BalanceMonitor, error) {
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if len(options.wmIds) == 0 {
+		return nil, ErrorWmIdsNotConfigured
+	}
+
+	if len(options.thresholds) == 0 {
+		return nil, ErrorThresholdsNotConfigured
+	}
+
+	for purse := range options.thresholds {
+		if err := purse.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(options.notifiers) == 0 {
+		return nil, ErrorNotifiersNotConfigured
+	}
+
+	if options.interval <= 0 {
+		options.interval = defaultInterval
+	}
+
+	if options.historySize == 0 {
+		options.historySize = defaultHistorySize
+	}
+
+	if options.historySize < 2 {
+		return nil, ErrorHistorySizeIsIncorrect
+	}
+
+	if options.logger == nil {
+		options.logger = zap.NewNop()
+	}
+
+	monitor := &BalanceMonitor{
+		client:   client,
+		options:  options,
+		now:      time.Now,
+		history:  make(map[webmoney.Purse][]Sample),
+		alerting: make(map[webmoney.Purse]bool),
+	}
+
+	return monitor, nil
+}
+
+// Run checks the balances with the interval until the context is done. The check errors are logged,
+// it returns nil on the graceful shutdown
+func (m *BalanceMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.options.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Check(ctx); err != nil && ctx.Err() == nil {
+			m.options.logger.Warn("webmoney balances check failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check requests the balances of all WMIDs once, records them to the history and notifies about
+// the crossed thresholds. The alert state changes even if the notifier fails, the notifiers errors
+// are returned joined with the balance requests errors
+func (m *BalanceMonitor) Check(ctx context.Context) error {
+	var errs []error
+	seen := make(map[webmoney.Purse]bool)
+
+	for _, wmId := range m.options.wmIds {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		rsp, err := m.client.GetBalance(&webmoney.GetBalanceRequest{Wmid: wmId})
+
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, purse := range rsp.PurseList {
+			seen[purse.PurseName] = true
+			alert := m.record(wmId, purse.PurseName, purse.Amount)
+
+			if alert == nil {
+				continue
+			}
+
+			if err = m.notify(ctx, alert); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for purse := range m.options.thresholds {
+		if !seen[purse] {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrorPurseNotFound, purse))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// History returns the recorded balances of purse from the oldest to the newest
+func (m *BalanceMonitor) History(purse webmoney.Purse) []Sample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Sample(nil), m.history[purse]...)
+}
+
+// BurnRate returns the average spending of purse per hour over the history. The incomes are not
+// counted, so the top up does not hide the spending. It returns false if the history is too short
+func (m *BalanceMonitor) BurnRate(purse webmoney.Purse) (webmoney.Amount, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return burnRate(m.history[purse])
+}
+
+// Alerting reports whether the alert of purse is raised and not resolved yet
+func (m *BalanceMonitor) Alerting(purse webmoney.Purse) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.alerting[purse]
+}
+
+func (m *BalanceMonitor) record(wmId string, purse webmoney.Purse, amount webmoney.Amount) *Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	samples := append(m.history[purse], Sample{Amount: amount, Date: now})
+
+	if len(samples) > m.options.historySize {
+		samples = samples[len(samples)-m.options.historySize:]
+	}
+
+	m.history[purse] = samples
+	threshold, ok := m.options.thresholds[purse]
+
+	if !ok {
+		return nil
+	}
+
+	var kind AlertKind
+
+	switch alerting := m.alerting[purse]; {
+	case !alerting && amount < threshold.Low:
+		kind = AlertLow
+	case alerting && amount >= threshold.High:
+		kind = AlertRecovered
+	default:
+		return nil
+	}
+
+	m.alerting[purse] = kind == AlertLow
+	alert := &Alert{
+		Kind:      kind,
+		Purse:     purse,
+		WmId:      wmId,
+		Balance:   amount,
+		Threshold: threshold,
+		Date:      now,
+	}
+
+	if rate, ok := burnRate(samples); ok && rate > 0 {
+		depletion := now.Add(time.Duration(float64(amount) / float64(rate) * float64(time.Hour)))
+		alert.BurnRate, alert.Depletion = rate, &depletion
+	}
+
+	return alert
+}
+
+func (m *BalanceMonitor) notify(ctx context.Context, alert *Alert) error {
+	var errs []error
+
+	for _, notifier := range m.options.notifiers {
+		if err := notifier.Notify(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func burnRate(samples []Sample) (webmoney.Amount, bool) {
+	if len(samples) < 2 {
+		return 0, false
+	}
+
+	elapsed := samples[len(samples)-1].Date.Sub(samples[0].Date)
+
+	if elapsed <= 0 {
+		return 0, false
+	}
+
+	var spent webmoney.Amount
+
+	for i := 1; i < len(samples); i++ {
+		if delta := samples[i-1].Amount - samples[i].Amount; delta > 0 {
+			spent += delta
+		}
+	}
+
+	return webmoney.Amount(float64(spent) / elapsed.Hours()), true
+}
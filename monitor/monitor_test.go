@@ -0,0 +1,266 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"github.com/sidmal/webmoney"
+	"github.com/sidmal/webmoney/signer"
+	"github.com/sidmal/webmoney/wmtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	TestWmId       = "405002833238"
+	TestPassword   = "FvGqPdAy8reVWw789"
+	TestPurse      = webmoney.Purse("Z405002833238")
+	TestOtherPurse = webmoney.Purse("E405002833238")
+)
+
+type MonitorTestSuite struct {
+	suite.Suite
+	server *wmtest.Server
+	client webmoney.XMLInterface
+	now    time.Time
+
+	mu     sync.Mutex
+	alerts []*Alert
+}
+
+func Test_Monitor(t *testing.T) {
+	suite.Run(t, new(MonitorTestSuite))
+}
+
+func (suite *MonitorTestSuite) SetupTest() {
+	suite.server = wmtest.NewServer()
+	suite.server.AddPurse(TestWmId, TestPurse, 10000)
+	suite.server.AddPurse(TestWmId, TestOtherPurse, 500)
+
+	key, _, err := signer.GenerateKey(nil, TestWmId, TestPassword)
+
+	if err != nil {
+		suite.FailNow("Key generation failed", "%v", err)
+	}
+
+	opts := []webmoney.Option{
+		webmoney.WmId(TestWmId),
+		webmoney.KeyBytes(key),
+		webmoney.Password(TestPassword),
+		webmoney.Endpoint(suite.server.URL),
+	}
+	suite.client, err = webmoney.NewWebMoney(opts...)
+
+	if err != nil {
+		suite.FailNow("WebMoney client initialization failed", "%v", err)
+	}
+
+	suite.now = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	suite.alerts = nil
+}
+
+func (suite *MonitorTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *MonitorTestSuite) notify(_ context.Context, alert *Alert) error {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+
+	suite.alerts = append(suite.alerts, alert)
+
+	return nil
+}
+
+func (suite *MonitorTestSuite) newMonitor(opts ...Option) *BalanceMonitor {
+	opts = append(
+		[]Option{WmIds(TestWmId), PurseThreshold(TestPurse, 5000, 8000), Notifiers(NotifierFunc(suite.notify))},
+		opts...,
+	)
+	monitor, err := NewBalanceMonitor(suite.client, opts...)
+
+	if err != nil {
+		suite.FailNow("Balance monitor initialization failed", "%v", err)
+	}
+
+	monitor.now = func() time.Time {
+		return suite.now
+	}
+
+	return monitor
+}
+
+// check sets the balance of purse and checks it an hour after the previous check
+func (suite *MonitorTestSuite) check(monitor *BalanceMonitor, amount webmoney.Amount) {
+	suite.server.AddPurse(TestWmId, TestPurse, amount)
+	suite.now = suite.now.Add(time.Hour)
+	assert.NoError(suite.T(), monitor.Check(context.Background()))
+}
+
+func (suite *MonitorTestSuite) TestMonitor_Check_Hysteresis_Ok() {
+	monitor := suite.newMonitor()
+
+	suite.check(monitor, 6000)
+	assert.Empty(suite.T(), suite.alerts)
+	assert.False(suite.T(), monitor.Alerting(TestPurse))
+
+	suite.check(monitor, 4000)
+	assert.Len(suite.T(), suite.alerts, 1)
+	assert.True(suite.T(), monitor.Alerting(TestPurse))
+
+	alert := suite.alerts[0]
+	assert.Equal(suite.T(), AlertLow, alert.Kind)
+	assert.Equal(suite.T(), TestPurse, alert.Purse)
+	assert.Equal(suite.T(), TestWmId, alert.WmId)
+	assert.EqualValues(suite.T(), 4000, alert.Balance)
+	assert.Equal(suite.T(), Threshold{Low: 5000, High: 8000}, alert.Threshold)
+	assert.EqualValues(suite.T(), 2000, alert.BurnRate)
+	assert.Equal(suite.T(), suite.now.Add(2*time.Hour), *alert.Depletion)
+	assert.Equal(suite.T(), suite.now, alert.Date)
+
+	// The balance swinging between the levels does not raise the alert again
+	suite.check(monitor, 3000)
+	suite.check(monitor, 6000)
+	suite.check(monitor, 4500)
+	assert.Len(suite.T(), suite.alerts, 1)
+
+	suite.check(monitor, 8000)
+	assert.Len(suite.T(), suite.alerts, 2)
+	assert.Equal(suite.T(), AlertRecovered, suite.alerts[1].Kind)
+	assert.False(suite.T(), monitor.Alerting(TestPurse))
+
+	suite.check(monitor, 4999)
+	assert.Len(suite.T(), suite.alerts, 3)
+	assert.Equal(suite.T(), AlertLow, suite.alerts[2].Kind)
+}
+
+func (suite *MonitorTestSuite) TestMonitor_BurnRate_Ok() {
+	monitor := suite.newMonitor(HistorySize(3))
+
+	_, ok := monitor.BurnRate(TestPurse)
+	assert.False(suite.T(), ok)
+
+	suite.check(monitor, 10000)
+	_, ok = monitor.BurnRate(TestPurse)
+	assert.False(suite.T(), ok)
+
+	// The top up is not counted as the spending
+	suite.check(monitor, 9000)
+	suite.check(monitor, 12000)
+	rate, ok := monitor.BurnRate(TestPurse)
+	assert.True(suite.T(), ok)
+	assert.EqualValues(suite.T(), 500, rate)
+
+	// The oldest samples are dropped
+	suite.check(monitor, 11000)
+	history := monitor.History(TestPurse)
+	assert.Len(suite.T(), history, 3)
+	assert.EqualValues(suite.T(), 9000, history[0].Amount)
+	assert.EqualValues(suite.T(), 11000, history[2].Amount)
+
+	rate, ok = monitor.BurnRate(TestPurse)
+	assert.True(suite.T(), ok)
+	assert.EqualValues(suite.T(), 500, rate)
+
+	// The purses without thresholds are recorded too
+	assert.Len(suite.T(), monitor.History(TestOtherPurse), 3)
+}
+
+func (suite *MonitorTestSuite) TestMonitor_Check_NoBurnRate_Ok() {
+	monitor := suite.newMonitor()
+
+	suite.check(monitor, 4000)
+	assert.Len(suite.T(), suite.alerts, 1)
+	assert.Zero(suite.T(), suite.alerts[0].BurnRate)
+	assert.Nil(suite.T(), suite.alerts[0].Depletion)
+}
+
+func (suite *MonitorTestSuite) TestMonitor_Check_NotifierError() {
+	failed := NotifierFunc(func(ctx context.Context, alert *Alert) error {
+		return errors.New("TestMonitor_Check_NotifierError")
+	})
+	monitor := suite.newMonitor(Notifiers(failed))
+	suite.server.AddPurse(TestWmId, TestPurse, 4000)
+
+	err := monitor.Check(context.Background())
+	assert.EqualError(suite.T(), err, "TestMonitor_Check_NotifierError")
+	assert.True(suite.T(), monitor.Alerting(TestPurse))
+
+	// Other notifiers are notified anyway and the alert is not raised again
+	assert.Len(suite.T(), suite.alerts, 1)
+	assert.NoError(suite.T(), monitor.Check(context.Background()))
+	assert.Len(suite.T(), suite.alerts, 1)
+}
+
+func (suite *MonitorTestSuite) TestMonitor_Check_Error() {
+	monitor := suite.newMonitor(PurseThreshold("Z123456789012", 100, 100))
+
+	err := monitor.Check(context.Background())
+	assert.True(suite.T(), errors.Is(err, ErrorPurseNotFound))
+	assert.EqualError(suite.T(), err, "the purse is not found in the balances of WMIDs: Z123456789012")
+
+	suite.server.Script(webmoney.InterfaceX9, wmtest.Fault{Code: -100, Reason: "General error"})
+	err = monitor.Check(context.Background())
+	assert.Error(suite.T(), err)
+	assert.False(suite.T(), errors.Is(err, ErrorPurseNotFound))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(suite.T(), context.Canceled, monitor.Check(ctx))
+}
+
+func (suite *MonitorTestSuite) TestMonitor_Run_Ok() {
+	ch := make(chan *Alert, 1)
+	monitor := suite.newMonitor(Notifiers(NewChannelNotifier(ch)), Interval(10*time.Millisecond))
+	suite.server.AddPurse(TestWmId, TestPurse, 4000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() {
+		done <- monitor.Run(ctx)
+	}()
+
+	select {
+	case alert := <-ch:
+		assert.Equal(suite.T(), AlertLow, alert.Kind)
+	case <-time.After(5 * time.Second):
+		suite.FailNow("The alert is not delivered")
+	}
+
+	cancel()
+	assert.NoError(suite.T(), <-done)
+}
+
+func (suite *MonitorTestSuite) TestMonitor_NewBalanceMonitor_Error() {
+	notifier := NotifierFunc(suite.notify)
+
+	_, err := NewBalanceMonitor(suite.client, PurseThreshold(TestPurse, 1, 1), Notifiers(notifier))
+	assert.Equal(suite.T(), ErrorWmIdsNotConfigured, err)
+
+	_, err = NewBalanceMonitor(suite.client, WmIds(TestWmId), Notifiers(notifier))
+	assert.Equal(suite.T(), ErrorThresholdsNotConfigured, err)
+
+	_, err = NewBalanceMonitor(suite.client, WmIds(TestWmId), PurseThreshold("Z1", 1, 1), Notifiers(notifier))
+	assert.Equal(suite.T(), webmoney.ErrorPurseIsIncorrect, err)
+
+	_, err = NewBalanceMonitor(suite.client, WmIds(TestWmId), PurseThreshold(TestPurse, 1, 1))
+	assert.Equal(suite.T(), ErrorNotifiersNotConfigured, err)
+
+	_, err = NewBalanceMonitor(
+		suite.client,
+		WmIds(TestWmId),
+		PurseThreshold(TestPurse, 1, 1),
+		Notifiers(notifier),
+		HistorySize(1),
+	)
+	assert.Equal(suite.T(), ErrorHistorySizeIsIncorrect, err)
+
+	monitor, err := NewBalanceMonitor(suite.client, WmIds(TestWmId), PurseThreshold(TestPurse, 1, 1), Notifiers(notifier))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), defaultInterval, monitor.options.interval)
+	assert.Equal(suite.T(), defaultHistorySize, monitor.options.historySize)
+	assert.NotNil(suite.T(), monitor.options.logger)
+}
@@ -0,0 +1,132 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+const defaultWebhookTimeout = 10 * time.Second
+
+var ErrorWebhookRequestFailed = errors.New("the webhook request failed")
+
+// Notifier delivers the balance alerts, e.g. to the chat or the paging system
+type Notifier interface {
+	Notify(ctx context.Context, alert *Alert) error
+}
+
+// NotifierFunc is the function implementing Notifier
+type NotifierFunc func(ctx context.Context, alert *Alert) error
+
+// WebhookNotifier posts the alert as JSON to the URL, the response status must be 2xx
+type WebhookNotifier struct {
+	url        string
+	headers    http.Header
+	httpClient *http.Client
+}
+
+// LogNotifier writes the alerts to the log, the low balance alert with warn level
+type LogNotifier struct {
+	logger *zap.Logger
+}
+
+// ChannelNotifier sends the alerts to the channel, it waits for the receiver until the context is done
+type ChannelNotifier struct {
+	ch chan<- *Alert
+}
+
+func (m NotifierFunc) Notify(ctx context.Context, alert *Alert) error {
+	return m(ctx, alert)
+}
+
+// NewWebhookNotifier returns the notifier posting alerts to the URL with the headers, e.g. the authorization.
+// The http client with timeout is used if httpClient is nil
+func NewWebhookNotifier(url string, headers http.Header, httpClient *http.Client) Notifier {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultWebhookTimeout}
+	}
+
+	return &WebhookNotifier{url: url, headers: headers, httpClient: httpClient}
+}
+
+func NewLogNotifier(logger *zap.Logger) Notifier {
+	return &LogNotifier{logger: logger}
+}
+
+func NewChannelNotifier(ch chan<- *Alert) Notifier {
+	return &ChannelNotifier{ch: ch}
+}
+
+func (m *WebhookNotifier) Notify(ctx context.Context, alert *Alert) error {
+	body, err := json.Marshal(alert)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	for name, values := range m.headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	rsp, err := m.httpClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+
+	if rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: status %d", ErrorWebhookRequestFailed, rsp.StatusCode)
+	}
+
+	return nil
+}
+
+func (m *LogNotifier) Notify(_ context.Context, alert *Alert) error {
+	fields := []zap.Field{
+		zap.String("purse", alert.Purse.String()),
+		zap.String("wmid", alert.WmId),
+		zap.String("balance", alert.Balance.String()),
+		zap.String("low", alert.Threshold.Low.String()),
+		zap.String("high", alert.Threshold.High.String()),
+		zap.String("burn_rate", alert.BurnRate.String()),
+	}
+
+	if alert.Depletion != nil {
+		fields = append(fields, zap.Time("depletion", *alert.Depletion))
+	}
+
+	if alert.Kind == AlertLow {
+		m.logger.Warn("webmoney purse balance is low", fields...)
+	} else {
+		m.logger.Info("webmoney purse balance is recovered", fields...)
+	}
+
+	return nil
+}
+
+func (m *ChannelNotifier) Notify(ctx context.Context, alert *Alert) error {
+	select {
+	case m.ch <- alert:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,105 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestAlert(kind AlertKind) *Alert {
+	date := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	depletion := date.Add(2 * time.Hour)
+
+	return &Alert{
+		Kind:      kind,
+		Purse:     TestPurse,
+		WmId:      TestWmId,
+		Balance:   4000,
+		Threshold: Threshold{Low: 5000, High: 8000},
+		BurnRate:  2000,
+		Depletion: &depletion,
+		Date:      date,
+	}
+}
+
+func TestNotifier_Webhook_Ok(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL, http.Header{"Authorization": {"Bearer token"}}, nil)
+	assert.NoError(t, notifier.Notify(context.Background(), newTestAlert(AlertLow)))
+
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(body, &fields))
+	assert.Equal(t, "low", fields["kind"])
+	assert.Equal(t, "Z405002833238", fields["purse"])
+	assert.Equal(t, "405002833238", fields["wmid"])
+	assert.Equal(t, "40", fields["balance"])
+	assert.Equal(t, "50", fields["low"])
+	assert.Equal(t, "80", fields["high"])
+	assert.Equal(t, "20", fields["burn_rate"])
+	assert.Equal(t, "2024-03-01T12:00:00Z", fields["depletion"])
+	assert.Equal(t, "2024-03-01T10:00:00Z", fields["date"])
+}
+
+func TestNotifier_Webhook_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL, nil, server.Client()).Notify(context.Background(), newTestAlert(AlertLow))
+	assert.True(t, errors.Is(err, ErrorWebhookRequestFailed))
+	assert.EqualError(t, err, "the webhook request failed: status 502")
+
+	err = NewWebhookNotifier("http://127.0.0.1:0", nil, nil).Notify(context.Background(), newTestAlert(AlertLow))
+	assert.Error(t, err)
+}
+
+func TestNotifier_Log_Ok(t *testing.T) {
+	core, recorder := observer.New(zapcore.InfoLevel)
+	notifier := NewLogNotifier(zap.New(core))
+
+	assert.NoError(t, notifier.Notify(context.Background(), newTestAlert(AlertLow)))
+	alert := newTestAlert(AlertRecovered)
+	alert.Depletion = nil
+	assert.NoError(t, notifier.Notify(context.Background(), alert))
+
+	entries := recorder.AllUntimed()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	assert.Equal(t, "webmoney purse balance is low", entries[0].Message)
+	assert.Equal(t, "Z405002833238", entries[0].ContextMap()["purse"])
+	assert.Equal(t, "40", entries[0].ContextMap()["balance"])
+	assert.Contains(t, entries[0].ContextMap(), "depletion")
+	assert.Equal(t, zapcore.InfoLevel, entries[1].Level)
+	assert.NotContains(t, entries[1].ContextMap(), "depletion")
+}
+
+func TestNotifier_Channel_Ok(t *testing.T) {
+	ch := make(chan *Alert, 1)
+	alert := newTestAlert(AlertLow)
+
+	assert.NoError(t, NewChannelNotifier(ch).Notify(context.Background(), alert))
+	assert.Equal(t, alert, <-ch)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, NewChannelNotifier(make(chan *Alert)).Notify(ctx, alert))
+}
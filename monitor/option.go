@@ -0,0 +1,70 @@
+package monitor
+
+import (
+	"github.com/sidmal/webmoney"
+	"go.uber.org/zap"
+	"time"
+)
+
+type Options struct {
+	// The WMIDs whose purses balances are checked
+	wmIds []string
+	// The thresholds of purses balances
+	thresholds map[webmoney.Purse]Threshold
+	// The notifiers of low balance alerts
+	notifiers []Notifier
+	// The interval between checks
+	interval time.Duration
+	// The number of balance samples kept per purse to calculate the burn rate
+	historySize int
+	// The logger
+	logger *zap.Logger
+}
+
+type Option func(*Options)
+
+func WmIds(val ...string) Option {
+	return func(opts *Options) {
+		opts.wmIds = append(opts.wmIds, val...)
+	}
+}
+
+// PurseThreshold sets the threshold of purse balance: the alert is raised when the balance falls below low
+// and resolved when the balance is back to high or above it. The high lower than low is set to low
+func PurseThreshold(purse webmoney.Purse, low, high webmoney.Amount) Option {
+	return func(opts *Options) {
+		if opts.thresholds == nil {
+			opts.thresholds = make(map[webmoney.Purse]Threshold)
+		}
+
+		if high < low {
+			high = low
+		}
+
+		opts.thresholds[purse] = Threshold{Low: low, High: high}
+	}
+}
+
+func Notifiers(val ...Notifier) Option {
+	return func(opts *Options) {
+		opts.notifiers = append(opts.notifiers, val...)
+	}
+}
+
+func Interval(val time.Duration) Option {
+	return func(opts *Options) {
+		opts.interval = val
+	}
+}
+
+func HistorySize(val int) Option {
+	return func(opts *Options) {
+		opts.historySize = val
+	}
+}
+
+func Logger(val *zap.Logger) Option {
+	return func(opts *Options) {
+		opts.logger = val
+	}
+}
@@ -0,0 +1,41 @@
+package monitor
+
+import (
+	"github.com/sidmal/webmoney"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestMonitorOptions_Setters(t *testing.T) {
+	logger := zap.NewNop()
+	notifier := NewLogNotifier(logger)
+
+	opts := []Option{
+		WmIds(TestWmId),
+		WmIds("123456789012"),
+		PurseThreshold(TestPurse, 5000, 8000),
+		PurseThreshold(TestOtherPurse, 300, 100),
+		Notifiers(notifier),
+		Interval(time.Second),
+		HistorySize(10),
+		Logger(logger),
+	}
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	assert.Equal(t, []string{TestWmId, "123456789012"}, options.wmIds)
+	assert.Equal(
+		t,
+		map[webmoney.Purse]Threshold{TestPurse: {Low: 5000, High: 8000}, TestOtherPurse: {Low: 300, High: 300}},
+		options.thresholds,
+	)
+	assert.Equal(t, []Notifier{notifier}, options.notifiers)
+	assert.Equal(t, time.Second, options.interval)
+	assert.Equal(t, 10, options.historySize)
+	assert.Equal(t, logger, options.logger)
+}
@@ -0,0 +1,207 @@
+package webmoney
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPursesRefreshInterval = time.Minute
+)
+
+var (
+	ErrorIdentitiesNotConfigured = errors.New("the WMIDs of the multi WMID client are not configured")
+	ErrorWmIdNotRegistered       = errors.New("the WMID is not registered in the multi WMID client")
+	ErrorPurseOwnerUnknown       = errors.New("the WMID owning the purse is unknown")
+)
+
+// MultiClient communicates with WebMoney XML interfaces on behalf of several WMIDs. The operation is sent
+// by the client of WMID owning the purse, the purses of WMIDs are learned from GetBalance responses
+type MultiClient struct {
+	clients         map[string]*WebMoney
+	wmIds           []string
+	refreshInterval time.Duration
+
+	mu          sync.RWMutex
+	owners      map[Purse]string
+	refreshedAt time.Time
+}
+
+// NewMultiClient returns the client of WMIDs registered with Identity option, other options are shared by all WMIDs
+func NewMultiClient(opts ...Option) (*MultiClient, error) {
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if len(options.identities) == 0 {
+		return nil, ErrorIdentitiesNotConfigured
+	}
+
+	multiClient := &MultiClient{
+		clients:         make(map[string]*WebMoney, len(options.identities)),
+		refreshInterval: options.pursesRefreshInterval,
+		owners:          make(map[Purse]string),
+	}
+
+	if multiClient.refreshInterval <= 0 {
+		multiClient.refreshInterval = defaultPursesRefreshInterval
+	}
+
+	for wmId := range options.identities {
+		multiClient.wmIds = append(multiClient.wmIds, wmId)
+	}
+
+	sort.Strings(multiClient.wmIds)
+
+	for _, wmId := range multiClient.wmIds {
+		// The HTTP client of the first WMID is shared by others
+		clientOpts := append(append([]Option{}, opts...), WmId(wmId), Signer(options.identities[wmId]))
+
+		if options.httpClient == nil && len(multiClient.clients) > 0 {
+			clientOpts = append(clientOpts, httpClient(multiClient.clients[multiClient.wmIds[0]].httpClient))
+		}
+
+		wm, err := NewWebMoney(clientOpts...)
+
+		if err != nil {
+			return nil, err
+		}
+
+		multiClient.clients[wmId] = wm.(*WebMoney)
+	}
+
+	return multiClient, nil
+}
+
+func (m *MultiClient) TransferMoney(in *TransferMoneyRequest) (*TransferMoneyResponse, error) {
+	client, err := m.purseClient(context.Background(), in.PurseSrc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return client.TransferMoney(in)
+}
+
+func (m *MultiClient) GetTransactionsHistory(in *GetTransactionsHistoryRequest) (*GetTransactionsHistoryResponse, error) {
+	client, err := m.purseClient(context.Background(), in.Purse)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetTransactionsHistory(in)
+}
+
+// GetBalance returns the purses of WMID by its own client and remembers WMID as their owner
+func (m *MultiClient) GetBalance(in *GetBalanceRequest) (*GetBalanceResponse, error) {
+	return m.getBalance(context.Background(), in)
+}
+
+func (m *MultiClient) IterateTransactions(ctx context.Context, purse Purse, from, to time.Time) *TransactionsCursor {
+	client, err := m.purseClient(ctx, purse)
+
+	if err != nil {
+		return &TransactionsCursor{ctx: ctx, purse: purse, err: err}
+	}
+
+	return client.IterateTransactions(ctx, purse, from, to)
+}
+
+// RefreshPurses requests the purses of all registered WMIDs to update the purses owners
+func (m *MultiClient) RefreshPurses(ctx context.Context) error {
+	m.mu.Lock()
+	m.refreshedAt = time.Now()
+	m.mu.Unlock()
+
+	return m.refreshPurses(ctx)
+}
+
+func (m *MultiClient) refreshPurses(ctx context.Context) error {
+	for _, wmId := range m.wmIds {
+		if _, err := m.getBalance(ctx, &GetBalanceRequest{Wmid: wmId}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Owner returns WMID owning the purse if it is known
+func (m *MultiClient) Owner(purse Purse) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	wmId, ok := m.owners[purse]
+
+	return wmId, ok
+}
+
+func (m *MultiClient) getBalance(ctx context.Context, in *GetBalanceRequest) (*GetBalanceResponse, error) {
+	client, ok := m.clients[in.Wmid]
+
+	if !ok {
+		return nil, ErrorWmIdNotRegistered
+	}
+
+	out, err := client.getBalance(ctx, in)
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, purse := range out.PurseList {
+		m.owners[purse.PurseName] = in.Wmid
+	}
+
+	return out, nil
+}
+
+// purseClient returns the client of WMID owning the purse, the purses are refreshed once if the owner is unknown
+// and the purses were not refreshed within the refresh interval
+func (m *MultiClient) purseClient(ctx context.Context, purse Purse) (*WebMoney, error) {
+	if err := purse.Validate(); err != nil {
+		return nil, err
+	}
+
+	wmId, ok := m.Owner(purse)
+
+	if !ok {
+		if !m.refreshDue() {
+			return nil, ErrorPurseOwnerUnknown
+		}
+
+		if err := m.refreshPurses(ctx); err != nil {
+			return nil, err
+		}
+
+		if wmId, ok = m.Owner(purse); !ok {
+			return nil, ErrorPurseOwnerUnknown
+		}
+	}
+
+	return m.clients[wmId], nil
+}
+
+// refreshDue reserves the purses refresh if the refresh interval is passed, the failed refresh is not repeated
+// within the interval too, so the unknown purses do not flood WebMoney with X9 requests
+func (m *MultiClient) refreshDue() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if time.Since(m.refreshedAt) < m.refreshInterval {
+		return false
+	}
+
+	m.refreshedAt = time.Now()
+
+	return true
+}
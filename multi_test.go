@@ -0,0 +1,216 @@
+package webmoney
+
+import (
+	"context"
+	"errors"
+	"github.com/sidmal/webmoney/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testOtherWmId = "210987654321"
+)
+
+var wmIdRequestRegex = regexp.MustCompile(`<wmid>([0-9]+)</wmid>`)
+
+// multiTransport answers X9 requests with the purses of WMID and records WMIDs of all requests
+type multiTransport struct {
+	mu       sync.Mutex
+	purses   map[string][]Purse
+	requests []string
+	fail     bool
+}
+
+func (m *multiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	b, _ := ioutil.ReadAll(req.Body)
+	wmId := wmIdRequestRegex.FindStringSubmatch(string(b))[1]
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, req.URL.Path+" "+wmId)
+	body := `<w3s.response><reqn>1</reqn><retval>0</retval><retdesc></retdesc>`
+
+	switch {
+	case m.fail:
+		body = `<w3s.response><reqn>1</reqn><retval>-100</retval><retdesc>General error</retdesc></w3s.response>`
+	case req.URL.Path == "/asp/XMLPurses.asp":
+		body += `<purses cnt="1">`
+
+		for _, purse := range m.purses[wmId] {
+			body += `<purse><pursename>` + purse.String() + `</pursename><amount>1.00</amount></purse>`
+		}
+
+		body += `</purses></w3s.response>`
+	case req.URL.Path == "/asp/XMLTrans.asp":
+		body += `<operation id="1"><tranid>1</tranid><amount>1.00</amount></operation></w3s.response>`
+	default:
+		body += `<operations cnt="0"></operations></w3s.response>`
+	}
+
+	rsp := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Header:     make(http.Header),
+	}
+
+	return rsp, nil
+}
+
+type MultiClientTestSuite struct {
+	suite.Suite
+	transport *multiTransport
+	client    *MultiClient
+}
+
+func Test_MultiClient(t *testing.T) {
+	suite.Run(t, new(MultiClientTestSuite))
+}
+
+func (suite *MultiClientTestSuite) SetupTest() {
+	suite.transport = &multiTransport{
+		purses: map[string][]Purse{
+			TestWmId:      {"Z123456789012", "E123456789012"},
+			testOtherWmId: {"Z210987654321"},
+		},
+	}
+	signer := &mocks.WebMoneySignerInterface{}
+	signer.On("Sign", mock.Anything).Return("signature", nil)
+
+	opts := []Option{
+		Identity(TestWmId, signer),
+		Identity(testOtherWmId, signer),
+		httpClient(&http.Client{Transport: suite.transport}),
+	}
+	client, err := NewMultiClient(opts...)
+
+	if err != nil {
+		suite.FailNow("Multi WMID client initialization failed", "%v", err)
+	}
+
+	suite.client = client
+}
+
+func (suite *MultiClientTestSuite) TestMultiClient_XMLInterface_Ok() {
+	var client XMLInterface = suite.client
+	assert.NotNil(suite.T(), client)
+}
+
+func (suite *MultiClientTestSuite) TestMultiClient_NewMultiClient_Error() {
+	client, err := NewMultiClient(WmId(TestWmId))
+	assert.Equal(suite.T(), ErrorIdentitiesNotConfigured, err)
+	assert.Nil(suite.T(), client)
+
+	client, err = NewMultiClient(Identity("wmid", &mocks.WebMoneySignerInterface{}))
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), client)
+}
+
+func (suite *MultiClientTestSuite) TestMultiClient_NewMultiClient_SharedHttpClient_Ok() {
+	signer := &mocks.WebMoneySignerInterface{}
+	client, err := NewMultiClient(Identity(TestWmId, signer), Identity(testOtherWmId, signer))
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), client.clients[TestWmId].httpClient)
+	assert.Equal(suite.T(), client.clients[TestWmId].httpClient, client.clients[testOtherWmId].httpClient)
+}
+
+func (suite *MultiClientTestSuite) TestMultiClient_TransferMoney_RoutedByPurseSrc_Ok() {
+	in := &TransferMoneyRequest{TxnId: 1, PurseSrc: "Z210987654321", PurseDest: "Z123456789012", Amount: 100}
+	_, err := suite.client.TransferMoney(in)
+	assert.NoError(suite.T(), err)
+
+	// The purses are refreshed once for the unknown purse
+	expected := []string{
+		"/asp/XMLPurses.asp " + testOtherWmId,
+		"/asp/XMLPurses.asp " + TestWmId,
+		"/asp/XMLTrans.asp " + testOtherWmId,
+	}
+	assert.Equal(suite.T(), expected, suite.transport.requests)
+
+	in.TxnId, in.PurseSrc, in.PurseDest = 2, "Z123456789012", "Z210987654321"
+	_, err = suite.client.TransferMoney(in)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "/asp/XMLTrans.asp "+TestWmId, suite.transport.requests[3])
+}
+
+func (suite *MultiClientTestSuite) TestMultiClient_GetTransactionsHistory_RoutedByPurse_Ok() {
+	_, err := suite.client.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.client.GetTransactionsHistory(&GetTransactionsHistoryRequest{Purse: "E123456789012"})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "/asp/XMLOperations.asp "+TestWmId, suite.transport.requests[1])
+
+	wmId, ok := suite.client.Owner("E123456789012")
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), TestWmId, wmId)
+}
+
+func (suite *MultiClientTestSuite) TestMultiClient_IterateTransactions_Ok() {
+	cursor := suite.client.IterateTransactions(context.Background(), "Z210987654321", time.Now().Add(-time.Hour), time.Now())
+	assert.False(suite.T(), cursor.Next())
+	assert.NoError(suite.T(), cursor.Err())
+
+	cursor = suite.client.IterateTransactions(context.Background(), "Z999999999999", time.Now().Add(-time.Hour), time.Now())
+	assert.False(suite.T(), cursor.Next())
+	assert.Equal(suite.T(), ErrorPurseOwnerUnknown, cursor.Err())
+	assert.Nil(suite.T(), cursor.Operation())
+}
+
+func (suite *MultiClientTestSuite) TestMultiClient_PurseOwnerUnknown_Error() {
+	_, err := suite.client.TransferMoney(&TransferMoneyRequest{PurseSrc: "Z999999999999", PurseDest: "Z123456789012"})
+	assert.Equal(suite.T(), ErrorPurseOwnerUnknown, err)
+
+	_, err = suite.client.GetTransactionsHistory(&GetTransactionsHistoryRequest{Purse: "purse"})
+	assert.Equal(suite.T(), ErrorPurseIsIncorrect, err)
+}
+
+func (suite *MultiClientTestSuite) TestMultiClient_PurseOwnerUnknown_RefreshLimited() {
+	in := &TransferMoneyRequest{PurseSrc: "Z999999999999", PurseDest: "Z123456789012"}
+
+	// The unknown purses do not refresh the purses again within the refresh interval
+	for i := 0; i < 3; i++ {
+		_, err := suite.client.TransferMoney(in)
+		assert.Equal(suite.T(), ErrorPurseOwnerUnknown, err)
+	}
+
+	assert.Len(suite.T(), suite.transport.requests, 2)
+
+	// The purse added after the refresh is found by the refresh after the interval
+	suite.transport.purses[TestWmId] = append(suite.transport.purses[TestWmId], "Z999999999999")
+	suite.client.refreshedAt = time.Now().Add(-defaultPursesRefreshInterval)
+
+	in.TxnId, in.Amount = 1, 100
+	_, err := suite.client.TransferMoney(in)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), suite.transport.requests, 5)
+	assert.Equal(suite.T(), "/asp/XMLTrans.asp "+TestWmId, suite.transport.requests[4])
+
+	// The explicit refresh is not limited
+	assert.NoError(suite.T(), suite.client.RefreshPurses(context.Background()))
+	assert.Len(suite.T(), suite.transport.requests, 7)
+}
+
+func (suite *MultiClientTestSuite) TestMultiClient_GetBalance_Error() {
+	_, err := suite.client.GetBalance(&GetBalanceRequest{Wmid: "111111111111"})
+	assert.Equal(suite.T(), ErrorWmIdNotRegistered, err)
+
+	suite.transport.fail = true
+	_, err = suite.client.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.Error(suite.T(), err)
+
+	_, err = suite.client.TransferMoney(&TransferMoneyRequest{PurseSrc: "Z123456789012", PurseDest: "Z210987654321"})
+
+	var rspErr *ResponseError
+	assert.True(suite.T(), errors.As(err, &rspErr))
+	assert.Equal(suite.T(), -100, rspErr.Code)
+}
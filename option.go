@@ -1,9 +1,12 @@
 package webmoney
 
 import (
+	"github.com/sidmal/webmoney/signer"
 	"go.uber.org/zap"
 	"io"
+	"log/slog"
 	"net/http"
+	"time"
 )
 
 type Options struct {
@@ -11,16 +14,50 @@ type Options struct {
 	wmId string
 	// The WebMoney Pro *.kvm key
 	key string
+	// The content of WebMoney Pro *.kwm key file
+	keyBytes []byte
+	// The path to WebMoney Pro *.kwm key file
+	keyFile string
+	// The reader of WebMoney Pro *.kwm key file
+	keyReader io.Reader
 	// The password to WebMoney Pro *.kvm key
 	password string
+	// The base URL of WebMoney XML interfaces
+	endpoint string
+	// The provider of the key and the password, it replaces the key and the password options
+	keyProvider signer.KeyProvider
+	// The key is loaded from the provider for each signature and is not kept in memory
+	keyOnDemand bool
+	// The signer of requests, it replaces the key options, e.g. the remote signer
+	signer signer.WebMoneySignerInterface
+	// The signers of WMIDs served by the multi WMID client
+	identities map[string]signer.WebMoneySignerInterface
+	// The minimal interval between the purses refreshes of the multi WMID client on unknown purse
+	pursesRefreshInterval time.Duration
 	// The HTTP client to send requests
 	httpClient *http.Client
 	// The reader for WebMoney root certificate
 	rootCaReader io.Reader
 	// The logger
 	logger *zap.Logger
-	// The func to clear log before save
+	// The slog logger, it is used if zap logger not set
+	slogLogger *slog.Logger
+	// The rules to redact request and response fields before log by XML element or attribute name
+	redactionRules map[string]RedactMode
+	// The log levels of the exchange events
+	logLevels map[LogEvent]LogLevel
+	// The metrics collector, it is called as the innermost interceptor
+	metrics Interceptor
+	// The func to clear request before log
 	logClearFn func(req *http.Request) *http.Request
+	// The maximal period covered by single X3 request in transactions iterator
+	historyWindow time.Duration
+	// The limiter of requests rate
+	rateLimiter *RateLimiter
+	// The behaviour of the request exceeded the rate limit
+	rateLimitMode RateLimitMode
+	// The interceptors chain wrapping each operation with WebMoney XML interfaces
+	interceptors []Interceptor
 }
 
 type Option func(*Options)
@@ -37,24 +74,173 @@ func Key(val string) Option {
 	}
 }
 
+// KeyBytes sets the key as the raw content of *.kwm file
+func KeyBytes(val []byte) Option {
+	return func(opts *Options) {
+		opts.keyBytes = val
+	}
+}
+
+// KeyFile sets the path to *.kwm key file
+func KeyFile(val string) Option {
+	return func(opts *Options) {
+		opts.keyFile = val
+	}
+}
+
+// KeyReader sets the reader of *.kwm key file content
+func KeyReader(val io.Reader) Option {
+	return func(opts *Options) {
+		opts.keyReader = val
+	}
+}
+
+// KeyProvider sets the storage of the key and the password, e.g. signer.NewVaultKeyProvider
+func KeyProvider(val signer.KeyProvider) Option {
+	return func(opts *Options) {
+		opts.keyProvider = val
+	}
+}
+
+// KeyOnDemand makes the client to load and decode the key for each signature and wipe it after
+func KeyOnDemand(val bool) Option {
+	return func(opts *Options) {
+		opts.keyOnDemand = val
+	}
+}
+
+// Signer sets the signer of requests instead of the key, e.g. remote.RemoteSigner of the signing server
+func Signer(val signer.WebMoneySignerInterface) Option {
+	return func(opts *Options) {
+		opts.signer = val
+	}
+}
+
+// Identity registers the signer of WMID in the multi WMID client
+func Identity(wmId string, val signer.WebMoneySignerInterface) Option {
+	return func(opts *Options) {
+		if opts.identities == nil {
+			opts.identities = make(map[string]signer.WebMoneySignerInterface)
+		}
+
+		opts.identities[wmId] = val
+	}
+}
+
+// PursesRefreshInterval limits the purses refreshes of the multi WMID client, the unknown purse does not
+// refresh the purses again until the interval is passed, 1 minute by default
+func PursesRefreshInterval(val time.Duration) Option {
+	return func(opts *Options) {
+		opts.pursesRefreshInterval = val
+	}
+}
+
 func Password(val string) Option {
 	return func(opts *Options) {
 		opts.password = val
 	}
 }
 
+// Endpoint sets the base URL of WebMoney XML interfaces, e.g. the URL of wmtest.Server
+func Endpoint(val string) Option {
+	return func(opts *Options) {
+		opts.endpoint = val
+	}
+}
+
 func Logger(val *zap.Logger) Option {
 	return func(opts *Options) {
 		opts.logger = val
 	}
 }
 
+func SlogLogger(val *slog.Logger) Option {
+	return func(opts *Options) {
+		opts.slogLogger = val
+	}
+}
+
+// LogRedaction sets how the value of the request or response field with the given XML element
+// or attribute name is logged, e.g. LogRedaction("amount", RedactHide)
+func LogRedaction(field string, mode RedactMode) Option {
+	return func(opts *Options) {
+		if opts.redactionRules == nil {
+			opts.redactionRules = make(map[string]RedactMode)
+		}
+
+		opts.redactionRules[field] = mode
+	}
+}
+
+func EventLogLevel(event LogEvent, level LogLevel) Option {
+	return func(opts *Options) {
+		if opts.logLevels == nil {
+			opts.logLevels = make(map[LogEvent]LogLevel)
+		}
+
+		opts.logLevels[event] = level
+	}
+}
+
+func Metrics(val Interceptor) Option {
+	return func(opts *Options) {
+		opts.metrics = val
+	}
+}
+
 func LogClearFn(val func(req *http.Request) *http.Request) Option {
 	return func(opts *Options) {
 		opts.logClearFn = val
 	}
 }
 
+func HistoryWindow(val time.Duration) Option {
+	return func(opts *Options) {
+		opts.historyWindow = val
+	}
+}
+
+func Interceptors(val ...Interceptor) Option {
+	return func(opts *Options) {
+		opts.interceptors = append(opts.interceptors, val...)
+	}
+}
+
+// Limiter sets the rate limiter, it can be shared by several clients working with the same WMID
+func Limiter(val *RateLimiter) Option {
+	return func(opts *Options) {
+		opts.rateLimiter = val
+	}
+}
+
+// WmIdRateLimit limits the requests of WMID to all interfaces with rate requests per second and burst
+func WmIdRateLimit(rate float64, burst int) Option {
+	return func(opts *Options) {
+		if opts.rateLimiter == nil {
+			opts.rateLimiter = NewRateLimiter()
+		}
+
+		opts.rateLimiter.SetWmIdLimit(rate, burst)
+	}
+}
+
+// InterfaceRateLimit limits the requests of WMID to the interface with rate requests per second and burst
+func InterfaceRateLimit(iface string, rate float64, burst int) Option {
+	return func(opts *Options) {
+		if opts.rateLimiter == nil {
+			opts.rateLimiter = NewRateLimiter()
+		}
+
+		opts.rateLimiter.SetInterfaceLimit(iface, rate, burst)
+	}
+}
+
+func OnRateLimit(val RateLimitMode) Option {
+	return func(opts *Options) {
+		opts.rateLimitMode = val
+	}
+}
+
 func httpClient(val *http.Client) Option {
 	return func(opts *Options) {
 		opts.httpClient = val
@@ -66,3 +252,15 @@ func rootCaReader(val io.Reader) Option {
 		opts.rootCaReader = val
 	}
 }
+
+func (m *Options) logSink() LogSink {
+	if m.logger != nil {
+		return NewZapLogSink(m.logger)
+	}
+
+	if m.slogLogger != nil {
+		return NewSlogLogSink(m.slogLogger)
+	}
+
+	return nil
+}
@@ -1,30 +1,61 @@
 package webmoney
 
 import (
+	"context"
+	"github.com/sidmal/webmoney/mocks"
+	"github.com/sidmal/webmoney/signer"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
+	"log/slog"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestWebmoneyOptions_Setters(t *testing.T) {
 	httpCln := &http.Client{}
 	caReader := strings.NewReader(``)
+	keyReader := strings.NewReader(`key`)
+	keyProvider := signer.NewEnvKeyProvider("KEY", "PASSWORD")
+	sig := &mocks.WebMoneySignerInterface{}
 	logger, err := zap.NewProduction()
 	assert.NoError(t, err)
+	slogLogger := slog.Default()
 	logClearFn := func(req *http.Request) *http.Request {
 		return req
 	}
+	interceptor := InterceptorFunc(func(ctx context.Context, invocation *Invocation, next Handler) error {
+		return next(ctx, invocation)
+	})
 
 	opts := []Option{
 		WmId("123456789012"),
 		Key("key"),
+		KeyBytes([]byte("key")),
+		KeyFile("/tmp/key.kwm"),
+		KeyReader(keyReader),
 		Password("password"),
+		KeyProvider(keyProvider),
+		KeyOnDemand(true),
+		Signer(sig),
+		Identity("210987654321", sig),
+		PursesRefreshInterval(time.Hour),
+		Endpoint("http://127.0.0.1:8080"),
 		httpClient(httpCln),
 		rootCaReader(caReader),
 		Logger(logger),
+		SlogLogger(slogLogger),
+		LogRedaction("amount", RedactHide),
+		LogRedaction("sign", RedactDrop),
+		EventLogLevel(LogEventExchange, LogLevelDebug),
 		LogClearFn(logClearFn),
+		HistoryWindow(time.Hour),
+		Interceptors(interceptor, interceptor),
+		Metrics(interceptor),
+		WmIdRateLimit(1, 1),
+		InterfaceRateLimit(InterfaceX2, 2, 2),
+		OnRateLimit(RateLimitFailFast),
 	}
 
 	options := &Options{}
@@ -35,9 +66,64 @@ func TestWebmoneyOptions_Setters(t *testing.T) {
 
 	assert.EqualValues(t, "123456789012", options.wmId)
 	assert.EqualValues(t, "key", options.key)
+	assert.EqualValues(t, []byte("key"), options.keyBytes)
+	assert.EqualValues(t, "/tmp/key.kwm", options.keyFile)
+	assert.EqualValues(t, keyReader, options.keyReader)
 	assert.EqualValues(t, "password", options.password)
+	assert.Equal(t, keyProvider, options.keyProvider)
+	assert.True(t, options.keyOnDemand)
+	assert.Equal(t, sig, options.signer)
+	assert.Equal(t, map[string]signer.WebMoneySignerInterface{"210987654321": sig}, options.identities)
+	assert.Equal(t, time.Hour, options.pursesRefreshInterval)
+	assert.EqualValues(t, "http://127.0.0.1:8080", options.endpoint)
 	assert.EqualValues(t, httpCln, options.httpClient)
 	assert.EqualValues(t, caReader, options.rootCaReader)
 	assert.EqualValues(t, logger, options.logger)
+	assert.EqualValues(t, slogLogger, options.slogLogger)
+	assert.Equal(t, map[string]RedactMode{"amount": RedactHide, "sign": RedactDrop}, options.redactionRules)
+	assert.Equal(t, map[LogEvent]LogLevel{LogEventExchange: LogLevelDebug}, options.logLevels)
 	assert.NotNil(t, options.logClearFn)
+	assert.EqualValues(t, time.Hour, options.historyWindow)
+	assert.Len(t, options.interceptors, 2)
+	assert.NotNil(t, options.metrics)
+	assert.NotNil(t, options.rateLimiter)
+	assert.Equal(t, &rateLimit{rate: 1, burst: 1}, options.rateLimiter.wmIdLimit)
+	assert.Equal(t, rateLimit{rate: 2, burst: 2}, options.rateLimiter.interfaceLimits[InterfaceX2])
+	assert.Equal(t, RateLimitFailFast, options.rateLimitMode)
+
+	limiter := NewRateLimiter()
+	Limiter(limiter)(options)
+	assert.Equal(t, limiter, options.rateLimiter)
+}
+
+func TestWebmoneyOptions_ExecuteOptions_LogDefaults(t *testing.T) {
+	options, err := executeOptions(
+		WmId("123456789012"),
+		Key("key"),
+		Password("password"),
+		LogRedaction("sign", RedactDrop),
+		EventLogLevel(LogEventExchange, LogLevelDebug),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, RedactDrop, options.redactionRules["sign"])
+	assert.Equal(t, RedactMask, options.redactionRules["pursesrc"])
+	assert.Equal(t, LogLevelDebug, options.logLevels[LogEventExchange])
+	assert.Equal(t, LogLevelError, options.logLevels[LogEventTransportError])
+	assert.Nil(t, options.logSink())
+
+	options.slogLogger = slog.Default()
+	assert.IsType(t, &slogLogSink{}, options.logSink())
+
+	options.logger = zap.NewNop()
+	assert.IsType(t, &zapLogSink{}, options.logSink())
+}
+
+func TestWebmoneyOptions_ExecuteOptions_Endpoint(t *testing.T) {
+	options, err := executeOptions(WmId("123456789012"), Key("key"), Password("password"))
+	assert.NoError(t, err)
+	assert.Equal(t, defaultEndpoint, options.endpoint)
+
+	options, err = executeOptions(WmId("123456789012"), Key("key"), Password("password"), Endpoint("http://127.0.0.1:8080/"))
+	assert.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:8080", options.endpoint)
 }
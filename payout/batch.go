@@ -0,0 +1,129 @@
+package payout
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sidmal/webmoney"
+	"io"
+	"strings"
+)
+
+const (
+	columnPurse      = "purse"
+	columnAmount     = "amount"
+	columnDesc       = "description"
+	columnExternalId = "external_id"
+)
+
+var (
+	ErrorBatchColumnMissing    = errors.New("the batch file column is missing")
+	ErrorItemExternalIdMissing = errors.New("the external id of the payout item is missing")
+	ErrorItemExternalIdTwice   = errors.New("the external id of the payout item is duplicated")
+	ErrorItemAmountIsIncorrect = errors.New("the amount of the payout item must be positive")
+)
+
+// Item is the single payout of the batch
+type Item struct {
+	// The identifier of the payout in the external system, it is unique in the batch
+	ExternalId string `json:"external_id"`
+	// The destination purse
+	Purse  webmoney.Purse  `json:"purse"`
+	Amount webmoney.Amount `json:"amount"`
+	Desc   string          `json:"description"`
+	// The tranid of the transfer assigned from the tranids counter of the source purse kept in the state file
+	TxnId int `json:"-"`
+}
+
+// ReadCSV reads the payout items from CSV with the header of purse, amount, description and external_id columns
+func ReadCSV(r io.Reader) ([]*Item, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{columnPurse, columnAmount, columnDesc, columnExternalId} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrorBatchColumnMissing, name)
+		}
+	}
+
+	var items []*Item
+
+	for {
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		amount, err := webmoney.ParseAmount(record[columns[columnAmount]])
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", len(items)+2, err)
+		}
+
+		item := &Item{
+			ExternalId: strings.TrimSpace(record[columns[columnExternalId]]),
+			Purse:      webmoney.Purse(strings.TrimSpace(record[columns[columnPurse]])),
+			Amount:     amount,
+			Desc:       record[columns[columnDesc]],
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// ReadJSON reads the payout items from JSON array of objects with purse, amount, description and external_id fields,
+// the amount is the string, e.g. "10.50"
+func ReadJSON(r io.Reader) ([]*Item, error) {
+	var items []*Item
+
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// validate checks the items of the batch
+func validate(items []*Item) error {
+	externalIds := make(map[string]struct{}, len(items))
+
+	for _, item := range items {
+		if item.ExternalId == "" {
+			return ErrorItemExternalIdMissing
+		}
+
+		if _, ok := externalIds[item.ExternalId]; ok {
+			return fmt.Errorf("%w: %s", ErrorItemExternalIdTwice, item.ExternalId)
+		}
+
+		if err := item.Purse.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", item.ExternalId, err)
+		}
+
+		if item.Amount <= 0 {
+			return fmt.Errorf("%w: %s", ErrorItemAmountIsIncorrect, item.ExternalId)
+		}
+
+		externalIds[item.ExternalId] = struct{}{}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package payout
+
+import (
+	"errors"
+	"github.com/sidmal/webmoney"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestPayout_ReadCSV_Ok(t *testing.T) {
+	content := "external_id,purse,amount,description\n" +
+		"p-1,Z123456789012,10.50,\"Payout, September\"\n" +
+		"p-2, Z210987654321,1,\n"
+	items, err := ReadCSV(strings.NewReader(content))
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, &Item{ExternalId: "p-1", Purse: "Z123456789012", Amount: 1050, Desc: "Payout, September"}, items[0])
+	assert.Equal(t, &Item{ExternalId: "p-2", Purse: "Z210987654321", Amount: 100}, items[1])
+}
+
+func TestPayout_ReadCSV_Error(t *testing.T) {
+	_, err := ReadCSV(strings.NewReader(""))
+	assert.Error(t, err)
+
+	_, err = ReadCSV(strings.NewReader("purse,amount,description\n"))
+	assert.True(t, errors.Is(err, ErrorBatchColumnMissing))
+	assert.EqualError(t, err, "the batch file column is missing: external_id")
+
+	_, err = ReadCSV(strings.NewReader("purse,amount,description,external_id\nZ123456789012,abc,,p-1\n"))
+	assert.True(t, errors.Is(err, webmoney.ErrorAmountIsIncorrect))
+
+	_, err = ReadCSV(strings.NewReader("purse,amount,description,external_id\nZ123456789012\n"))
+	assert.Error(t, err)
+}
+
+func TestPayout_ReadJSON_Ok(t *testing.T) {
+	content := `[{"external_id":"p-1","purse":"Z123456789012","amount":"10.5","description":"Payout"}]`
+	items, err := ReadJSON(strings.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, []*Item{{ExternalId: "p-1", Purse: "Z123456789012", Amount: 1050, Desc: "Payout"}}, items)
+
+	_, err = ReadJSON(strings.NewReader(`{}`))
+	assert.Error(t, err)
+}
+
+func TestPayout_Validate_Error(t *testing.T) {
+	items := [][]*Item{
+		{{Purse: "Z123456789012", Amount: 1}},
+		{{ExternalId: "p-1", Purse: "Z123456789012", Amount: 1}, {ExternalId: "p-1", Purse: "Z123456789012", Amount: 1}},
+		{{ExternalId: "p-1", Purse: "purse", Amount: 1}},
+		{{ExternalId: "p-1", Purse: "Z123456789012", Amount: 0}},
+	}
+	expected := []error{
+		ErrorItemExternalIdMissing,
+		ErrorItemExternalIdTwice,
+		webmoney.ErrorPurseIsIncorrect,
+		ErrorItemAmountIsIncorrect,
+	}
+
+	for i, v := range items {
+		assert.True(t, errors.Is(validate(v), expected[i]), i)
+	}
+}
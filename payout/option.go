@@ -0,0 +1,70 @@
+package payout
+
+import (
+	"github.com/sidmal/webmoney"
+	"go.uber.org/zap"
+)
+
+type Options struct {
+	// The purse paying the batch
+	purseSrc webmoney.Purse
+	// The identifier of the batch, e.g. "partners-2020-09", the external ids of the items are unique in it
+	batchId string
+	// The path to the file keeping the progress of the batches and the tranids counter of the source purse
+	stateFile string
+	// The first tranid of the counter of the source purse, the smaller tranids may be used by other tools
+	startTxnId int
+	// The number of transfers executed concurrently
+	concurrency int
+	// The limiter of transfers rate
+	rateLimiter *webmoney.TokenBucket
+	// The logger of the batch progress
+	logger *zap.Logger
+}
+
+type Option func(*Options)
+
+func PurseSrc(val webmoney.Purse) Option {
+	return func(opts *Options) {
+		opts.purseSrc = val
+	}
+}
+
+func BatchId(val string) Option {
+	return func(opts *Options) {
+		opts.batchId = val
+	}
+}
+
+func StateFile(val string) Option {
+	return func(opts *Options) {
+		opts.stateFile = val
+	}
+}
+
+// StartTxnId starts the tranids counter of the source purse from the value, e.g. the tranid following the tranids
+// of the transfers sent from the purse by other tools. The counter continued by the state file is not decreased
+func StartTxnId(val int) Option {
+	return func(opts *Options) {
+		opts.startTxnId = val
+	}
+}
+
+func Concurrency(val int) Option {
+	return func(opts *Options) {
+		opts.concurrency = val
+	}
+}
+
+// RateLimit limits the transfers to rate per second with the burst
+func RateLimit(rate float64, burst int) Option {
+	return func(opts *Options) {
+		opts.rateLimiter = webmoney.NewTokenBucket(rate, burst)
+	}
+}
+
+func Logger(val *zap.Logger) Option {
+	return func(opts *Options) {
+		opts.logger = val
+	}
+}
@@ -0,0 +1,34 @@
+package payout
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"testing"
+)
+
+func TestPayoutOptions_Setters(t *testing.T) {
+	logger := zap.NewNop()
+
+	opts := []Option{
+		PurseSrc("Z123456789012"),
+		BatchId("batch"),
+		StateFile("/tmp/state.jsonl"),
+		StartTxnId(100),
+		Concurrency(4),
+		RateLimit(2, 3),
+		Logger(logger),
+	}
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	assert.EqualValues(t, "Z123456789012", options.purseSrc)
+	assert.Equal(t, "batch", options.batchId)
+	assert.Equal(t, "/tmp/state.jsonl", options.stateFile)
+	assert.Equal(t, 100, options.startTxnId)
+	assert.Equal(t, 4, options.concurrency)
+	assert.NotNil(t, options.rateLimiter)
+	assert.Equal(t, logger, options.logger)
+}
@@ -0,0 +1,450 @@
+// Package payout executes the batches of WebMoney transfers. The tranids of the batch items are assigned
+// from the tranids counter of the source purse kept in the state file with the progress of the batches, so
+// the rerun of the batch after the crash skips completed transfers and checks the transfers sent without
+// the known result
+package payout
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"github.com/sidmal/webmoney"
+	"go.uber.org/zap"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultConcurrency = 1
+
+	// The period of X3 request to find the transfer sent by the previous run
+	pendingLookupWindow = 24 * time.Hour
+	// The difference of the local and WebMoney clocks, the operation created earlier than the first transfer
+	// of the item by more than it is the other transfer
+	pendingClockSkew = 5 * time.Minute
+
+	// The retval of the request which reqn is not greater than reqn of the previous request of WMID, the concurrent
+	// transfers may reach WebMoney out of order, they are not executed and sent again
+	codeReqnNotIncreasing = 102
+	reqnRetries           = 3
+
+	// The retval of the transfer which tranid is already used by the transfer from the source purse
+	codeTxnIdDuplicate = 103
+)
+
+var (
+	ErrorPurseSrcNotConfigured  = errors.New("the source purse of the payout is not configured")
+	ErrorBatchIdNotConfigured   = errors.New("the batch id of the payout is not configured")
+	ErrorStateFileNotConfigured = errors.New("the state file of the payout is not configured")
+	ErrorStartTxnIdIsIncorrect  = errors.New("the start tranid of the payout must not be negative")
+	ErrorTxnIdUsed              = errors.New("the tranid is used by the other transfer from the source purse")
+)
+
+// Engine executes the payout batches from the source purse
+type Engine struct {
+	client  webmoney.XMLInterface
+	options *Options
+}
+
+// Result is the result of the payout item
+type Result struct {
+	Item        *Item
+	Status      Status
+	OperationId string
+	Error       error
+}
+
+// Report is the results of the batch items in the order of the batch
+type Report struct {
+	Results   []*Result
+	Completed int
+	Skipped   int
+	Failed    int
+	Pending   int
+}
+
+func NewEngine(client webmoney.XMLInterface, opts ...Option) (*Engine, error) {
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if err := options.purseSrc.Validate(); err != nil {
+		if options.purseSrc == "" {
+			return nil, ErrorPurseSrcNotConfigured
+		}
+
+		return nil, err
+	}
+
+	if options.batchId == "" {
+		return nil, ErrorBatchIdNotConfigured
+	}
+
+	if options.stateFile == "" {
+		return nil, ErrorStateFileNotConfigured
+	}
+
+	if options.startTxnId < 0 {
+		return nil, ErrorStartTxnIdIsIncorrect
+	}
+
+	if options.concurrency < 1 {
+		options.concurrency = defaultConcurrency
+	}
+
+	if options.logger == nil {
+		options.logger = zap.NewNop()
+	}
+
+	return &Engine{client: client, options: options}, nil
+}
+
+// Run executes the transfers of the batch items not completed by the previous runs. The transfer failed
+// with WebMoney error is recorded as failed, the transfer failed with transport error is left pending
+// and it is checked with X3 interface on rerun. The items not started before the context is done
+// are missing in the report
+func (m *Engine) Run(ctx context.Context, items []*Item) (*Report, error) {
+	if err := validate(items); err != nil {
+		return nil, err
+	}
+
+	st, err := openState(m.options.stateFile, m.options.batchId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = st.close()
+	}()
+
+	st.seed(m.options.purseSrc, m.options.startTxnId)
+
+	for _, item := range items {
+		item.TxnId = st.txnId(m.options.purseSrc, item.ExternalId)
+	}
+
+	results := make([]*Result, len(items))
+	queue := make(chan int)
+	errs := make(chan error, m.options.concurrency)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < m.options.concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for idx := range queue {
+				result, err := m.execute(ctx, st, items[idx])
+
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				results[idx] = result
+			}
+		}()
+	}
+
+	err = m.dispatch(ctx, st, items, results, queue, errs)
+	close(queue)
+	wg.Wait()
+
+	if err == nil {
+		select {
+		case err = <-errs:
+		default:
+		}
+	}
+
+	return newReport(results), err
+}
+
+// dispatch sends the indexes of the items not completed yet to the workers until the context is done
+// or the worker fails on the state file writing
+func (m *Engine) dispatch(
+	ctx context.Context,
+	st *state,
+	items []*Item,
+	results []*Result,
+	queue chan<- int,
+	errs chan error,
+) error {
+	for idx, item := range items {
+		if rec, ok := st.get(item.ExternalId); ok && rec.Status == StatusCompleted {
+			results[idx] = &Result{Item: item, Status: StatusSkipped, OperationId: rec.OperationId}
+			continue
+		}
+
+		select {
+		case queue <- idx:
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// execute transfers the item, the returned error is the state file error stopping the batch
+func (m *Engine) execute(ctx context.Context, st *state, item *Item) (*Result, error) {
+	logger := m.options.logger.With(zap.String("external_id", item.ExternalId), zap.Int("tranid", item.TxnId))
+
+	sent := time.Now()
+
+	if rec, ok := st.get(item.ExternalId); ok && rec.Status == StatusPending {
+		operation, used, err := m.lookup(item, rec.Time)
+
+		if err != nil {
+			logger.Warn("payout pending transfer lookup failed", zap.Error(err))
+			return &Result{Item: item, Status: StatusPending, Error: err}, nil
+		}
+
+		if used {
+			logger.Error("payout pending tranid is used by the other transfer")
+			return m.release(st, item)
+		}
+
+		if operation != nil {
+			logger.Info("payout pending transfer found", zap.String("operation_id", operation.Id))
+			return m.complete(st, item, operation)
+		}
+
+		// The pending record keeps the time of the first transfer with the tranid, the lookups start from it
+		sent = rec.Time
+	}
+
+	if m.options.rateLimiter != nil {
+		if err := m.options.rateLimiter.Wait(ctx); err != nil {
+			return &Result{Item: item, Status: StatusPending, Error: err}, nil
+		}
+	}
+
+	rec := &record{
+		ExternalId: item.ExternalId,
+		PurseSrc:   m.options.purseSrc,
+		TxnId:      item.TxnId,
+		Status:     StatusPending,
+		Time:       sent,
+	}
+
+	if err := st.put(rec); err != nil {
+		return nil, err
+	}
+
+	operation, err := m.transfer(item)
+
+	if err != nil {
+		var rspErr *webmoney.ResponseError
+
+		if !errors.As(err, &rspErr) {
+			logger.Warn("payout transfer result is unknown", zap.Error(err))
+			return &Result{Item: item, Status: StatusPending, Error: err}, nil
+		}
+
+		// The tranid may be used by the transfer of the item whose response was lost, it is not failed
+		// until the lookup shows the other transfer
+		if rspErr.Code == codeTxnIdDuplicate {
+			return m.duplicate(st, item, sent, rspErr)
+		}
+
+		logger.Warn("payout transfer failed", zap.Error(err))
+		rec := &record{
+			ExternalId: item.ExternalId,
+			PurseSrc:   m.options.purseSrc,
+			TxnId:      item.TxnId,
+			Status:     StatusFailed,
+			Error:      err.Error(),
+			Time:       time.Now(),
+		}
+
+		if err := st.put(rec); err != nil {
+			return nil, err
+		}
+
+		return &Result{Item: item, Status: StatusFailed, Error: rspErr}, nil
+	}
+
+	logger.Info("payout transfer completed", zap.String("operation_id", operation.Id))
+
+	return m.complete(st, item, operation)
+}
+
+// duplicate completes the item if its transfer is found by the tranid, the item is failed without the tranid
+// if the tranid is used by the other transfer, otherwise it is left pending, so it is checked again on rerun
+// and never sent with the other tranid
+func (m *Engine) duplicate(st *state, item *Item, sent time.Time, rspErr *webmoney.ResponseError) (*Result, error) {
+	logger := m.options.logger.With(zap.String("external_id", item.ExternalId), zap.Int("tranid", item.TxnId))
+	operation, used, err := m.lookup(item, sent)
+
+	if err != nil {
+		logger.Warn("payout duplicated tranid lookup failed", zap.Error(err))
+		return &Result{Item: item, Status: StatusPending, Error: err}, nil
+	}
+
+	if used {
+		logger.Error("payout tranid is used by the other transfer", zap.Error(rspErr))
+		return m.release(st, item)
+	}
+
+	if operation == nil {
+		logger.Warn("payout transfer with duplicated tranid is not found", zap.Error(rspErr))
+		return &Result{Item: item, Status: StatusPending, Error: rspErr}, nil
+	}
+
+	logger.Info("payout transfer with duplicated tranid found", zap.String("operation_id", operation.Id))
+
+	return m.complete(st, item, operation)
+}
+
+// release fails the item whose tranid is used by the other transfer, so the transfer of the item is not executed.
+// The failed record has no tranid, the rerun sends the item with the next tranid of the counter
+func (m *Engine) release(st *state, item *Item) (*Result, error) {
+	rec := &record{
+		ExternalId: item.ExternalId,
+		PurseSrc:   m.options.purseSrc,
+		Status:     StatusFailed,
+		Error:      ErrorTxnIdUsed.Error(),
+		Time:       time.Now(),
+	}
+
+	if err := st.put(rec); err != nil {
+		return nil, err
+	}
+
+	return &Result{Item: item, Status: StatusFailed, Error: ErrorTxnIdUsed}, nil
+}
+
+// transfer sends the transfer of the item, the request is created for each attempt because the client
+// converts the description to windows-1251 in place
+func (m *Engine) transfer(item *Item) (*webmoney.TransferMoneyResponse, error) {
+	var rspErr *webmoney.ResponseError
+
+	for i := 0; ; i++ {
+		req := &webmoney.TransferMoneyRequest{
+			TxnId:     item.TxnId,
+			PurseSrc:  m.options.purseSrc,
+			PurseDest: item.Purse,
+			Amount:    item.Amount,
+			Desc:      item.Desc,
+		}
+		operation, err := m.client.TransferMoney(req)
+
+		if err == nil || i == reqnRetries || !errors.As(err, &rspErr) || rspErr.Code != codeReqnNotIncreasing {
+			return operation, err
+		}
+	}
+}
+
+func (m *Engine) complete(st *state, item *Item, operation *webmoney.TransferMoneyResponse) (*Result, error) {
+	rec := &record{
+		ExternalId:  item.ExternalId,
+		PurseSrc:    m.options.purseSrc,
+		TxnId:       item.TxnId,
+		Status:      StatusCompleted,
+		OperationId: operation.Id,
+		Time:        time.Now(),
+	}
+
+	if err := st.put(rec); err != nil {
+		return nil, err
+	}
+
+	return &Result{Item: item, Status: StatusCompleted, OperationId: operation.Id}, nil
+}
+
+// lookup returns the operation of the item sent by the previous run if it exists, the operation must match
+// the item by the destination purse and the amount and must not be created before the first transfer of the item.
+// The tranid found in the other operation is used by the other transfer
+func (m *Engine) lookup(item *Item, sent time.Time) (*webmoney.TransferMoneyResponse, bool, error) {
+	req := &webmoney.GetTransactionsHistoryRequest{
+		Purse:      m.options.purseSrc,
+		TxnId:      int64(item.TxnId),
+		DateStart:  webmoney.NewWMTime(sent.Add(-pendingLookupWindow)),
+		DateFinish: webmoney.NewWMTime(time.Now().Add(pendingLookupWindow)),
+	}
+	rsp, err := m.client.GetTransactionsHistory(req)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	used := false
+
+	for _, operation := range rsp.OperationList {
+		if operation.TxnId != int64(item.TxnId) || operation.PurseSrc != m.options.purseSrc {
+			continue
+		}
+
+		if operation.PurseDest == item.Purse && operation.Amount == item.Amount &&
+			!operation.DateCrt.Before(sent.Add(-pendingClockSkew)) {
+			return operation, false, nil
+		}
+
+		used = true
+	}
+
+	return nil, used, nil
+}
+
+func newReport(results []*Result) *Report {
+	report := &Report{}
+
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+
+		report.Results = append(report.Results, result)
+
+		switch result.Status {
+		case StatusCompleted:
+			report.Completed++
+		case StatusSkipped:
+			report.Skipped++
+		case StatusFailed:
+			report.Failed++
+		case StatusPending:
+			report.Pending++
+		}
+	}
+
+	return report
+}
+
+// WriteCSV writes the results of the items as CSV with the header
+func (m *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"external_id", "purse", "amount", "tranid", "status", "operation_id", "error"})
+
+	for _, result := range m.Results {
+		errStr := ""
+
+		if result.Error != nil {
+			errStr = result.Error.Error()
+		}
+
+		row := []string{
+			result.Item.ExternalId,
+			result.Item.Purse.String(),
+			result.Item.Amount.String(),
+			strconv.Itoa(result.Item.TxnId),
+			string(result.Status),
+			result.OperationId,
+			errStr,
+		}
+		_ = writer.Write(row)
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,410 @@
+package payout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sidmal/webmoney"
+	"github.com/sidmal/webmoney/signer"
+	"github.com/sidmal/webmoney/wmtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	TestWmId      = "405002833238"
+	TestPassword  = "FvGqPdAy8reVWw789"
+	TestPurseSrc  = "Z405002833238"
+	TestPartnerId = "123456789012"
+)
+
+// lostResponseClient executes the transfers but loses the responses of the transfers to the configured purses
+type lostResponseClient struct {
+	webmoney.XMLInterface
+	lost map[webmoney.Purse]bool
+}
+
+func (m *lostResponseClient) TransferMoney(in *webmoney.TransferMoneyRequest) (*webmoney.TransferMoneyResponse, error) {
+	rsp, err := m.XMLInterface.TransferMoney(in)
+
+	if err == nil && m.lost[in.PurseDest] {
+		return nil, errors.New("connection reset by peer")
+	}
+
+	return rsp, err
+}
+
+// laggingHistoryClient returns the empty history for the first requests as the history not updated yet
+type laggingHistoryClient struct {
+	webmoney.XMLInterface
+	lag int
+}
+
+func (m *laggingHistoryClient) GetTransactionsHistory(
+	in *webmoney.GetTransactionsHistoryRequest,
+) (*webmoney.GetTransactionsHistoryResponse, error) {
+	if m.lag > 0 {
+		m.lag--
+		return &webmoney.GetTransactionsHistoryResponse{}, nil
+	}
+
+	return m.XMLInterface.GetTransactionsHistory(in)
+}
+
+type PayoutTestSuite struct {
+	suite.Suite
+	key       []byte
+	server    *wmtest.Server
+	client    webmoney.XMLInterface
+	stateFile string
+	// The offset of the simulator clock
+	offset time.Duration
+}
+
+func Test_Payout(t *testing.T) {
+	suite.Run(t, new(PayoutTestSuite))
+}
+
+func (suite *PayoutTestSuite) SetupSuite() {
+	key, _, err := signer.GenerateKey(nil, TestWmId, TestPassword)
+
+	if err != nil {
+		suite.FailNow("Key generation failed", "%v", err)
+	}
+
+	suite.key = key
+}
+
+func (suite *PayoutTestSuite) SetupTest() {
+	suite.offset = 0
+	suite.server = wmtest.NewServer(wmtest.Clock(func() time.Time {
+		return time.Now().Add(suite.offset)
+	}))
+	suite.server.AddPurse(TestWmId, TestPurseSrc, 100000)
+
+	for i := 1; i <= 5; i++ {
+		suite.server.AddPurse(TestPartnerId, suite.partnerPurse(i), 0)
+	}
+
+	opts := []webmoney.Option{
+		webmoney.WmId(TestWmId),
+		webmoney.KeyBytes(suite.key),
+		webmoney.Password(TestPassword),
+		webmoney.Endpoint(suite.server.URL),
+	}
+	client, err := webmoney.NewWebMoney(opts...)
+
+	if err != nil {
+		suite.FailNow("WebMoney client initialization failed", "%v", err)
+	}
+
+	suite.client = client
+	suite.stateFile = filepath.Join(suite.T().TempDir(), "state.jsonl")
+}
+
+func (suite *PayoutTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *PayoutTestSuite) partnerPurse(i int) webmoney.Purse {
+	return webmoney.Purse(fmt.Sprintf("Z%012d", i))
+}
+
+func (suite *PayoutTestSuite) items(amounts ...webmoney.Amount) []*Item {
+	var items []*Item
+
+	for i, amount := range amounts {
+		item := &Item{
+			ExternalId: fmt.Sprintf("p-%d", i+1),
+			Purse:      suite.partnerPurse(i + 1),
+			Amount:     amount,
+			Desc:       "Partner payout",
+		}
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func (suite *PayoutTestSuite) engine(client webmoney.XMLInterface, opts ...Option) *Engine {
+	opts = append([]Option{PurseSrc(TestPurseSrc), BatchId("2020-09"), StateFile(suite.stateFile)}, opts...)
+	engine, err := NewEngine(client, opts...)
+
+	if err != nil {
+		suite.FailNow("Payout engine initialization failed", "%v", err)
+	}
+
+	return engine
+}
+
+func (suite *PayoutTestSuite) TestPayout_Run_Concurrent_Ok() {
+	engine := suite.engine(suite.client, Concurrency(3), RateLimit(1000, 5))
+	report, err := engine.Run(context.Background(), suite.items(100, 200, 300, 400, 500))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 5, report.Completed)
+	assert.Len(suite.T(), report.Results, 5)
+
+	for i, result := range report.Results {
+		assert.Equal(suite.T(), fmt.Sprintf("p-%d", i+1), result.Item.ExternalId)
+		assert.Equal(suite.T(), StatusCompleted, result.Status)
+		assert.NotEmpty(suite.T(), result.OperationId)
+
+		balance, _ := suite.server.Balance(suite.partnerPurse(i + 1))
+		assert.EqualValues(suite.T(), (i+1)*100, balance)
+	}
+
+	// The rerun skips the completed transfers
+	report, err = engine.Run(context.Background(), suite.items(100, 200, 300, 400, 500))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 5, report.Skipped)
+	assert.Len(suite.T(), suite.server.Operations(), 5)
+}
+
+func (suite *PayoutTestSuite) TestPayout_Run_FailedRetriedOnRerun_Ok() {
+	suite.server.Script(webmoney.InterfaceX2, wmtest.Fault{}, wmtest.Fault{Code: -100, Reason: "General error"})
+
+	engine := suite.engine(suite.client)
+	items := suite.items(100, 200, 300)
+	report, err := engine.Run(context.Background(), items)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, report.Completed)
+	assert.Equal(suite.T(), 1, report.Failed)
+	assert.Equal(suite.T(), StatusFailed, report.Results[1].Status)
+	assert.EqualError(suite.T(), report.Results[1].Error, "General error")
+
+	report, err = engine.Run(context.Background(), suite.items(100, 200, 300))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, report.Skipped)
+	assert.Equal(suite.T(), 1, report.Completed)
+	assert.Equal(suite.T(), StatusCompleted, report.Results[1].Status)
+
+	out := new(bytes.Buffer)
+	assert.NoError(suite.T(), report.WriteCSV(out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(suite.T(), lines, 4)
+	assert.Equal(suite.T(), "external_id,purse,amount,tranid,status,operation_id,error", lines[0])
+	assert.True(suite.T(), strings.HasPrefix(lines[2], fmt.Sprintf("p-2,Z000000000002,2,%d,completed,", items[1].TxnId)))
+}
+
+func (suite *PayoutTestSuite) TestPayout_Run_LostResponse_FoundOnRerun_Ok() {
+	items := suite.items(100, 200)
+	client := &lostResponseClient{XMLInterface: suite.client, lost: map[webmoney.Purse]bool{suite.partnerPurse(2): true}}
+
+	report, err := suite.engine(client).Run(context.Background(), items)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, report.Completed)
+	assert.Equal(suite.T(), 1, report.Pending)
+	assert.Len(suite.T(), suite.server.Operations(), 2)
+
+	// The pending transfer is found with X3 interface and is not sent again
+	report, err = suite.engine(suite.client).Run(context.Background(), suite.items(100, 200))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, report.Skipped)
+	assert.Equal(suite.T(), 1, report.Completed)
+	assert.NotEmpty(suite.T(), report.Results[1].OperationId)
+	assert.Len(suite.T(), suite.server.Operations(), 2)
+}
+
+func (suite *PayoutTestSuite) TestPayout_Run_TxnIdDuplicate_Found_Ok() {
+	client := &lostResponseClient{XMLInterface: suite.client, lost: map[webmoney.Purse]bool{suite.partnerPurse(1): true}}
+	report, err := suite.engine(client).Run(context.Background(), suite.items(100))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, report.Pending)
+
+	// The pending transfer is not found in the history yet, so it is sent again and rejected by the tranid
+	report, err = suite.engine(&laggingHistoryClient{XMLInterface: suite.client, lag: 1}).Run(context.Background(), suite.items(100))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, report.Completed)
+	assert.Equal(suite.T(), suite.server.Operations()[0].Id, report.Results[0].OperationId)
+	assert.Len(suite.T(), suite.server.Operations(), 1)
+}
+
+func (suite *PayoutTestSuite) TestPayout_Run_TxnIdDuplicate_OtherTransfer_Failed() {
+	_, err := suite.client.TransferMoney(&webmoney.TransferMoneyRequest{
+		TxnId:     1,
+		PurseSrc:  TestPurseSrc,
+		PurseDest: suite.partnerPurse(5),
+		Amount:    100,
+	})
+	assert.NoError(suite.T(), err)
+
+	// The tranid used by the transfer sent out of the payouts is not taken as the transfer of the item
+	report, err := suite.engine(suite.client).Run(context.Background(), suite.items(100))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, report.Failed)
+	assert.Equal(suite.T(), 1, report.Results[0].Item.TxnId)
+	assert.Equal(suite.T(), ErrorTxnIdUsed, report.Results[0].Error)
+	assert.Len(suite.T(), suite.server.Operations(), 1)
+
+	// The rerun sends the item with the next tranid
+	report, err = suite.engine(suite.client).Run(context.Background(), suite.items(100))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, report.Completed)
+	assert.Equal(suite.T(), 2, report.Results[0].Item.TxnId)
+	assert.Len(suite.T(), suite.server.Operations(), 2)
+}
+
+func (suite *PayoutTestSuite) TestPayout_Run_PendingTxnIdUsed_SentWithNextTxnId_Ok() {
+	suite.server.Script(webmoney.InterfaceX2, wmtest.Fault{Drop: true})
+
+	report, err := suite.engine(suite.client).Run(context.Background(), suite.items(100))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, report.Pending)
+
+	// The tranid of the pending item is used by the transfer sent by other tool meanwhile
+	_, err = suite.client.TransferMoney(&webmoney.TransferMoneyRequest{
+		TxnId:     1,
+		PurseSrc:  TestPurseSrc,
+		PurseDest: suite.partnerPurse(5),
+		Amount:    100,
+	})
+	assert.NoError(suite.T(), err)
+
+	report, err = suite.engine(suite.client).Run(context.Background(), suite.items(100))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, report.Failed)
+	assert.Equal(suite.T(), ErrorTxnIdUsed, report.Results[0].Error)
+
+	report, err = suite.engine(suite.client).Run(context.Background(), suite.items(100))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, report.Completed)
+	assert.Equal(suite.T(), 2, report.Results[0].Item.TxnId)
+	assert.Len(suite.T(), suite.server.Operations(), 2)
+}
+
+func (suite *PayoutTestSuite) TestPayout_Run_TxnIdDuplicate_OldTransfer_Failed() {
+	// The transfer of the same amount to the same purse sent by other tool an hour ago
+	suite.offset = -time.Hour
+	_, err := suite.client.TransferMoney(&webmoney.TransferMoneyRequest{
+		TxnId:     1,
+		PurseSrc:  TestPurseSrc,
+		PurseDest: suite.partnerPurse(1),
+		Amount:    100,
+	})
+	assert.NoError(suite.T(), err)
+	suite.offset = 0
+
+	report, err := suite.engine(suite.client).Run(context.Background(), suite.items(100))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, report.Failed)
+	assert.Equal(suite.T(), ErrorTxnIdUsed, report.Results[0].Error)
+	assert.Len(suite.T(), suite.server.Operations(), 1)
+}
+
+func (suite *PayoutTestSuite) TestPayout_Run_StartTxnId_Ok() {
+	for i := 1; i <= 2; i++ {
+		_, err := suite.client.TransferMoney(&webmoney.TransferMoneyRequest{
+			TxnId:     i,
+			PurseSrc:  TestPurseSrc,
+			PurseDest: suite.partnerPurse(5),
+			Amount:    100,
+		})
+		assert.NoError(suite.T(), err)
+	}
+
+	// The tranids of the transfers sent by other tools are skipped
+	report, err := suite.engine(suite.client, StartTxnId(3)).Run(context.Background(), suite.items(100, 200))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, report.Completed)
+	assert.Equal(suite.T(), 3, report.Results[0].Item.TxnId)
+	assert.Equal(suite.T(), 4, report.Results[1].Item.TxnId)
+
+	// The counter continued by the state file is not decreased by the start tranid
+	report, err = suite.engine(suite.client, BatchId("2020-10"), StartTxnId(3)).Run(context.Background(), suite.items(100))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, report.Completed)
+	assert.Equal(suite.T(), 5, report.Results[0].Item.TxnId)
+	assert.Len(suite.T(), suite.server.Operations(), 5)
+}
+
+func (suite *PayoutTestSuite) TestPayout_Run_TxnIdCounter_Ok() {
+	report, err := suite.engine(suite.client, BatchId("2020-09")).Run(context.Background(), suite.items(100, 200, 300))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, report.Completed)
+
+	// The batches of the purse sharing the state file continue the tranids of the previous batches
+	// even for the same external ids
+	report, err = suite.engine(suite.client, BatchId("2020-10")).Run(context.Background(), suite.items(100, 200))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, report.Completed)
+	assert.Equal(suite.T(), 4, report.Results[0].Item.TxnId)
+	assert.Equal(suite.T(), 5, report.Results[1].Item.TxnId)
+
+	var txnIds []int64
+
+	for _, operation := range suite.server.Operations() {
+		txnIds = append(txnIds, operation.TxnId)
+	}
+
+	assert.ElementsMatch(suite.T(), []int64{1, 2, 3, 4, 5}, txnIds)
+
+	// The rerun of the first batch keeps its tranids
+	report, err = suite.engine(suite.client, BatchId("2020-09")).Run(context.Background(), suite.items(100, 200, 300))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, report.Skipped)
+	assert.Equal(suite.T(), 3, report.Results[2].Item.TxnId)
+}
+
+func (suite *PayoutTestSuite) TestPayout_Run_PendingNotExecuted_SentAgain_Ok() {
+	suite.server.Script(webmoney.InterfaceX2, wmtest.Fault{Drop: true})
+
+	report, err := suite.engine(suite.client).Run(context.Background(), suite.items(100))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, report.Pending)
+	assert.Empty(suite.T(), suite.server.Operations())
+
+	report, err = suite.engine(suite.client).Run(context.Background(), suite.items(100))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, report.Completed)
+	assert.Len(suite.T(), suite.server.Operations(), 1)
+}
+
+func (suite *PayoutTestSuite) TestPayout_Run_ContextCancelled_Error() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err := suite.engine(suite.client).Run(ctx, suite.items(100, 200))
+	assert.Equal(suite.T(), context.Canceled, err)
+	assert.Empty(suite.T(), report.Results)
+	assert.Empty(suite.T(), suite.server.Operations())
+}
+
+func (suite *PayoutTestSuite) TestPayout_Run_Error() {
+	engine := suite.engine(suite.client)
+
+	_, err := engine.Run(context.Background(), []*Item{{Purse: TestPurseSrc, Amount: 1}})
+	assert.Equal(suite.T(), ErrorItemExternalIdMissing, err)
+
+	engine = suite.engine(suite.client, StateFile(suite.T().TempDir()))
+	_, err = engine.Run(context.Background(), suite.items(100))
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PayoutTestSuite) TestPayout_NewEngine_Error() {
+	opts := [][]Option{
+		{BatchId("batch"), StateFile(suite.stateFile)},
+		{PurseSrc("purse"), BatchId("batch"), StateFile(suite.stateFile)},
+		{PurseSrc(TestPurseSrc), StateFile(suite.stateFile)},
+		{PurseSrc(TestPurseSrc), BatchId("batch")},
+		{PurseSrc(TestPurseSrc), BatchId("batch"), StateFile(suite.stateFile), StartTxnId(-1)},
+	}
+	expected := []error{
+		ErrorPurseSrcNotConfigured,
+		webmoney.ErrorPurseIsIncorrect,
+		ErrorBatchIdNotConfigured,
+		ErrorStateFileNotConfigured,
+		ErrorStartTxnIdIsIncorrect,
+	}
+
+	for i, v := range opts {
+		engine, err := NewEngine(suite.client, v...)
+		assert.Equal(suite.T(), expected[i], err)
+		assert.Nil(suite.T(), engine)
+	}
+}
@@ -0,0 +1,166 @@
+package payout
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/sidmal/webmoney"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// StatusPending is the status of the transfer sent to WebMoney without the known result
+	StatusPending Status = "pending"
+	// StatusCompleted is the status of the executed transfer
+	StatusCompleted Status = "completed"
+	// StatusFailed is the status of the transfer rejected by WebMoney, it is sent again on rerun
+	StatusFailed Status = "failed"
+	// StatusSkipped is the status of the transfer completed by the previous run
+	StatusSkipped Status = "skipped"
+)
+
+// Status is the status of the payout item
+type Status string
+
+// record is the line of the state file, the last record of the item is its current state
+type record struct {
+	BatchId     string         `json:"batch_id"`
+	ExternalId  string         `json:"external_id"`
+	PurseSrc    webmoney.Purse `json:"pursesrc"`
+	TxnId       int            `json:"tranid"`
+	Status      Status         `json:"status"`
+	OperationId string         `json:"operation_id,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Time        time.Time      `json:"time"`
+}
+
+// state is the append only JSON lines file of the batches progress, each record is synced to the disk
+// before the next step, so the rerun after the crash knows the transfers possibly sent. The file is shared
+// by the batches of the source purse, the last tranid of the purse is the greatest tranid of its records
+type state struct {
+	batchId string
+
+	mu      sync.Mutex
+	file    *os.File
+	records map[string]*record
+	txnIds  map[webmoney.Purse]int
+}
+
+func openState(path, batchId string) (*state, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+
+	if err != nil {
+		return nil, err
+	}
+
+	st := &state{
+		batchId: batchId,
+		file:    file,
+		records: make(map[string]*record),
+		txnIds:  make(map[webmoney.Purse]int),
+	}
+	b, err := ioutil.ReadAll(file)
+
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	for _, line := range bytes.Split(b, []byte{'\n'}) {
+		rec := new(record)
+
+		// The last line may be partially written on crash
+		if err = json.Unmarshal(line, rec); err != nil {
+			continue
+		}
+
+		st.add(rec)
+	}
+
+	// The partially written line is terminated to keep the next records on their own lines
+	if len(b) > 0 && b[len(b)-1] != '\n' {
+		if _, err = file.Write([]byte{'\n'}); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+
+	return st, nil
+}
+
+// get returns the current record of the item of the batch
+func (m *state) get(externalId string) (*record, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.records[recordKey(m.batchId, externalId)]
+
+	return rec, ok
+}
+
+// txnId returns the tranid of the item: the tranid of its previous record or the next tranid of the source purse.
+// The new tranid is reserved in memory only, it is persisted with the pending record before the transfer
+func (m *state) txnId(purseSrc webmoney.Purse, externalId string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rec, ok := m.records[recordKey(m.batchId, externalId)]; ok && rec.TxnId > 0 {
+		return rec.TxnId
+	}
+
+	m.txnIds[purseSrc]++
+
+	return m.txnIds[purseSrc]
+}
+
+// seed continues the tranids counter of the purse from the start tranid if the counter is behind it
+func (m *state) seed(purseSrc webmoney.Purse, start int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if start-1 > m.txnIds[purseSrc] {
+		m.txnIds[purseSrc] = start - 1
+	}
+}
+
+func (m *state) put(rec *record) error {
+	rec.BatchId = m.batchId
+	b, err := json.Marshal(rec)
+
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err = m.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+
+	if err = m.file.Sync(); err != nil {
+		return err
+	}
+
+	m.add(rec)
+
+	return nil
+}
+
+func (m *state) add(rec *record) {
+	m.records[recordKey(rec.BatchId, rec.ExternalId)] = rec
+
+	if rec.TxnId > m.txnIds[rec.PurseSrc] {
+		m.txnIds[rec.PurseSrc] = rec.TxnId
+	}
+}
+
+func (m *state) close() error {
+	return m.file.Close()
+}
+
+func recordKey(batchId, externalId string) string {
+	return batchId + "\x00" + externalId
+}
@@ -0,0 +1,91 @@
+package payout
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestPayout_State_Ok(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.jsonl")
+
+	st, err := openState(path, "batch")
+	assert.NoError(t, err)
+	assert.NoError(t, st.put(&record{ExternalId: "p-1", TxnId: 1, Status: StatusPending}))
+	assert.NoError(t, st.put(&record{ExternalId: "p-1", TxnId: 1, Status: StatusCompleted, OperationId: "10"}))
+	assert.NoError(t, st.put(&record{ExternalId: "p-2", TxnId: 2, Status: StatusPending}))
+	assert.NoError(t, st.close())
+
+	// The record partially written on crash is ignored
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path, append(b, []byte(`{"batch_id":"batch","external_id":"p-2","sta`)...), 0600))
+
+	st, err = openState(path, "batch")
+	assert.NoError(t, err)
+	assert.NoError(t, st.put(&record{ExternalId: "p-3", TxnId: 3, Status: StatusFailed}))
+	assert.NoError(t, st.close())
+
+	st, err = openState(path, "batch")
+	assert.NoError(t, err)
+	defer func() {
+		_ = st.close()
+	}()
+
+	rec, ok := st.get("p-1")
+	assert.True(t, ok)
+	assert.Equal(t, StatusCompleted, rec.Status)
+	assert.Equal(t, "10", rec.OperationId)
+
+	rec, ok = st.get("p-2")
+	assert.True(t, ok)
+	assert.Equal(t, StatusPending, rec.Status)
+
+	rec, ok = st.get("p-3")
+	assert.True(t, ok)
+	assert.Equal(t, StatusFailed, rec.Status)
+
+	_, ok = st.get("p-4")
+	assert.False(t, ok)
+}
+
+func TestPayout_State_TxnId_Ok(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.jsonl")
+
+	st, err := openState(path, "2020-09")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, st.txnId("Z123456789012", "p-1"))
+	assert.Equal(t, 1, st.txnId("E123456789012", "p-1"))
+	assert.NoError(t, st.put(&record{ExternalId: "p-1", PurseSrc: "Z123456789012", TxnId: 7, Status: StatusPending}))
+	assert.Equal(t, 7, st.txnId("Z123456789012", "p-1"))
+	assert.Equal(t, 8, st.txnId("Z123456789012", "p-2"))
+	assert.NoError(t, st.close())
+
+	// The counter is restored from the records of all batches, the tranids reserved without records are free
+	st, err = openState(path, "2020-10")
+	assert.NoError(t, err)
+	defer func() {
+		_ = st.close()
+	}()
+
+	_, ok := st.get("p-1")
+	assert.False(t, ok)
+	assert.Equal(t, 8, st.txnId("Z123456789012", "p-1"))
+	assert.Equal(t, 1, st.txnId("E123456789012", "p-1"))
+
+	// The start tranid moves the counter forward only
+	st.seed("Z123456789012", 5)
+	st.seed("E123456789012", 100)
+	assert.Equal(t, 9, st.txnId("Z123456789012", "p-2"))
+	assert.Equal(t, 100, st.txnId("E123456789012", "p-2"))
+
+	// The failed record without tranid is sent with the next tranid
+	assert.NoError(t, st.put(&record{ExternalId: "p-3", PurseSrc: "Z123456789012", Status: StatusFailed}))
+	assert.Equal(t, 10, st.txnId("Z123456789012", "p-3"))
+}
+
+func TestPayout_State_Error(t *testing.T) {
+	_, err := openState(t.TempDir(), "batch")
+	assert.Error(t, err)
+}
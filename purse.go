@@ -0,0 +1,131 @@
+package webmoney
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	CurrencyWMZ Currency = "WMZ"
+	CurrencyWME Currency = "WME"
+	CurrencyWMR Currency = "WMR"
+	CurrencyWMU Currency = "WMU"
+	CurrencyWMB Currency = "WMB"
+	CurrencyWMX Currency = "WMX"
+	CurrencyWMG Currency = "WMG"
+	CurrencyWMK Currency = "WMK"
+	CurrencyWMC Currency = "WMC"
+	CurrencyWMD Currency = "WMD"
+	CurrencyWMH Currency = "WMH"
+	CurrencyWML Currency = "WML"
+	CurrencyWMV Currency = "WMV"
+	CurrencyWMT Currency = "WMT"
+	CurrencyWMY Currency = "WMY"
+)
+
+var (
+	purseRegex = regexp.MustCompile(`^[A-Z][0-9]{12}$`)
+
+	currencies = map[byte]Currency{
+		'Z': CurrencyWMZ,
+		'E': CurrencyWME,
+		'R': CurrencyWMR,
+		'U': CurrencyWMU,
+		'B': CurrencyWMB,
+		'X': CurrencyWMX,
+		'G': CurrencyWMG,
+		'K': CurrencyWMK,
+		'C': CurrencyWMC,
+		'D': CurrencyWMD,
+		'H': CurrencyWMH,
+		'L': CurrencyWML,
+		'V': CurrencyWMV,
+		'T': CurrencyWMT,
+		'Y': CurrencyWMY,
+	}
+)
+
+// Currency is the WebMoney title unit, e.g. WMZ
+type Currency string
+
+// Purse is the WebMoney purse identifier: the currency letter followed by 12 digits, e.g. Z123456789012
+type Purse string
+
+// ParsePurse parses and validates the WebMoney purse identifier
+func ParsePurse(val string) (Purse, error) {
+	purse := Purse(val)
+
+	if err := purse.Validate(); err != nil {
+		return "", err
+	}
+
+	return purse, nil
+}
+
+// Validate checks the purse has the known currency letter and the 12 digits body
+func (m Purse) Validate() error {
+	if err := m.validateFormat(); err != nil {
+		return err
+	}
+
+	if _, ok := currencies[m[0]]; !ok {
+		return ErrorPurseCurrencyUnknown
+	}
+
+	return nil
+}
+
+// Currency returns the purse currency or empty string if the purse is incorrect
+func (m Purse) Currency() Currency {
+	if m.Validate() != nil {
+		return ""
+	}
+
+	return currencies[m[0]]
+}
+
+func (m Purse) String() string {
+	return string(m)
+}
+
+// MarshalText checks the purse format only, the currency of the purses in requests is checked
+// by the client before sending
+func (m Purse) MarshalText() ([]byte, error) {
+	if m == "" {
+		return nil, nil
+	}
+
+	if err := m.validateFormat(); err != nil {
+		return nil, err
+	}
+
+	return []byte(m), nil
+}
+
+// UnmarshalText accepts the purse of any currency letter, so the responses with the purses
+// of currencies added by WebMoney later are decoded
+func (m *Purse) UnmarshalText(data []byte) error {
+	val := strings.TrimSpace(string(data))
+
+	if val == "" {
+		*m = ""
+		return nil
+	}
+
+	purse := Purse(val)
+
+	if err := purse.validateFormat(); err != nil {
+		return err
+	}
+
+	*m = purse
+	return nil
+}
+
+func (m Purse) validateFormat() error {
+	if !purseRegex.MatchString(string(m)) {
+		return ErrorPurseIsIncorrect
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package webmoney
+
+import (
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPurse_ParsePurse_Ok(t *testing.T) {
+	cases := map[string]Currency{
+		"Z123456789012": CurrencyWMZ,
+		"E123456789012": CurrencyWME,
+		"R123456789012": CurrencyWMR,
+		"U123456789012": CurrencyWMU,
+		"B123456789012": CurrencyWMB,
+		"X123456789012": CurrencyWMX,
+		"G123456789012": CurrencyWMG,
+		"K123456789012": CurrencyWMK,
+		"C123456789012": CurrencyWMC,
+		"D123456789012": CurrencyWMD,
+	}
+
+	for val, currency := range cases {
+		purse, err := ParsePurse(val)
+		assert.NoError(t, err, val)
+		assert.EqualValues(t, val, purse)
+		assert.Equal(t, currency, purse.Currency(), val)
+	}
+}
+
+func TestPurse_ParsePurse_Error(t *testing.T) {
+	cases := map[string]error{
+		"":               ErrorPurseIsIncorrect,
+		"Z12345678901":   ErrorPurseIsIncorrect,
+		"Z1234567890123": ErrorPurseIsIncorrect,
+		"z123456789012":  ErrorPurseIsIncorrect,
+		"ZZ23456789012":  ErrorPurseIsIncorrect,
+		"123456789012":   ErrorPurseIsIncorrect,
+		"Q123456789012":  ErrorPurseCurrencyUnknown,
+	}
+
+	for val, expected := range cases {
+		purse, err := ParsePurse(val)
+		assert.Equal(t, expected, err, val)
+		assert.Zero(t, purse, val)
+		assert.Zero(t, Purse(val).Currency(), val)
+	}
+}
+
+func TestPurse_Xml_Ok(t *testing.T) {
+	type request struct {
+		XMLName xml.Name `xml:"trans"`
+		Purse   Purse    `xml:"purse"`
+	}
+
+	b, err := xml.Marshal(&request{Purse: "Z123456789012"})
+	assert.NoError(t, err)
+	assert.Equal(t, `<trans><purse>Z123456789012</purse></trans>`, string(b))
+
+	_, err = xml.Marshal(&request{Purse: "Z12345"})
+	assert.Equal(t, ErrorPurseIsIncorrect, err)
+
+	out := new(request)
+	err = xml.Unmarshal([]byte(`<trans><purse> E123456789012 </purse></trans>`), out)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "E123456789012", out.Purse)
+	assert.Equal(t, CurrencyWME, out.Purse.Currency())
+
+	err = xml.Unmarshal([]byte(`<trans><purse>E12345</purse></trans>`), out)
+	assert.Equal(t, ErrorPurseIsIncorrect, err)
+
+	// The purses of unknown currencies are decoded from responses
+	err = xml.Unmarshal([]byte(`<trans><purse>Q123456789012</purse></trans>`), out)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Q123456789012", out.Purse)
+	assert.Zero(t, out.Purse.Currency())
+
+	b, err = xml.Marshal(out)
+	assert.NoError(t, err)
+	assert.Equal(t, `<trans><purse>Q123456789012</purse></trans>`, string(b))
+}
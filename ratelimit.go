@@ -0,0 +1,246 @@
+package webmoney
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// RateLimitWait makes the request to wait until the rate limit allows it or the context is done
+	RateLimitWait RateLimitMode = iota
+	// RateLimitFailFast makes the request to fail with ErrorRateLimitExceeded immediately
+	RateLimitFailFast
+)
+
+var (
+	ErrorRateLimitExceeded = errors.New("the WebMoney requests rate limit exceeded")
+)
+
+// RateLimitMode defines the behaviour of the request which exceeds the rate limit
+type RateLimitMode int8
+
+// TokenBucket is the token bucket rate limiter: the bucket holds up to burst tokens
+// and it is refilled with rate tokens per second, each request takes one token
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// RateLimiter limits the requests to WebMoney XML interfaces per WMID and per interface of WMID.
+// The single limiter can be shared by several clients working with the same WMID
+type RateLimiter struct {
+	mu              sync.Mutex
+	wmIdLimit       *rateLimit
+	interfaceLimits map[string]rateLimit
+	buckets         map[rateLimitKey]*TokenBucket
+}
+
+type rateLimit struct {
+	rate  float64
+	burst int
+}
+
+type rateLimitKey struct {
+	wmId  string
+	iface string
+}
+
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// Allow takes the token if it is available right now
+func (m *TokenBucket) Allow() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.refill()
+
+	if m.tokens < 1 {
+		return false
+	}
+
+	m.tokens--
+	return true
+}
+
+// Wait takes the token waiting until it is available. It fails with ErrorRateLimitExceeded
+// without waiting if the token will not be available before the context deadline
+func (m *TokenBucket) Wait(ctx context.Context) error {
+	delay, err := m.reserve(ctx)
+
+	if err != nil || delay <= 0 {
+		return err
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		m.cancel()
+		return ctx.Err()
+	}
+}
+
+func (m *TokenBucket) reserve(ctx context.Context) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.refill()
+	m.tokens--
+
+	if m.tokens >= 0 {
+		return 0, nil
+	}
+
+	if m.rate <= 0 {
+		m.tokens++
+		return 0, ErrorRateLimitExceeded
+	}
+
+	delay := time.Duration(math.Ceil(-m.tokens / m.rate * float64(time.Second)))
+
+	if deadline, ok := ctx.Deadline(); ok && m.last.Add(delay).After(deadline) {
+		m.tokens++
+		return 0, ErrorRateLimitExceeded
+	}
+
+	return delay, nil
+}
+
+// cancel returns the token taken by the request which will not be sent
+func (m *TokenBucket) cancel() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.refill()
+	m.tokens = math.Min(m.tokens+1, m.burst)
+}
+
+func (m *TokenBucket) refill() {
+	now := m.now()
+
+	if !m.last.IsZero() && now.After(m.last) {
+		m.tokens = math.Min(m.tokens+now.Sub(m.last).Seconds()*m.rate, m.burst)
+	}
+
+	m.last = now
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		interfaceLimits: make(map[string]rateLimit),
+		buckets:         make(map[rateLimitKey]*TokenBucket),
+	}
+}
+
+// SetWmIdLimit limits the requests of each WMID to all interfaces
+func (m *RateLimiter) SetWmIdLimit(rate float64, burst int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.wmIdLimit = &rateLimit{rate: rate, burst: burst}
+	m.reset()
+}
+
+// SetInterfaceLimit limits the requests of each WMID to the interface, e.g. X2
+func (m *RateLimiter) SetInterfaceLimit(iface string, rate float64, burst int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.interfaceLimits[iface] = rateLimit{rate: rate, burst: burst}
+	m.reset()
+}
+
+// Allow reports whether the request of WMID to the interface may be sent right now
+func (m *RateLimiter) Allow(wmId, iface string) bool {
+	var taken []*TokenBucket
+
+	for _, bucket := range m.getBuckets(wmId, iface) {
+		if !bucket.Allow() {
+			for _, b := range taken {
+				b.cancel()
+			}
+
+			return false
+		}
+
+		taken = append(taken, bucket)
+	}
+
+	return true
+}
+
+// Wait blocks until the request of WMID to the interface may be sent or the context is done
+func (m *RateLimiter) Wait(ctx context.Context, wmId, iface string) error {
+	var taken []*TokenBucket
+
+	for _, bucket := range m.getBuckets(wmId, iface) {
+		if err := bucket.Wait(ctx); err != nil {
+			for _, b := range taken {
+				b.cancel()
+			}
+
+			return err
+		}
+
+		taken = append(taken, bucket)
+	}
+
+	return nil
+}
+
+func (m *RateLimiter) getBuckets(wmId, iface string) []*TokenBucket {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var buckets []*TokenBucket
+
+	if m.wmIdLimit != nil {
+		buckets = append(buckets, m.getBucket(rateLimitKey{wmId: wmId}, *m.wmIdLimit))
+	}
+
+	if limit, ok := m.interfaceLimits[iface]; ok {
+		buckets = append(buckets, m.getBucket(rateLimitKey{wmId: wmId, iface: iface}, limit))
+	}
+
+	return buckets
+}
+
+func (m *RateLimiter) getBucket(key rateLimitKey, limit rateLimit) *TokenBucket {
+	bucket, ok := m.buckets[key]
+
+	if !ok {
+		bucket = NewTokenBucket(limit.rate, limit.burst)
+		m.buckets[key] = bucket
+	}
+
+	return bucket
+}
+
+func (m *RateLimiter) reset() {
+	m.buckets = make(map[rateLimitKey]*TokenBucket)
+}
@@ -0,0 +1,200 @@
+package webmoney
+
+import (
+	"context"
+	"github.com/sidmal/webmoney/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type RateLimitTestSuite struct {
+	suite.Suite
+	now time.Time
+}
+
+func Test_RateLimit(t *testing.T) {
+	suite.Run(t, new(RateLimitTestSuite))
+}
+
+func (suite *RateLimitTestSuite) SetupTest() {
+	suite.now = time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (suite *RateLimitTestSuite) newTokenBucket(rate float64, burst int) *TokenBucket {
+	bucket := NewTokenBucket(rate, burst)
+	bucket.now = func() time.Time {
+		return suite.now
+	}
+	return bucket
+}
+
+func (suite *RateLimitTestSuite) TestRateLimit_TokenBucket_Allow_Ok() {
+	bucket := suite.newTokenBucket(2, 3)
+
+	for i := 0; i < 3; i++ {
+		assert.True(suite.T(), bucket.Allow())
+	}
+
+	assert.False(suite.T(), bucket.Allow())
+
+	suite.now = suite.now.Add(500 * time.Millisecond)
+	assert.True(suite.T(), bucket.Allow())
+	assert.False(suite.T(), bucket.Allow())
+
+	suite.now = suite.now.Add(time.Hour)
+
+	for i := 0; i < 3; i++ {
+		assert.True(suite.T(), bucket.Allow())
+	}
+
+	assert.False(suite.T(), bucket.Allow())
+}
+
+func (suite *RateLimitTestSuite) TestRateLimit_TokenBucket_Reserve_Ok() {
+	bucket := suite.newTokenBucket(10, 1)
+
+	delay, err := bucket.reserve(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), delay)
+
+	delay, err = bucket.reserve(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 100*time.Millisecond, delay)
+
+	delay, err = bucket.reserve(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 200*time.Millisecond, delay)
+}
+
+func (suite *RateLimitTestSuite) TestRateLimit_TokenBucket_Reserve_Deadline_Error() {
+	suite.now = time.Now()
+	bucket := suite.newTokenBucket(1, 1)
+	assert.True(suite.T(), bucket.Allow())
+
+	ctx, cancel := context.WithDeadline(context.Background(), suite.now.Add(500*time.Millisecond))
+	defer cancel()
+
+	delay, err := bucket.reserve(ctx)
+	assert.Equal(suite.T(), ErrorRateLimitExceeded, err)
+	assert.Zero(suite.T(), delay)
+
+	suite.now = suite.now.Add(time.Second)
+	assert.True(suite.T(), bucket.Allow())
+}
+
+func (suite *RateLimitTestSuite) TestRateLimit_TokenBucket_Reserve_ZeroRate_Error() {
+	bucket := suite.newTokenBucket(0, 1)
+	assert.True(suite.T(), bucket.Allow())
+
+	_, err := bucket.reserve(context.Background())
+	assert.Equal(suite.T(), ErrorRateLimitExceeded, err)
+}
+
+func (suite *RateLimitTestSuite) TestRateLimit_TokenBucket_Wait_Ok() {
+	bucket := NewTokenBucket(50, 1)
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(suite.T(), bucket.Wait(context.Background()))
+	}
+
+	assert.True(suite.T(), time.Since(start) >= 35*time.Millisecond)
+}
+
+func (suite *RateLimitTestSuite) TestRateLimit_TokenBucket_Wait_ContextCanceled_Error() {
+	bucket := NewTokenBucket(0.1, 1)
+	assert.True(suite.T(), bucket.Allow())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	err := bucket.Wait(ctx)
+	assert.Equal(suite.T(), context.Canceled, err)
+	assert.True(suite.T(), bucket.tokens > -1)
+
+	err = bucket.Wait(ctx)
+	assert.Equal(suite.T(), context.Canceled, err)
+}
+
+func (suite *RateLimitTestSuite) TestRateLimit_RateLimiter_Ok() {
+	limiter := NewRateLimiter()
+	assert.True(suite.T(), limiter.Allow(TestWmId, InterfaceX2))
+
+	limiter.SetWmIdLimit(0, 3)
+	limiter.SetInterfaceLimit(InterfaceX2, 0, 1)
+
+	assert.True(suite.T(), limiter.Allow(TestWmId, InterfaceX2))
+	assert.False(suite.T(), limiter.Allow(TestWmId, InterfaceX2))
+	assert.True(suite.T(), limiter.Allow("123456789012", InterfaceX2))
+
+	// The WMID token is returned when the interface limit is exceeded
+	assert.True(suite.T(), limiter.Allow(TestWmId, InterfaceX9))
+	assert.True(suite.T(), limiter.Allow(TestWmId, InterfaceX3))
+	assert.False(suite.T(), limiter.Allow(TestWmId, InterfaceX3))
+	assert.NoError(suite.T(), limiter.Wait(context.Background(), "123456789012", InterfaceX9))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Equal(suite.T(), ErrorRateLimitExceeded, limiter.Wait(ctx, TestWmId, InterfaceX9))
+}
+
+func (suite *RateLimitTestSuite) newWebMoney(opts ...Option) *WebMoney {
+	opts = append(
+		[]Option{
+			WmId(TestWmId),
+			Key(TestKey),
+			Password(TestPassword),
+			httpClient(mocks.NewTransportStatusOk()),
+		},
+		opts...,
+	)
+	wm, err := NewWebMoney(opts...)
+
+	if err != nil {
+		suite.FailNow("WebMoney handler initialization failed", "%v", err)
+	}
+
+	return wm.(*WebMoney)
+}
+
+func (suite *RateLimitTestSuite) TestRateLimit_WebMoney_FailFast_Error() {
+	wm := suite.newWebMoney(InterfaceRateLimit(InterfaceX9, 0.01, 1), OnRateLimit(RateLimitFailFast))
+
+	_, err := wm.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+
+	_, err = wm.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.Equal(suite.T(), ErrorRateLimitExceeded, err)
+
+	_, err = wm.GetTransactionsHistory(&GetTransactionsHistoryRequest{Purse: "Z123456789012"})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RateLimitTestSuite) TestRateLimit_WebMoney_Wait_ContextDeadline_Error() {
+	wm := suite.newWebMoney(WmIdRateLimit(0.01, 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := wm.getBalance(ctx, &GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+
+	_, err = wm.getBalance(ctx, &GetBalanceRequest{Wmid: TestWmId})
+	assert.Equal(suite.T(), ErrorRateLimitExceeded, err)
+}
+
+func (suite *RateLimitTestSuite) TestRateLimit_WebMoney_SharedLimiter_Ok() {
+	limiter := NewRateLimiter()
+	limiter.SetWmIdLimit(0.01, 1)
+
+	first := suite.newWebMoney(Limiter(limiter), OnRateLimit(RateLimitFailFast))
+	second := suite.newWebMoney(Limiter(limiter), OnRateLimit(RateLimitFailFast))
+
+	_, err := first.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+
+	_, err = second.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.Equal(suite.T(), ErrorRateLimitExceeded, err)
+}
@@ -0,0 +1,215 @@
+// Package reconcile compares the internal ledger with the transactions history of WebMoney purses
+package reconcile
+
+import (
+	"context"
+	"github.com/sidmal/webmoney"
+	"sort"
+	"time"
+)
+
+const (
+	// KindMatched is the ledger record matching WebMoney operation
+	KindMatched Kind = "matched"
+	// KindMissingInLedger is WebMoney operation without the ledger record
+	KindMissingInLedger Kind = "missing_in_ledger"
+	// KindMissingInWebMoney is the ledger record without WebMoney operation
+	KindMissingInWebMoney Kind = "missing_in_webmoney"
+	// KindAmountMismatch is the ledger record with the amount different from WebMoney operation
+	KindAmountMismatch Kind = "amount_mismatch"
+	// KindCommissionMismatch is the ledger record with the commission different from WebMoney operation
+	KindCommissionMismatch Kind = "commission_mismatch"
+	// KindDuplicate is the ledger record or WebMoney operation with the key of the previous one
+	KindDuplicate Kind = "duplicate"
+
+	// DirectionOutgoing is the operation from the purse of the ledger, its tranid is assigned by the ledger
+	DirectionOutgoing Direction = "out"
+	// DirectionIncoming is the operation to the purse of the ledger, its tranid is assigned by the sender
+	DirectionIncoming Direction = "in"
+)
+
+// Kind is the result of the comparison of the ledger record and WebMoney operation
+type Kind string
+
+// Direction is the direction of the operation relative to the purse of the ledger
+type Direction string
+
+// Record is the operation of the internal ledger
+type Record struct {
+	// The purse of the ledger, the outgoing operations are matched by the purse and tranid
+	Purse webmoney.Purse
+	// The direction of the operation, DirectionOutgoing if it is not set
+	Direction Direction
+	// The purse of the sender of the incoming operation, the incoming operations are matched by it too
+	// because the tranids are assigned by the senders
+	Counterparty webmoney.Purse
+	TxnId        int64
+	Amount       webmoney.Amount
+	// The commission charged by WebMoney, it is not compared if it is nil
+	Commission *webmoney.Amount
+	Date       time.Time
+}
+
+// Operation is WebMoney operation of the purse
+type Operation struct {
+	Purse webmoney.Purse
+	*webmoney.TransferMoneyResponse
+}
+
+type key struct {
+	purse        webmoney.Purse
+	direction    Direction
+	counterparty webmoney.Purse
+	txnId        int64
+}
+
+// Reconcile compares the ledger records dated in the period with WebMoney operations of the purses in the period.
+// The purses of the records are requested if the purses are not set
+func Reconcile(
+	ctx context.Context,
+	client webmoney.XMLInterface,
+	records []*Record,
+	from, to time.Time,
+	purses ...webmoney.Purse,
+) (*Report, error) {
+	var inPeriod []*Record
+
+	for _, record := range records {
+		if !record.Date.Before(from) && !record.Date.After(to) {
+			inPeriod = append(inPeriod, record)
+		}
+	}
+
+	if len(purses) == 0 {
+		seen := make(map[webmoney.Purse]struct{})
+
+		for _, record := range inPeriod {
+			if _, ok := seen[record.Purse]; !ok {
+				seen[record.Purse] = struct{}{}
+				purses = append(purses, record.Purse)
+			}
+		}
+	}
+
+	operations, err := Fetch(ctx, client, from, to, purses...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Compare(inPeriod, operations), nil
+}
+
+// Fetch returns WebMoney operations of the purses in the period
+func Fetch(
+	ctx context.Context,
+	client webmoney.XMLInterface,
+	from, to time.Time,
+	purses ...webmoney.Purse,
+) ([]*Operation, error) {
+	var operations []*Operation
+
+	for _, purse := range purses {
+		cursor := webmoney.IterateTransactions(ctx, client, purse, from, to)
+
+		for cursor.Next() {
+			operations = append(operations, &Operation{Purse: purse, TransferMoneyResponse: cursor.Operation()})
+		}
+
+		if err := cursor.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return operations, nil
+}
+
+// Compare matches the ledger records with WebMoney operations by the purse, direction and tranid, the incoming
+// operations are matched by the sender purse too. The record is matched with the first operation of its key,
+// the next records and operations of the key are duplicates
+func Compare(records []*Record, operations []*Operation) *Report {
+	report := &Report{}
+	byKey := make(map[key][]*Operation, len(operations))
+	seen := make(map[key]struct{}, len(records))
+	matched := make(map[key]struct{}, len(records))
+
+	for _, operation := range operations {
+		k := operation.key()
+		byKey[k] = append(byKey[k], operation)
+	}
+
+	for _, record := range records {
+		k := record.key()
+
+		if _, ok := seen[k]; ok {
+			report.add(KindDuplicate, k, record, nil)
+			continue
+		}
+
+		seen[k] = struct{}{}
+		keyOperations, ok := byKey[k]
+
+		if !ok {
+			report.add(KindMissingInWebMoney, k, record, nil)
+			continue
+		}
+
+		operation := keyOperations[0]
+		byKey[k] = keyOperations[1:]
+		matched[k] = struct{}{}
+
+		switch {
+		case record.Amount != operation.Amount:
+			report.add(KindAmountMismatch, k, record, operation)
+		case record.Commission != nil && *record.Commission != operation.Commission:
+			report.add(KindCommissionMismatch, k, record, operation)
+		default:
+			report.add(KindMatched, k, record, operation)
+		}
+	}
+
+	for k, keyOperations := range byKey {
+		for i, operation := range keyOperations {
+			if _, ok := matched[k]; !ok && i == 0 {
+				report.add(KindMissingInLedger, k, nil, operation)
+				continue
+			}
+
+			report.add(KindDuplicate, k, nil, operation)
+		}
+	}
+
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		a, b := report.Entries[i], report.Entries[j]
+
+		switch {
+		case a.Purse != b.Purse:
+			return a.Purse < b.Purse
+		case a.Direction != b.Direction:
+			return a.Direction > b.Direction
+		case a.Counterparty != b.Counterparty:
+			return a.Counterparty < b.Counterparty
+		}
+
+		return a.TxnId < b.TxnId
+	})
+
+	return report
+}
+
+func (m *Record) key() key {
+	if m.Direction == DirectionIncoming {
+		return key{purse: m.Purse, direction: DirectionIncoming, counterparty: m.Counterparty, txnId: m.TxnId}
+	}
+
+	return key{purse: m.Purse, direction: DirectionOutgoing, txnId: m.TxnId}
+}
+
+// key returns the key of the operation, the operation is outgoing if it is sent from the purse
+func (m *Operation) key() key {
+	if m.PurseSrc == m.Purse {
+		return key{purse: m.Purse, direction: DirectionOutgoing, txnId: m.TxnId}
+	}
+
+	return key{purse: m.Purse, direction: DirectionIncoming, counterparty: m.PurseSrc, txnId: m.TxnId}
+}
@@ -0,0 +1,255 @@
+package reconcile
+
+import (
+	"context"
+	"github.com/sidmal/webmoney"
+	"github.com/sidmal/webmoney/signer"
+	"github.com/sidmal/webmoney/wmtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+const (
+	TestWmId      = "405002833238"
+	TestPassword  = "FvGqPdAy8reVWw789"
+	TestPurse     = webmoney.Purse("Z405002833238")
+	TestOtherWmId = "123456789012"
+)
+
+func amount(val webmoney.Amount) *webmoney.Amount {
+	return &val
+}
+
+func operation(purse webmoney.Purse, txnId int64, amount, commission webmoney.Amount) *Operation {
+	rsp := &webmoney.TransferMoneyResponse{
+		Id:         "op-" + purse.String(),
+		TxnId:      txnId,
+		PurseSrc:   purse,
+		Amount:     amount,
+		Commission: commission,
+	}
+
+	return &Operation{Purse: purse, TransferMoneyResponse: rsp}
+}
+
+// incoming returns the operation to the purse from the sender purse with the tranid of the sender
+func incoming(purse, purseSrc webmoney.Purse, txnId int64, amount webmoney.Amount) *Operation {
+	rsp := &webmoney.TransferMoneyResponse{
+		Id:        "in-" + purseSrc.String(),
+		TxnId:     txnId,
+		PurseSrc:  purseSrc,
+		PurseDest: purse,
+		Amount:    amount,
+	}
+
+	return &Operation{Purse: purse, TransferMoneyResponse: rsp}
+}
+
+type ReconcileTestSuite struct {
+	suite.Suite
+	server *wmtest.Server
+	client webmoney.XMLInterface
+}
+
+func Test_Reconcile(t *testing.T) {
+	suite.Run(t, new(ReconcileTestSuite))
+}
+
+func (suite *ReconcileTestSuite) SetupTest() {
+	key, _, err := signer.GenerateKey(nil, TestWmId, TestPassword)
+
+	if err != nil {
+		suite.FailNow("Key generation failed", "%v", err)
+	}
+
+	suite.server = wmtest.NewServer(wmtest.CommissionRate(0.008))
+	suite.server.AddPurse(TestWmId, TestPurse, 100000)
+	suite.server.AddPurse(TestOtherWmId, "Z123456789012", 0)
+
+	opts := []webmoney.Option{
+		webmoney.WmId(TestWmId),
+		webmoney.KeyBytes(key),
+		webmoney.Password(TestPassword),
+		webmoney.Endpoint(suite.server.URL),
+	}
+	suite.client, err = webmoney.NewWebMoney(opts...)
+
+	if err != nil {
+		suite.FailNow("WebMoney client initialization failed", "%v", err)
+	}
+}
+
+func (suite *ReconcileTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *ReconcileTestSuite) TestReconcile_Compare_Ok() {
+	records := []*Record{
+		{Purse: "Z000000000001", TxnId: 1, Amount: 100, Commission: amount(1)},
+		{Purse: "Z000000000001", TxnId: 2, Amount: 200},
+		{Purse: "Z000000000001", TxnId: 3, Amount: 300, Commission: amount(2)},
+		{Purse: "Z000000000001", TxnId: 4, Amount: 400},
+		{Purse: "Z000000000002", TxnId: 1, Amount: 500},
+	}
+	operations := []*Operation{
+		operation("Z000000000001", 1, 100, 1),
+		operation("Z000000000001", 2, 250, 2),
+		operation("Z000000000001", 3, 300, 3),
+		operation("Z000000000001", 5, 600, 5),
+		operation("Z000000000003", 1, 700, 6),
+	}
+
+	report := Compare(records, operations)
+	kinds := []Kind{
+		KindMatched,
+		KindAmountMismatch,
+		KindCommissionMismatch,
+		KindMissingInWebMoney,
+		KindMissingInLedger,
+		KindMissingInWebMoney,
+		KindMissingInLedger,
+	}
+	assert.Len(suite.T(), report.Entries, len(kinds))
+
+	for i, entry := range report.Entries {
+		assert.Equal(suite.T(), kinds[i], entry.Kind, i)
+	}
+
+	assert.Equal(suite.T(), 1, report.Count(KindMatched))
+	assert.Equal(suite.T(), 2, report.Count(KindMissingInLedger))
+	assert.Len(suite.T(), report.Discrepancies(), 6)
+	assert.Equal(suite.T(), webmoney.Purse("Z000000000003"), report.Entries[6].Purse)
+	assert.Nil(suite.T(), report.Entries[6].Record)
+	assert.Nil(suite.T(), report.Entries[3].Operation)
+}
+
+func (suite *ReconcileTestSuite) TestReconcile_Compare_Duplicate_Ok() {
+	records := []*Record{
+		{Purse: "Z000000000001", TxnId: 1, Amount: 100},
+		{Purse: "Z000000000001", TxnId: 2, Amount: 200},
+		{Purse: "Z000000000001", TxnId: 2, Amount: 200},
+	}
+	first := operation("Z000000000001", 1, 100, 1)
+	second := operation("Z000000000001", 1, 100, 1)
+	third := operation("Z000000000001", 3, 300, 3)
+	fourth := operation("Z000000000001", 3, 300, 3)
+	operations := []*Operation{first, second, third, fourth, operation("Z000000000001", 2, 200, 2)}
+
+	report := Compare(records, operations)
+	expected := []struct {
+		kind      Kind
+		txnId     int64
+		record    *Record
+		operation *Operation
+	}{
+		{kind: KindMatched, txnId: 1, record: records[0], operation: first},
+		{kind: KindDuplicate, txnId: 1, operation: second},
+		{kind: KindMatched, txnId: 2, record: records[1], operation: operations[4]},
+		{kind: KindDuplicate, txnId: 2, record: records[2]},
+		{kind: KindMissingInLedger, txnId: 3, operation: third},
+		{kind: KindDuplicate, txnId: 3, operation: fourth},
+	}
+	assert.Len(suite.T(), report.Entries, len(expected))
+
+	for i, entry := range report.Entries {
+		assert.Equal(suite.T(), expected[i].kind, entry.Kind, i)
+		assert.Equal(suite.T(), expected[i].txnId, entry.TxnId, i)
+		assert.Same(suite.T(), expected[i].record, entry.Record, i)
+		assert.Same(suite.T(), expected[i].operation, entry.Operation, i)
+	}
+
+	assert.Equal(suite.T(), 3, report.Count(KindDuplicate))
+}
+
+func (suite *ReconcileTestSuite) TestReconcile_Compare_Incoming_Ok() {
+	records := []*Record{
+		{Purse: "Z000000000001", TxnId: 1, Amount: 100},
+		{Purse: "Z000000000001", Direction: DirectionIncoming, Counterparty: "Z000000000002", TxnId: 1, Amount: 500},
+		{Purse: "Z000000000001", Direction: DirectionIncoming, Counterparty: "Z000000000003", TxnId: 1, Amount: 700},
+		{Purse: "Z000000000001", Direction: DirectionIncoming, Counterparty: "Z000000000004", TxnId: 1, Amount: 900},
+	}
+	out := operation("Z000000000001", 1, 100, 1)
+	first := incoming("Z000000000001", "Z000000000002", 1, 500)
+	second := incoming("Z000000000001", "Z000000000003", 1, 800)
+	// The incoming operation with the tranid of the outgoing record is not matched with it
+	third := incoming("Z000000000001", "Z000000000005", 1, 100)
+
+	report := Compare(records, []*Operation{third, second, first, out})
+	expected := []struct {
+		kind         Kind
+		direction    Direction
+		counterparty webmoney.Purse
+		record       *Record
+		operation    *Operation
+	}{
+		{kind: KindMatched, direction: DirectionOutgoing, record: records[0], operation: out},
+		{
+			kind:         KindMatched,
+			direction:    DirectionIncoming,
+			counterparty: "Z000000000002",
+			record:       records[1],
+			operation:    first,
+		},
+		{
+			kind:         KindAmountMismatch,
+			direction:    DirectionIncoming,
+			counterparty: "Z000000000003",
+			record:       records[2],
+			operation:    second,
+		},
+		{kind: KindMissingInWebMoney, direction: DirectionIncoming, counterparty: "Z000000000004", record: records[3]},
+		{kind: KindMissingInLedger, direction: DirectionIncoming, counterparty: "Z000000000005", operation: third},
+	}
+	assert.Len(suite.T(), report.Entries, len(expected))
+
+	for i, entry := range report.Entries {
+		assert.Equal(suite.T(), expected[i].kind, entry.Kind, i)
+		assert.Equal(suite.T(), expected[i].direction, entry.Direction, i)
+		assert.Equal(suite.T(), expected[i].counterparty, entry.Counterparty, i)
+		assert.Same(suite.T(), expected[i].record, entry.Record, i)
+		assert.Same(suite.T(), expected[i].operation, entry.Operation, i)
+	}
+
+	assert.Zero(suite.T(), report.Count(KindDuplicate))
+}
+
+func (suite *ReconcileTestSuite) TestReconcile_Reconcile_Ok() {
+	from := time.Now().Add(-time.Hour)
+
+	for i := 1; i <= 3; i++ {
+		in := &webmoney.TransferMoneyRequest{
+			TxnId:     i,
+			PurseSrc:  TestPurse,
+			PurseDest: "Z123456789012",
+			Amount:    webmoney.Amount(i * 1000),
+			Desc:      "Payout",
+		}
+		_, err := suite.client.TransferMoney(in)
+		assert.NoError(suite.T(), err)
+	}
+
+	records := []*Record{
+		{Purse: TestPurse, TxnId: 1, Amount: 1000, Commission: amount(8), Date: time.Now()},
+		{Purse: TestPurse, TxnId: 2, Amount: 2000, Commission: amount(10), Date: time.Now()},
+		{Purse: TestPurse, TxnId: 4, Amount: 4000, Date: time.Now()},
+		// The record out of the period is not compared
+		{Purse: TestPurse, TxnId: 5, Amount: 5000, Date: from.Add(-time.Hour)},
+	}
+
+	report, err := Reconcile(context.Background(), suite.client, records, from, time.Now().Add(time.Hour))
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), report.Entries, 4)
+	assert.Equal(suite.T(), KindMatched, report.Entries[0].Kind)
+	assert.Equal(suite.T(), KindCommissionMismatch, report.Entries[1].Kind)
+	assert.Equal(suite.T(), KindMissingInLedger, report.Entries[2].Kind)
+	assert.Equal(suite.T(), KindMissingInWebMoney, report.Entries[3].Kind)
+}
+
+func (suite *ReconcileTestSuite) TestReconcile_Reconcile_Error() {
+	records := []*Record{{Purse: "Z999999999999", TxnId: 1, Amount: 1000, Date: time.Now()}}
+
+	_, err := Reconcile(context.Background(), suite.client, records, time.Now().Add(-time.Hour), time.Now())
+	assert.Error(suite.T(), err)
+}
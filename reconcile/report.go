@@ -0,0 +1,156 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/sidmal/webmoney"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Report is the result of the reconciliation ordered by the purse, direction, sender purse and tranid
+type Report struct {
+	Entries []*Entry
+}
+
+// Entry is the comparison of the ledger record and WebMoney operation, one of them is nil if it is missing
+type Entry struct {
+	Kind      Kind
+	Purse     webmoney.Purse
+	Direction Direction
+	// The sender purse of the incoming operation
+	Counterparty webmoney.Purse
+	TxnId        int64
+	Record       *Record
+	Operation    *Operation
+}
+
+type entryView struct {
+	Kind               Kind   `json:"kind"`
+	Purse              string `json:"purse"`
+	Direction          string `json:"direction"`
+	Counterparty       string `json:"counterparty,omitempty"`
+	TxnId              int64  `json:"tranid"`
+	OperationId        string `json:"operation_id,omitempty"`
+	LedgerAmount       string `json:"ledger_amount,omitempty"`
+	WebMoneyAmount     string `json:"webmoney_amount,omitempty"`
+	LedgerCommission   string `json:"ledger_commission,omitempty"`
+	WebMoneyCommission string `json:"webmoney_commission,omitempty"`
+	LedgerDate         string `json:"ledger_date,omitempty"`
+	WebMoneyDate       string `json:"webmoney_date,omitempty"`
+}
+
+func (m *Report) add(kind Kind, k key, record *Record, operation *Operation) {
+	entry := &Entry{
+		Kind:         kind,
+		Purse:        k.purse,
+		Direction:    k.direction,
+		Counterparty: k.counterparty,
+		TxnId:        k.txnId,
+		Record:       record,
+		Operation:    operation,
+	}
+	m.Entries = append(m.Entries, entry)
+}
+
+// Count returns the number of entries of the kind
+func (m *Report) Count(kind Kind) int {
+	count := 0
+
+	for _, entry := range m.Entries {
+		if entry.Kind == kind {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Discrepancies returns the entries other than matched
+func (m *Report) Discrepancies() []*Entry {
+	var entries []*Entry
+
+	for _, entry := range m.Entries {
+		if entry.Kind != KindMatched {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// WriteCSV writes the entries as CSV with the header
+func (m *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"kind", "purse", "direction", "counterparty", "tranid", "operation_id", "ledger_amount", "webmoney_amount", "ledger_commission",
+		"webmoney_commission", "ledger_date", "webmoney_date",
+	}
+	_ = writer.Write(header)
+
+	for _, entry := range m.Entries {
+		view := entry.view()
+		row := []string{
+			string(view.Kind),
+			view.Purse,
+			view.Direction,
+			view.Counterparty,
+			strconv.FormatInt(view.TxnId, 10),
+			view.OperationId,
+			view.LedgerAmount,
+			view.WebMoneyAmount,
+			view.LedgerCommission,
+			view.WebMoneyCommission,
+			view.LedgerDate,
+			view.WebMoneyDate,
+		}
+		_ = writer.Write(row)
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// WriteJSON writes the entries as JSON array
+func (m *Report) WriteJSON(w io.Writer) error {
+	views := make([]*entryView, 0, len(m.Entries))
+
+	for _, entry := range m.Entries {
+		views = append(views, entry.view())
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(views)
+}
+
+func (m *Entry) view() *entryView {
+	view := &entryView{
+		Kind:         m.Kind,
+		Purse:        m.Purse.String(),
+		Direction:    string(m.Direction),
+		Counterparty: m.Counterparty.String(),
+		TxnId:        m.TxnId,
+	}
+
+	if m.Record != nil {
+		view.LedgerAmount = m.Record.Amount.String()
+		view.LedgerDate = m.Record.Date.Format(time.RFC3339)
+
+		if m.Record.Commission != nil {
+			view.LedgerCommission = m.Record.Commission.String()
+		}
+	}
+
+	if m.Operation != nil {
+		view.OperationId = m.Operation.Id
+		view.WebMoneyAmount = m.Operation.Amount.String()
+		view.WebMoneyCommission = m.Operation.Commission.String()
+		view.WebMoneyDate = m.Operation.DateCrt.Format(time.RFC3339)
+	}
+
+	return view
+}
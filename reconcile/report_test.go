@@ -0,0 +1,60 @@
+package reconcile
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/sidmal/webmoney"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestReport() *Report {
+	date := time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC)
+	op := operation("Z000000000001", 2, 250, 2)
+	op.DateCrt = webmoney.NewWMTime(date)
+
+	records := []*Record{
+		{Purse: "Z000000000001", TxnId: 1, Amount: 100, Commission: amount(1), Date: date},
+		{Purse: "Z000000000001", TxnId: 2, Amount: 200, Date: date},
+	}
+
+	return Compare(records, []*Operation{op})
+}
+
+func TestReconcile_Report_WriteCSV_Ok(t *testing.T) {
+	out := new(bytes.Buffer)
+	assert.NoError(t, newTestReport().WriteCSV(out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(
+		t,
+		"kind,purse,direction,counterparty,tranid,operation_id,ledger_amount,webmoney_amount,ledger_commission,"+
+			"webmoney_commission,ledger_date,webmoney_date",
+		lines[0],
+	)
+	assert.Equal(t, "missing_in_webmoney,Z000000000001,out,,1,,1,,0.01,,2020-09-01T12:00:00Z,", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "amount_mismatch,Z000000000001,out,,2,op-Z000000000001,2,2.5,,0.02,"))
+}
+
+func TestReconcile_Report_WriteJSON_Ok(t *testing.T) {
+	out := new(bytes.Buffer)
+	assert.NoError(t, newTestReport().WriteJSON(out))
+
+	var entries []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &entries))
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "missing_in_webmoney", entries[0]["kind"])
+	assert.EqualValues(t, 1, entries[0]["tranid"])
+	assert.Equal(t, "out", entries[0]["direction"])
+	assert.NotContains(t, entries[0], "counterparty")
+	assert.NotContains(t, entries[0], "webmoney_amount")
+	assert.Equal(t, "2.5", entries[1]["webmoney_amount"])
+	assert.Equal(t, "op-Z000000000001", entries[1]["operation_id"])
+
+	out.Reset()
+	assert.NoError(t, (&Report{}).WriteJSON(out))
+	assert.Equal(t, "[]\n", out.String())
+}
@@ -0,0 +1,123 @@
+package webmoney
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SignatureFieldRequestNumber is the name of the request number field in the signature specification
+	SignatureFieldRequestNumber = "reqn"
+)
+
+var (
+	ErrorSignatureSpecUnknown  = errors.New("the signature specification of the interface is unknown")
+	ErrorSignatureFieldUnknown = errors.New("the signed field is not found in the request")
+
+	// The signature strings of interfaces as described in WebMoney XML interfaces documentation
+	signatureSpecs = map[string]*SignatureSpec{
+		InterfaceX2: {
+			Interface: InterfaceX2,
+			Fields: []string{
+				SignatureFieldRequestNumber, "tranid", "pursesrc", "pursedest", "amount", "period", "pcode", "desc",
+				"wminvid",
+			},
+		},
+		InterfaceX3: {Interface: InterfaceX3, Fields: []string{"purse", SignatureFieldRequestNumber}},
+		InterfaceX9: {Interface: InterfaceX9, Fields: []string{"wmid", SignatureFieldRequestNumber}},
+	}
+)
+
+// SignatureSpec declares the string signed for the request of WebMoney XML interface as the sequence of
+// request XML elements. The values are formatted as they are sent in the request, so the text fields
+// have to be converted to windows-1251 before signing
+type SignatureSpec struct {
+	// The WebMoney XML interface name, e.g. X2
+	Interface string
+	// The XML element names of the request fields concatenated to the signature string,
+	// SignatureFieldRequestNumber for the request number
+	Fields []string
+}
+
+// GetSignatureSpec returns the signature specification of the WebMoney XML interface
+func GetSignatureSpec(iface string) (*SignatureSpec, error) {
+	spec, ok := signatureSpecs[iface]
+
+	if !ok {
+		return nil, ErrorSignatureSpecUnknown
+	}
+
+	return spec, nil
+}
+
+// String returns the signature string of the request with the request number
+func (m *SignatureSpec) String(reqn string, req interface{}) (string, error) {
+	val := reflect.Indirect(reflect.ValueOf(req))
+
+	if val.Kind() != reflect.Struct {
+		return "", fmt.Errorf("%w: the request of %s must be a struct", ErrorSignatureFieldUnknown, m.Interface)
+	}
+
+	var out strings.Builder
+
+	for _, name := range m.Fields {
+		if name == SignatureFieldRequestNumber {
+			out.WriteString(reqn)
+			continue
+		}
+
+		field, ok := signatureField(val, name)
+
+		if !ok {
+			return "", fmt.Errorf("%w: %s field %s", ErrorSignatureFieldUnknown, m.Interface, name)
+		}
+
+		str, err := signatureValue(field)
+
+		if err != nil {
+			return "", err
+		}
+
+		out.WriteString(str)
+	}
+
+	return out.String(), nil
+}
+
+// signatureField returns the struct field by the name of its XML element
+func signatureField(val reflect.Value, name string) (reflect.Value, bool) {
+	typ := val.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		tag := strings.Split(typ.Field(i).Tag.Get("xml"), ",")[0]
+
+		if tag == name {
+			return val.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// signatureValue formats the field value the same way as XML encoder does
+func signatureValue(val reflect.Value) (string, error) {
+	if marshaler, ok := val.Interface().(encoding.TextMarshaler); ok {
+		b, err := marshaler.MarshalText()
+		return string(b), err
+	}
+
+	switch val.Kind() {
+	case reflect.String:
+		return val.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(val.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(val.Uint(), 10), nil
+	}
+
+	return "", fmt.Errorf("%w: the field of type %s can not be signed", ErrorSignatureFieldUnknown, val.Type())
+}
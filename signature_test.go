@@ -0,0 +1,213 @@
+package webmoney
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/sidmal/webmoney/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/text/encoding/charmap"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// The signature strings of WebMoney XML interfaces as they are written in the description of the sign element
+// of the request in WebMoney Transfer wiki: "Interface X2", "Interface X3" and "Interface X9" pages
+var signatureFormulas = map[string]string{
+	InterfaceX2: "reqn+tranid+pursesrc+pursedest+amount+period+pcode+desc+wminvid",
+	InterfaceX3: "purse+reqn",
+	InterfaceX9: "wmid+reqn",
+}
+
+// signatureTransport keeps the bodies of the requests sent to WebMoney
+type signatureTransport struct {
+	mocks.TransportStatusOk
+	bodies [][]byte
+}
+
+type signatureRequestField struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type signatureRequest struct {
+	XMLName       xml.Name `xml:"w3s.request"`
+	RequestNumber string   `xml:"reqn"`
+	Request       struct {
+		Fields []signatureRequestField `xml:",any"`
+	} `xml:",any"`
+}
+
+func (m *signatureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.bodies = append(m.bodies, body)
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return m.TransportStatusOk.RoundTrip(req)
+}
+
+// formulaString returns the signature string of the documentation formula from the values of the sent request
+func formulaString(formula string, body []byte) (string, error) {
+	in := new(signatureRequest)
+
+	if err := xml.Unmarshal(body, in); err != nil {
+		return "", err
+	}
+
+	values := map[string]string{SignatureFieldRequestNumber: in.RequestNumber}
+
+	for _, field := range in.Request.Fields {
+		values[field.XMLName.Local] = field.Value
+	}
+
+	var out strings.Builder
+
+	for _, name := range strings.Split(formula, "+") {
+		value, ok := values[name]
+
+		if !ok {
+			return "", fmt.Errorf("the field %s is not sent", name)
+		}
+
+		out.WriteString(value)
+	}
+
+	return out.String(), nil
+}
+
+type SignatureTestSuite struct {
+	suite.Suite
+}
+
+func Test_Signature(t *testing.T) {
+	suite.Run(t, new(SignatureTestSuite))
+}
+
+// The signed strings are checked against the documentation formulas applied to the values sent in the request,
+// so the order of the fields and the formatting of the values do not come from the signature specs
+func (suite *SignatureTestSuite) TestSignature_Vectors_Ok() {
+	calls := map[string]func(wm XMLInterface) error{
+		InterfaceX2: func(wm XMLInterface) error {
+			in := &TransferMoneyRequest{
+				TxnId:     1234567890,
+				PurseSrc:  "Z123456789012",
+				PurseDest: "Z098765432109",
+				Amount:    1050,
+				Period:    3,
+				PCode:     "code",
+				Desc:      "Test payment",
+				WmInvId:   12345,
+			}
+			_, err := wm.TransferMoney(in)
+			return err
+		},
+		InterfaceX3: func(wm XMLInterface) error {
+			in := &GetTransactionsHistoryRequest{Purse: "Z123456789012", TxnId: 1234567890}
+			_, err := wm.GetTransactionsHistory(in)
+			return err
+		},
+		InterfaceX9: func(wm XMLInterface) error {
+			_, err := wm.GetBalance(&GetBalanceRequest{Wmid: "123456789012"})
+			return err
+		},
+	}
+
+	assert.Len(suite.T(), calls, len(signatureFormulas))
+
+	for iface, call := range calls {
+		mockSigner := &mocks.WebMoneySignerInterface{}
+		mockSigner.On("Sign", mock.Anything).Return("signature", nil)
+		transport := &signatureTransport{}
+
+		wm, err := NewWebMoney(WmId(TestWmId), Signer(mockSigner), httpClient(&http.Client{Transport: transport}))
+		assert.NoError(suite.T(), err)
+		assert.NoError(suite.T(), call(wm), iface)
+		assert.Len(suite.T(), transport.bodies, 1, iface)
+
+		expected, err := formulaString(signatureFormulas[iface], transport.bodies[0])
+		assert.NoError(suite.T(), err, iface)
+		assert.Equal(suite.T(), expected, mockSigner.Calls[0].Arguments.String(0), iface)
+	}
+}
+
+func (suite *SignatureTestSuite) TestSignature_SpecsMatchRequests_Ok() {
+	requests := map[string]interface{}{
+		InterfaceX2: TransferMoneyRequest{},
+		InterfaceX3: GetTransactionsHistoryRequest{},
+		InterfaceX9: GetBalanceRequest{},
+	}
+
+	assert.Len(suite.T(), signatureSpecs, len(requests))
+
+	for iface, req := range requests {
+		spec, err := GetSignatureSpec(iface)
+		assert.NoError(suite.T(), err)
+
+		_, err = spec.String("1", req)
+		assert.NoError(suite.T(), err, iface)
+	}
+}
+
+func (suite *SignatureTestSuite) TestSignature_GetSignatureSpec_Error() {
+	spec, err := GetSignatureSpec("X0")
+	assert.Equal(suite.T(), ErrorSignatureSpecUnknown, err)
+	assert.Nil(suite.T(), spec)
+}
+
+func (suite *SignatureTestSuite) TestSignature_String_Error() {
+	spec := &SignatureSpec{Interface: "X0", Fields: []string{"unknown"}}
+
+	_, err := spec.String("1", &GetBalanceRequest{})
+	assert.True(suite.T(), errors.Is(err, ErrorSignatureFieldUnknown))
+	assert.EqualError(suite.T(), err, "the signed field is not found in the request: X0 field unknown")
+
+	_, err = spec.String("1", "request")
+	assert.True(suite.T(), errors.Is(err, ErrorSignatureFieldUnknown))
+
+	spec = &SignatureSpec{Interface: "X0", Fields: []string{"list"}}
+	_, err = spec.String("1", struct {
+		List []string `xml:"list"`
+	}{})
+	assert.True(suite.T(), errors.Is(err, ErrorSignatureFieldUnknown))
+
+	spec = &SignatureSpec{Interface: "X0", Fields: []string{"reqn", "id"}}
+	str, err := spec.String("1", struct {
+		Id uint `xml:"id,attr"`
+	}{Id: 2})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "12", str)
+}
+
+func (suite *SignatureTestSuite) TestSignature_TransferMoney_SignsWin1251Desc_Ok() {
+	mockSigner := &mocks.WebMoneySignerInterface{}
+	mockSigner.On("Sign", mock.Anything).Return("signature", nil)
+
+	wm, err := NewWebMoney(WmId(TestWmId), Signer(mockSigner), httpClient(mocks.NewTransportStatusOk()))
+	assert.NoError(suite.T(), err)
+
+	in := &TransferMoneyRequest{
+		TxnId:     1,
+		PurseSrc:  "Z123456789012",
+		PurseDest: "Z098765432109",
+		Amount:    1000,
+		Desc:      "Тестовая операция",
+	}
+	_, err = wm.TransferMoney(in)
+	assert.NoError(suite.T(), err)
+
+	desc, err := charmap.Windows1251.NewEncoder().String("Тестовая операция")
+	assert.NoError(suite.T(), err)
+
+	signed := mockSigner.Calls[0].Arguments.String(0)
+	assert.True(suite.T(), strings.HasSuffix(signed, "1Z123456789012Z098765432109100"+desc+"0"), signed)
+}
@@ -0,0 +1,153 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"math/big"
+)
+
+const (
+	keyReserved       = 0x81
+	keyNumberLength   = 66
+	keyPrimeBits      = keyNumberLength * 8 / 2
+	keyPublicExponent = 65537
+)
+
+// PublicKey is the public part of the generated key to verify the signatures
+type PublicKey struct {
+	Exponent *big.Int
+	Modulus  *big.Int
+}
+
+// GenerateKey generates the new key pair of the same size as WebMoney Keeper keys. It returns the content
+// of *.kwm file encrypted with WMID and password and the public key. The random is crypto/rand.Reader if nil
+func GenerateKey(random io.Reader, wmId, password string) ([]byte, *PublicKey, error) {
+	if !WmIdRegex.MatchString(wmId) {
+		return nil, nil, ErrorWmIdIsIncorrect
+	}
+
+	if password == "" {
+		return nil, nil, ErrorPasswordNotConfigured
+	}
+
+	if random == nil {
+		random = rand.Reader
+	}
+
+	exponent := big.NewInt(keyPublicExponent)
+	one := big.NewInt(1)
+
+	for {
+		p, err := rand.Prime(random, keyPrimeBits)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		q, err := rand.Prime(random, keyPrimeBits)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if p.Cmp(q) == 0 {
+			continue
+		}
+
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		power := new(big.Int).ModInverse(exponent, phi)
+
+		if power == nil {
+			continue
+		}
+
+		modulus := new(big.Int).Mul(p, q)
+		key, err := newGeneratedKeyContainer(power, modulus).seal(wmId, password)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return key, &PublicKey{Exponent: exponent, Modulus: modulus}, nil
+	}
+}
+
+// Verifier returns the verifier of the signatures made with the key
+func (m *PublicKey) Verifier() (WebMoneyVerifierInterface, error) {
+	return NewVerifier(m.Exponent, m.Modulus)
+}
+
+// ReEncryptKey decrypts *.kwm key of WMID with the password and encrypts it with the new password.
+// The result is the content of *.kwm file which can be loaded with the new password
+func ReEncryptKey(key []byte, wmId, password, newPassword string) ([]byte, error) {
+	if !WmIdRegex.MatchString(wmId) {
+		return nil, ErrorWmIdIsIncorrect
+	}
+
+	if password == "" || newPassword == "" {
+		return nil, ErrorPasswordNotConfigured
+	}
+
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	container, err := newKeyContainer(key, wmId, password, newReader())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !container.Verify() {
+		return nil, verifyError(container)
+	}
+
+	return container.(*keyContainer).seal(wmId, newPassword)
+}
+
+// seal returns the content of *.kwm file for the decrypted key: the CRC is calculated
+// for the decrypted key and the key is encrypted with WMID and password
+func (m *keyContainer) seal(wmId, password string) ([]byte, error) {
+	crc, err := m.checksum()
+
+	if err != nil {
+		return nil, err
+	}
+
+	key := *m.key
+	key.SignFlag = keySignFlag
+	key.Crc = crc
+	key.Buffer = xor(key.Buffer, md4Hash([]byte(wmId+password)), 6)
+
+	buffer := new(bytes.Buffer)
+
+	if err := m.external.BinaryWrite(buffer, binary.LittleEndian, &key); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// newGeneratedKeyContainer returns the decrypted key container for the private exponent and the modulus
+func newGeneratedKeyContainer(power, modulus *big.Int) *keyContainer {
+	data := &keyData{
+		PowerBase:   keyNumberLength,
+		ModulusBase: keyNumberLength,
+	}
+	copy(data.Power[:], reverseBytes(power.FillBytes(make([]byte, keyNumberLength))))
+	copy(data.Modulus[:], reverseBytes(modulus.FillBytes(make([]byte, keyNumberLength))))
+
+	b := new(bytes.Buffer)
+	_ = binary.Write(b, binary.LittleEndian, data)
+
+	key := &keyContainerKey{
+		Reserved: keyReserved,
+		SignFlag: keySignFlag,
+		Length:   uint32(len(buffer{})),
+	}
+	copy(key.Buffer[:], b.Bytes())
+
+	return &keyContainer{key: key, external: newReader()}
+}
@@ -0,0 +1,108 @@
+package signer
+
+import (
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+)
+
+type KeyTestSuite struct {
+	suite.Suite
+	key []byte
+}
+
+func Test_Key(t *testing.T) {
+	suite.Run(t, new(KeyTestSuite))
+}
+
+func (suite *KeyTestSuite) SetupTest() {
+	key, err := base64.StdEncoding.DecodeString(TestKey)
+
+	if err != nil {
+		suite.FailNow("Key decoding failed", "%v", err)
+	}
+
+	suite.key = key
+}
+
+func (suite *KeyTestSuite) TestKey_ReEncryptKey_Ok() {
+	key, err := ReEncryptKey(suite.key, TestWmId, TestPassword, "NewPassword")
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), key, keyLength)
+	assert.NotEqual(suite.T(), suite.key, key)
+
+	signer, err := NewSigner(WmId(TestWmId), KeyBytes(key), Password("NewPassword"))
+	assert.NoError(suite.T(), err)
+
+	origin, err := NewSigner(WmId(TestWmId), KeyBytes(suite.key), Password(TestPassword))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), origin.(*Signer).power, signer.(*Signer).power)
+	assert.Equal(suite.T(), origin.(*Signer).modulus, signer.(*Signer).modulus)
+
+	_, err = NewSigner(WmId(TestWmId), KeyBytes(key), Password(TestPassword))
+	assert.Equal(suite.T(), ErrorKeyPasswordIncorrect, err)
+
+	key, err = ReEncryptKey(key, TestWmId, "NewPassword", TestPassword)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.key, key)
+}
+
+func (suite *KeyTestSuite) TestKey_ReEncryptKey_Error() {
+	_, err := ReEncryptKey(suite.key, "1234", TestPassword, "NewPassword")
+	assert.Equal(suite.T(), ErrorWmIdIsIncorrect, err)
+
+	_, err = ReEncryptKey(suite.key, TestWmId, TestPassword, "")
+	assert.Equal(suite.T(), ErrorPasswordNotConfigured, err)
+
+	_, err = ReEncryptKey(suite.key[1:], TestWmId, TestPassword, "NewPassword")
+	assert.Equal(suite.T(), ErrorKeyLengthIsIncorrect, err)
+
+	_, err = ReEncryptKey(suite.key, TestWmId, "password", "NewPassword")
+	assert.Equal(suite.T(), ErrorKeyPasswordIncorrect, err)
+
+	key := append([]byte{}, suite.key...)
+	key[100] ^= 0x01
+	_, err = ReEncryptKey(key, TestWmId, TestPassword, "NewPassword")
+	assert.Equal(suite.T(), ErrorKeyCrcMismatch, err)
+}
+
+func (suite *KeyTestSuite) TestKey_GenerateKey_Ok() {
+	key, publicKey, err := GenerateKey(nil, TestWmId, "password")
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), key, keyLength)
+	assert.Equal(suite.T(), keyNumberLength*8, publicKey.Modulus.BitLen())
+	assert.EqualValues(suite.T(), keyPublicExponent, publicKey.Exponent.Int64())
+
+	signer, err := NewSigner(WmId(TestWmId), KeyBytes(key), Password("password"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), publicKey.Modulus, signer.(*Signer).modulus)
+
+	verifier, err := publicKey.Verifier()
+	assert.NoError(suite.T(), err)
+
+	signature, err := signer.Sign("405002833238")
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), verifier.Verify("405002833238", signature))
+
+	_, err = NewSigner(WmId(TestWmId), KeyBytes(key), Password(TestPassword))
+	assert.Equal(suite.T(), ErrorKeyPasswordIncorrect, err)
+
+	key, err = ReEncryptKey(key, TestWmId, "password", TestPassword)
+	assert.NoError(suite.T(), err)
+
+	_, err = NewSigner(WmId(TestWmId), KeyBytes(key), Password(TestPassword))
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *KeyTestSuite) TestKey_GenerateKey_Error() {
+	_, _, err := GenerateKey(nil, "1234", "password")
+	assert.Equal(suite.T(), ErrorWmIdIsIncorrect, err)
+
+	_, _, err = GenerateKey(nil, TestWmId, "")
+	assert.Equal(suite.T(), ErrorPasswordNotConfigured, err)
+
+	_, _, err = GenerateKey(strings.NewReader("not enough random"), TestWmId, "password")
+	assert.Error(suite.T(), err)
+}
@@ -1,12 +1,24 @@
 package signer
 
+import "io"
+
 type Options struct {
 	// The WebMoney's WMID identifier
 	wmId string
 	// The WebMoney Pro *.kvm TestKey
 	key string
+	// The content of WebMoney Pro *.kwm key file
+	keyBytes []byte
+	// The path to WebMoney Pro *.kwm key file
+	keyFile string
+	// The reader of WebMoney Pro *.kwm key file
+	keyReader io.Reader
 	// The TestPassword to WebMoney Pro *.kvm TestKey
 	password string
+	// The provider of the key and the password, it replaces the key and the password options
+	provider KeyProvider
+	// The key is loaded from the provider for each signature and is not kept in memory
+	onDemand bool
 	// The handler to initialize WebMoney TestKey container
 	newKeyContainerFn func(key []byte, wmid, keyPassword string, reader ExternalInterface) (KeyContainerInterface, error)
 }
@@ -25,12 +37,46 @@ func Key(val string) Option {
 	}
 }
 
+// KeyBytes sets the key as the raw content of *.kwm file
+func KeyBytes(val []byte) Option {
+	return func(opts *Options) {
+		opts.keyBytes = val
+	}
+}
+
+// KeyFile sets the path to *.kwm key file
+func KeyFile(val string) Option {
+	return func(opts *Options) {
+		opts.keyFile = val
+	}
+}
+
+// KeyReader sets the reader of *.kwm key file content
+func KeyReader(val io.Reader) Option {
+	return func(opts *Options) {
+		opts.keyReader = val
+	}
+}
+
 func Password(val string) Option {
 	return func(opts *Options) {
 		opts.password = val
 	}
 }
 
+func Provider(val KeyProvider) Option {
+	return func(opts *Options) {
+		opts.provider = val
+	}
+}
+
+// OnDemand makes the signer to load and decode the key for each signature and wipe it after
+func OnDemand(val bool) Option {
+	return func(opts *Options) {
+		opts.onDemand = val
+	}
+}
+
 func NewKeyContainerFn(val func(key []byte, wmid, keyPassword string, reader ExternalInterface) (KeyContainerInterface, error)) Option {
 	return func(opts *Options) {
 		opts.newKeyContainerFn = val
@@ -2,14 +2,21 @@ package signer
 
 import (
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
 func TestSignerOptions_Setters(t *testing.T) {
+	reader := strings.NewReader("TestKey")
 	opts := []Option{
 		WmId("123456789012"),
 		Key("TestKey"),
+		KeyBytes([]byte("TestKeyBytes")),
+		KeyFile("/tmp/TestKey.kwm"),
+		KeyReader(reader),
 		Password("TestPassword"),
+		Provider(NewEnvKeyProvider("KEY", "PASSWORD")),
+		OnDemand(true),
 		NewKeyContainerFn(newKeyContainer),
 	}
 
@@ -21,6 +28,11 @@ func TestSignerOptions_Setters(t *testing.T) {
 
 	assert.Equal(t, "123456789012", options.wmId)
 	assert.Equal(t, "TestKey", options.key)
+	assert.Equal(t, []byte("TestKeyBytes"), options.keyBytes)
+	assert.Equal(t, "/tmp/TestKey.kwm", options.keyFile)
+	assert.Equal(t, reader, options.keyReader)
 	assert.Equal(t, "TestPassword", options.password)
+	assert.Equal(t, &EnvKeyProvider{keyEnv: "KEY", passwordEnv: "PASSWORD"}, options.provider)
+	assert.True(t, options.onDemand)
 	assert.NotNil(t, options.newKeyContainerFn)
 }
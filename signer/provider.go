@@ -0,0 +1,255 @@
+package signer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultVaultKeyField      = "key"
+	defaultVaultPasswordField = "password"
+	vaultTokenHeader          = "X-Vault-Token"
+)
+
+var (
+	ErrorVaultRequestFailed = errors.New("the request to Vault failed")
+)
+
+// KeyProvider returns the content of *.kwm key and the password to it. The signer calls it on creation
+// and for each signature if the key is loaded on demand. The returned key is wiped after decoding,
+// so the provider must return the new slice on each call
+type KeyProvider interface {
+	Key(ctx context.Context) ([]byte, string, error)
+}
+
+// EnvKeyProvider reads the base64 encoded key and the password from the environment variables
+type EnvKeyProvider struct {
+	keyEnv      string
+	passwordEnv string
+}
+
+// FileKeyProvider reads *.kwm key and the password from the files, e.g. mounted secrets
+type FileKeyProvider struct {
+	keyPath      string
+	passwordPath string
+}
+
+// VaultKeyProvider reads the base64 encoded key and the password from HashiCorp Vault KV secret
+type VaultKeyProvider struct {
+	// The Vault address, e.g. https://vault.example.com:8200
+	Address string
+	// The Vault token
+	Token string
+	// The secret path, e.g. secret/data/webmoney for KV version 2 or secret/webmoney for KV version 1
+	Path string
+	// The secret field of the base64 encoded key, "key" by default
+	KeyField string
+	// The secret field of the password, "password" by default
+	PasswordField string
+	// The HTTP client to send requests, http.DefaultClient with timeout is used by default
+	HttpClient *http.Client
+}
+
+type vaultSecret struct {
+	Data json.RawMessage `json:"data"`
+}
+
+type optionsKeyProvider struct {
+	options *Options
+}
+
+func NewEnvKeyProvider(keyEnv, passwordEnv string) KeyProvider {
+	return &EnvKeyProvider{keyEnv: keyEnv, passwordEnv: passwordEnv}
+}
+
+func NewFileKeyProvider(keyPath, passwordPath string) KeyProvider {
+	return &FileKeyProvider{keyPath: keyPath, passwordPath: passwordPath}
+}
+
+func NewVaultKeyProvider(address, token, path string) *VaultKeyProvider {
+	return &VaultKeyProvider{Address: address, Token: token, Path: path}
+}
+
+func (m *EnvKeyProvider) Key(_ context.Context) ([]byte, string, error) {
+	key := os.Getenv(m.keyEnv)
+
+	if key == "" {
+		return nil, "", ErrorKeyNotConfigured
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(key)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return decoded, os.Getenv(m.passwordEnv), nil
+}
+
+func (m *FileKeyProvider) Key(_ context.Context) ([]byte, string, error) {
+	key, err := ioutil.ReadFile(m.keyPath)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	password, err := ioutil.ReadFile(m.passwordPath)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key, strings.TrimRight(string(password), "\r\n"), nil
+}
+
+func (m *VaultKeyProvider) Key(ctx context.Context) ([]byte, string, error) {
+	url := strings.TrimRight(m.Address, "/") + "/v1/" + strings.TrimLeft(m.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	req.Header.Set(vaultTokenHeader, m.Token)
+	client := m.HttpClient
+
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	rsp, err := client.Do(req)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%w: status %d", ErrorVaultRequestFailed, rsp.StatusCode)
+	}
+
+	fields, err := m.fields(rsp.Body)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	keyField, passwordField := m.KeyField, m.PasswordField
+
+	if keyField == "" {
+		keyField = defaultVaultKeyField
+	}
+
+	if passwordField == "" {
+		passwordField = defaultVaultPasswordField
+	}
+
+	if fields[keyField] == "" {
+		return nil, "", ErrorKeyNotConfigured
+	}
+
+	key, err := base64.StdEncoding.DecodeString(fields[keyField])
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key, fields[passwordField], nil
+}
+
+// fields returns the fields of KV secret, the fields of version 2 secret are nested to the second data object
+func (m *VaultKeyProvider) fields(body io.Reader) (map[string]string, error) {
+	secret := new(vaultSecret)
+
+	if err := json.NewDecoder(body).Decode(secret); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorVaultRequestFailed, err)
+	}
+
+	nested := new(vaultSecret)
+
+	if err := json.Unmarshal(secret.Data, nested); err == nil && len(nested.Data) > 0 {
+		secret = nested
+	}
+
+	fields := make(map[string]interface{})
+
+	if err := json.Unmarshal(secret.Data, &fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorVaultRequestFailed, err)
+	}
+
+	result := make(map[string]string, len(fields))
+
+	for k, v := range fields {
+		if str, ok := v.(string); ok {
+			result[k] = str
+		}
+	}
+
+	return result, nil
+}
+
+// Key returns the key configured with the signer options: raw bytes, file, reader or base64 string
+func (m *optionsKeyProvider) Key(_ context.Context) ([]byte, string, error) {
+	var (
+		key []byte
+		err error
+	)
+
+	switch {
+	case m.options.keyBytes != nil:
+		key = append([]byte{}, m.options.keyBytes...)
+	case m.options.keyFile != "":
+		key, err = ioutil.ReadFile(m.options.keyFile)
+	case m.options.keyReader != nil:
+		key, err = m.readKey()
+	default:
+		key, err = base64.StdEncoding.DecodeString(m.options.key)
+	}
+
+	return key, m.options.password, err
+}
+
+// readKey reads the key from the reader, the reader is limited to detect the key longer
+// than expected without reading it all
+func (m *optionsKeyProvider) readKey() ([]byte, error) {
+	return ioutil.ReadAll(io.LimitReader(m.options.keyReader, keyLength+1))
+}
+
+// wipe overwrites the values of the decoded key
+func wipe(values ...*big.Int) {
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+
+		words := v.Bits()
+
+		for i := range words {
+			words[i] = 0
+		}
+
+		v.SetInt64(0)
+	}
+}
+
+// wipeBytes overwrites the key content
+func wipeBytes(values ...[]byte) {
+	for _, v := range values {
+		for i := range v {
+			v[i] = 0
+		}
+	}
+}
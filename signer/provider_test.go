@@ -0,0 +1,250 @@
+package signer
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+type countingKeyProvider struct {
+	key      []byte
+	password string
+	err      error
+	calls    int
+	keys     [][]byte
+}
+
+func (m *countingKeyProvider) Key(_ context.Context) ([]byte, string, error) {
+	m.calls++
+	key := append([]byte{}, m.key...)
+	m.keys = append(m.keys, key)
+
+	return key, m.password, m.err
+}
+
+type ProviderTestSuite struct {
+	suite.Suite
+	key []byte
+}
+
+func Test_Provider(t *testing.T) {
+	suite.Run(t, new(ProviderTestSuite))
+}
+
+func (suite *ProviderTestSuite) SetupTest() {
+	key, err := base64.StdEncoding.DecodeString(TestKey)
+
+	if err != nil {
+		suite.FailNow("Key decoding failed", "%v", err)
+	}
+
+	suite.key = key
+}
+
+func (suite *ProviderTestSuite) TestProvider_EnvKeyProvider_Ok() {
+	suite.T().Setenv("TEST_WEBMONEY_KEY", TestKey)
+	suite.T().Setenv("TEST_WEBMONEY_PASSWORD", TestPassword)
+
+	signer, err := NewSigner(WmId(TestWmId), Provider(NewEnvKeyProvider("TEST_WEBMONEY_KEY", "TEST_WEBMONEY_PASSWORD")))
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), signer.(*Signer).modulus)
+}
+
+func (suite *ProviderTestSuite) TestProvider_EnvKeyProvider_Error() {
+	provider := NewEnvKeyProvider("TEST_WEBMONEY_KEY", "TEST_WEBMONEY_PASSWORD")
+
+	_, err := NewSigner(WmId(TestWmId), Provider(provider))
+	assert.Equal(suite.T(), ErrorKeyNotConfigured, err)
+
+	suite.T().Setenv("TEST_WEBMONEY_KEY", TestKey)
+	_, err = NewSigner(WmId(TestWmId), Provider(provider))
+	assert.Equal(suite.T(), ErrorPasswordNotConfigured, err)
+
+	// The password option is used if the provider does not return the password
+	_, err = NewSigner(WmId(TestWmId), Provider(provider), Password(TestPassword))
+	assert.NoError(suite.T(), err)
+
+	suite.T().Setenv("TEST_WEBMONEY_KEY", "not base64")
+	_, err = NewSigner(WmId(TestWmId), Provider(provider), Password(TestPassword))
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ProviderTestSuite) TestProvider_FileKeyProvider_Ok() {
+	dir := suite.T().TempDir()
+	keyPath, passwordPath := filepath.Join(dir, "key.kwm"), filepath.Join(dir, "password")
+	assert.NoError(suite.T(), ioutil.WriteFile(keyPath, suite.key, 0600))
+	assert.NoError(suite.T(), ioutil.WriteFile(passwordPath, []byte(TestPassword+"\n"), 0600))
+
+	signer, err := NewSigner(WmId(TestWmId), Provider(NewFileKeyProvider(keyPath, passwordPath)))
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), signer)
+
+	_, err = NewSigner(WmId(TestWmId), Provider(NewFileKeyProvider(keyPath, filepath.Join(dir, "unknown"))))
+	assert.Error(suite.T(), err)
+
+	_, err = NewSigner(WmId(TestWmId), Provider(NewFileKeyProvider(filepath.Join(dir, "unknown"), passwordPath)))
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ProviderTestSuite) newVaultServer(status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(vaultTokenHeader) != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if r.URL.Path != "/v1/secret/data/webmoney" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func (suite *ProviderTestSuite) TestProvider_VaultKeyProvider_KvV2_Ok() {
+	server := suite.newVaultServer(
+		http.StatusOK,
+		`{"data":{"data":{"key":"`+TestKey+`","password":"`+TestPassword+`"},"metadata":{"version":1}}}`,
+	)
+	defer server.Close()
+
+	provider := NewVaultKeyProvider(server.URL+"/", "token", "/secret/data/webmoney")
+	signer, err := NewSigner(WmId(TestWmId), Provider(provider))
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), signer)
+}
+
+func (suite *ProviderTestSuite) TestProvider_VaultKeyProvider_KvV1_CustomFields_Ok() {
+	server := suite.newVaultServer(http.StatusOK, `{"data":{"kwm":"`+TestKey+`","secret":"`+TestPassword+`"}}`)
+	defer server.Close()
+
+	provider := NewVaultKeyProvider(server.URL, "token", "secret/data/webmoney")
+	provider.KeyField, provider.PasswordField = "kwm", "secret"
+	provider.HttpClient = server.Client()
+
+	key, password, err := provider.Key(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.key, key)
+	assert.Equal(suite.T(), TestPassword, password)
+}
+
+func (suite *ProviderTestSuite) TestProvider_VaultKeyProvider_Error() {
+	server := suite.newVaultServer(http.StatusOK, `{"data":{"data":{"password":"`+TestPassword+`"}}}`)
+	defer server.Close()
+
+	_, _, err := NewVaultKeyProvider(server.URL, "token", "secret/data/webmoney").Key(context.Background())
+	assert.Equal(suite.T(), ErrorKeyNotConfigured, err)
+
+	_, _, err = NewVaultKeyProvider(server.URL, "wrong", "secret/data/webmoney").Key(context.Background())
+	assert.True(suite.T(), errors.Is(err, ErrorVaultRequestFailed))
+	assert.EqualError(suite.T(), err, "the request to Vault failed: status 403")
+
+	_, _, err = NewVaultKeyProvider("http://127.0.0.1:0", "token", "secret/data/webmoney").Key(context.Background())
+	assert.Error(suite.T(), err)
+
+	broken := suite.newVaultServer(http.StatusOK, `<html></html>`)
+	defer broken.Close()
+
+	_, _, err = NewVaultKeyProvider(broken.URL, "token", "secret/data/webmoney").Key(context.Background())
+	assert.True(suite.T(), errors.Is(err, ErrorVaultRequestFailed))
+
+	notBase64 := suite.newVaultServer(http.StatusOK, `{"data":{"key":"not base64"}}`)
+	defer notBase64.Close()
+
+	_, _, err = NewVaultKeyProvider(notBase64.URL, "token", "secret/data/webmoney").Key(context.Background())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ProviderTestSuite) TestProvider_OnDemand_Ok() {
+	key, publicKey, err := GenerateKey(nil, TestWmId, TestPassword)
+	assert.NoError(suite.T(), err)
+
+	provider := &countingKeyProvider{key: key, password: TestPassword}
+	signer, err := NewSigner(WmId(TestWmId), Provider(provider), OnDemand(true))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, provider.calls)
+	assert.Nil(suite.T(), signer.(*Signer).power)
+	assert.Nil(suite.T(), signer.(*Signer).modulus)
+
+	verifier, err := publicKey.Verifier()
+	assert.NoError(suite.T(), err)
+
+	for i := 0; i < 3; i++ {
+		signature, err := signer.Sign("405002833238")
+		assert.NoError(suite.T(), err)
+		assert.NoError(suite.T(), verifier.Verify("405002833238", signature))
+	}
+
+	assert.Equal(suite.T(), 4, provider.calls)
+
+	// The key content returned by the provider is wiped after each signature
+	for _, key := range provider.keys {
+		assert.Equal(suite.T(), make([]byte, len(key)), key)
+	}
+
+	provider.err = errors.New("TestProvider_OnDemand_Error")
+	_, err = signer.Sign("405002833238")
+	assert.EqualError(suite.T(), err, "TestProvider_OnDemand_Error")
+}
+
+func (suite *ProviderTestSuite) TestProvider_OnDemand_KeyReader_Error() {
+	reader := &countingReader{data: append([]byte{}, suite.key...)}
+	_, err := NewSigner(WmId(TestWmId), KeyReader(reader), Password(TestPassword), OnDemand(true))
+	assert.Equal(suite.T(), ErrorKeyReaderOnDemand, err)
+
+	// The key bytes option is kept as is for the next signatures
+	key := append([]byte{}, suite.key...)
+	signer, err := NewSigner(WmId(TestWmId), KeyBytes(key), Password(TestPassword), OnDemand(true))
+	assert.NoError(suite.T(), err)
+
+	for i := 0; i < 2; i++ {
+		_, err = signer.Sign("405002833238")
+		assert.NoError(suite.T(), err)
+	}
+
+	assert.Equal(suite.T(), suite.key, key)
+}
+
+func (suite *ProviderTestSuite) TestProvider_Wipe_Ok() {
+	value := new(big.Int).Lsh(big.NewInt(1), 256)
+	words := value.Bits()
+	wipe(value, nil)
+
+	assert.Zero(suite.T(), value.Sign())
+
+	for _, w := range words {
+		assert.Zero(suite.T(), w)
+	}
+}
+
+type countingReader struct {
+	data []byte
+	eof  bool
+}
+
+func (m *countingReader) Read(b []byte) (int, error) {
+	if m.eof {
+		return 0, errors.New("the reader is read twice")
+	}
+
+	if len(m.data) == 0 {
+		m.eof = true
+		return 0, io.EOF
+	}
+
+	n := copy(b, m.data)
+	m.data = m.data[n:]
+
+	return n, nil
+}
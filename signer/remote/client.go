@@ -0,0 +1,93 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sidmal/webmoney/signer"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrorUrlNotConfigured       = errors.New("the signing server URL is not configured")
+	ErrorSignatureRequestFailed = errors.New("the signature request to the signing server failed")
+)
+
+// RemoteSigner signs the data of WMID with the signing server, it is plugged to the client
+// with webmoney.Signer option
+type RemoteSigner struct {
+	url        string
+	wmId       string
+	httpClient *http.Client
+}
+
+func NewRemoteSigner(opts ...ClientOption) (signer.WebMoneySignerInterface, error) {
+	options := &ClientOptions{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.url == "" {
+		return nil, ErrorUrlNotConfigured
+	}
+
+	if options.wmId == "" {
+		return nil, signer.ErrorWmIdNotConfigured
+	}
+
+	if !signer.WmIdRegex.MatchString(options.wmId) {
+		return nil, signer.ErrorWmIdIsIncorrect
+	}
+
+	httpClient := options.httpClient
+
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: options.tlsConfig},
+		}
+	}
+
+	remoteSigner := &RemoteSigner{
+		url:        strings.TrimRight(options.url, "/") + signPath,
+		wmId:       options.wmId,
+		httpClient: httpClient,
+	}
+
+	return remoteSigner, nil
+}
+
+func (m *RemoteSigner) Sign(data string) (string, error) {
+	b, err := json.Marshal(&signRequest{WmId: m.wmId, Data: []byte(data)})
+
+	if err != nil {
+		return "", err
+	}
+
+	rsp, err := m.httpClient.Post(m.url, "application/json", bytes.NewReader(b))
+
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+
+	out := new(signResponse)
+	err = json.NewDecoder(rsp.Body).Decode(out)
+
+	if rsp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: status %d: %s", ErrorSignatureRequestFailed, rsp.StatusCode, out.Error)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrorSignatureRequestFailed, err)
+	}
+
+	return out.Signature, nil
+}
@@ -0,0 +1,117 @@
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/sidmal/webmoney/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type ClientTestSuite struct {
+	suite.Suite
+	pki      *testPki
+	verifier signer.WebMoneyVerifierInterface
+	server   *httptest.Server
+}
+
+func Test_Client(t *testing.T) {
+	suite.Run(t, new(ClientTestSuite))
+}
+
+func (suite *ClientTestSuite) SetupTest() {
+	key, publicKey, err := signer.GenerateKey(nil, testWmId, "password")
+
+	if err != nil {
+		suite.FailNow("Key generation failed", "%v", err)
+	}
+
+	sig, err := signer.NewSigner(signer.WmId(testWmId), signer.KeyBytes(key), signer.Password("password"))
+
+	if err != nil {
+		suite.FailNow("Signer initialization failed", "%v", err)
+	}
+
+	suite.verifier, err = publicKey.Verifier()
+
+	if err != nil {
+		suite.FailNow("Verifier initialization failed", "%v", err)
+	}
+
+	server, err := NewServer(Signer(testWmId, sig))
+
+	if err != nil {
+		suite.FailNow("Signing server initialization failed", "%v", err)
+	}
+
+	suite.pki = newTestPki(suite.T())
+	suite.server = httptest.NewUnstartedServer(server)
+	suite.server.TLS = ServerTLSConfig(suite.pki.certificate("signer", x509.ExtKeyUsageServerAuth), suite.pki.pool)
+	suite.server.StartTLS()
+}
+
+func (suite *ClientTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *ClientTestSuite) tlsConfig() *tls.Config {
+	return &tls.Config{
+		RootCAs:      suite.pki.pool,
+		Certificates: []tls.Certificate{suite.pki.certificate("billing", x509.ExtKeyUsageClientAuth)},
+	}
+}
+
+func (suite *ClientTestSuite) TestClient_Sign_Ok() {
+	remoteSigner, err := NewRemoteSigner(Url(suite.server.URL+"/"), WmId(testWmId), TLSConfig(suite.tlsConfig()))
+	assert.NoError(suite.T(), err)
+
+	// The windows-1251 description is not valid UTF-8 and must be signed as is
+	data := "Z123456789012" + string([]byte{0xd2, 0xe5, 0xf1, 0xf2})
+	signature, err := remoteSigner.Sign(data)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.verifier.Verify(data, signature))
+}
+
+func (suite *ClientTestSuite) TestClient_Sign_Error() {
+	remoteSigner, err := NewRemoteSigner(Url(suite.server.URL), WmId("210987654321"), TLSConfig(suite.tlsConfig()))
+	assert.NoError(suite.T(), err)
+
+	_, err = remoteSigner.Sign("405002833238")
+	assert.True(suite.T(), errors.Is(err, ErrorSignatureRequestFailed))
+	assert.EqualError(suite.T(), err, "the signature request to the signing server failed: status 403: the WMID is not allowed")
+
+	// The server requires the client certificate on TLS handshake
+	remoteSigner, err = NewRemoteSigner(Url(suite.server.URL), WmId(testWmId), TLSConfig(&tls.Config{RootCAs: suite.pki.pool}))
+	assert.NoError(suite.T(), err)
+
+	_, err = remoteSigner.Sign("405002833238")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ClientTestSuite) TestClient_Sign_ResponseMalformed_Error() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html></html>`))
+	}))
+	defer server.Close()
+
+	remoteSigner, err := NewRemoteSigner(Url(server.URL), WmId(testWmId), HttpClient(server.Client()))
+	assert.NoError(suite.T(), err)
+
+	_, err = remoteSigner.Sign("405002833238")
+	assert.True(suite.T(), errors.Is(err, ErrorSignatureRequestFailed))
+}
+
+func (suite *ClientTestSuite) TestClient_NewRemoteSigner_Error() {
+	_, err := NewRemoteSigner(WmId(testWmId))
+	assert.Equal(suite.T(), ErrorUrlNotConfigured, err)
+
+	_, err = NewRemoteSigner(Url(suite.server.URL))
+	assert.Equal(suite.T(), signer.ErrorWmIdNotConfigured, err)
+
+	_, err = NewRemoteSigner(Url(suite.server.URL), WmId("wmid"))
+	assert.Equal(suite.T(), signer.ErrorWmIdIsIncorrect, err)
+}
@@ -0,0 +1,99 @@
+package remote
+
+import (
+	"crypto/tls"
+	"github.com/sidmal/webmoney/signer"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type ServerOptions struct {
+	// The signers of WMIDs
+	signers map[string]signer.WebMoneySignerInterface
+	// The WMIDs allowed to sign, all WMIDs with signers are allowed if empty
+	allowedWmIds map[string]struct{}
+	// The common names of client certificates allowed to request signatures, all verified clients are allowed if empty
+	allowedClients map[string]struct{}
+	// The audit logger of signature requests
+	logger *zap.Logger
+}
+
+type ServerOption func(*ServerOptions)
+
+type ClientOptions struct {
+	// The URL of the signing server, e.g. https://signer.example.com:8443
+	url string
+	// The WMID to sign requests for
+	wmId string
+	// The TLS config with the client certificate and the signing server CA
+	tlsConfig *tls.Config
+	// The HTTP client to send requests, it overrides the TLS config
+	httpClient *http.Client
+}
+
+type ClientOption func(*ClientOptions)
+
+// Signer registers the signer of WMID on the server
+func Signer(wmId string, val signer.WebMoneySignerInterface) ServerOption {
+	return func(opts *ServerOptions) {
+		if opts.signers == nil {
+			opts.signers = make(map[string]signer.WebMoneySignerInterface)
+		}
+
+		opts.signers[wmId] = val
+	}
+}
+
+func AllowedWmIds(val ...string) ServerOption {
+	return func(opts *ServerOptions) {
+		if opts.allowedWmIds == nil {
+			opts.allowedWmIds = make(map[string]struct{})
+		}
+
+		for _, v := range val {
+			opts.allowedWmIds[v] = struct{}{}
+		}
+	}
+}
+
+func AllowedClients(val ...string) ServerOption {
+	return func(opts *ServerOptions) {
+		if opts.allowedClients == nil {
+			opts.allowedClients = make(map[string]struct{})
+		}
+
+		for _, v := range val {
+			opts.allowedClients[v] = struct{}{}
+		}
+	}
+}
+
+func Logger(val *zap.Logger) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.logger = val
+	}
+}
+
+func Url(val string) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.url = val
+	}
+}
+
+func WmId(val string) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.wmId = val
+	}
+}
+
+func TLSConfig(val *tls.Config) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.tlsConfig = val
+	}
+}
+
+func HttpClient(val *http.Client) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.httpClient = val
+	}
+}
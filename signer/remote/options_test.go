@@ -0,0 +1,65 @@
+package remote
+
+import (
+	"crypto/tls"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"testing"
+)
+
+type staticSigner struct {
+	signature string
+	err       error
+	data      []string
+}
+
+func (m *staticSigner) Sign(data string) (string, error) {
+	m.data = append(m.data, data)
+	return m.signature, m.err
+}
+
+func TestServerOptions_Setters(t *testing.T) {
+	sig := &staticSigner{}
+	logger := zap.NewNop()
+
+	opts := []ServerOption{
+		Signer("123456789012", sig),
+		AllowedWmIds("123456789012", "210987654321"),
+		AllowedClients("billing"),
+		Logger(logger),
+	}
+	options := &ServerOptions{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	assert.Len(t, options.signers, 1)
+	assert.Equal(t, sig, options.signers["123456789012"])
+	assert.Equal(t, map[string]struct{}{"123456789012": {}, "210987654321": {}}, options.allowedWmIds)
+	assert.Equal(t, map[string]struct{}{"billing": {}}, options.allowedClients)
+	assert.Equal(t, logger, options.logger)
+}
+
+func TestClientOptions_Setters(t *testing.T) {
+	tlsConfig := &tls.Config{}
+	httpCln := &http.Client{}
+
+	opts := []ClientOption{
+		Url("https://signer.example.com"),
+		WmId("123456789012"),
+		TLSConfig(tlsConfig),
+		HttpClient(httpCln),
+	}
+	options := &ClientOptions{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	assert.Equal(t, "https://signer.example.com", options.url)
+	assert.Equal(t, "123456789012", options.wmId)
+	assert.Equal(t, tlsConfig, options.tlsConfig)
+	assert.Equal(t, httpCln, options.httpClient)
+}
@@ -0,0 +1,143 @@
+// Package remote provides the signing server holding WebMoney keys and the client signing
+// the requests with it, so the keys are kept on the single hardened host
+package remote
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+const (
+	signPath = "/v1/sign"
+
+	maxRequestSize = 64 << 10
+)
+
+var (
+	ErrorSignersNotConfigured = errors.New("the signers of WMIDs are not configured")
+
+	errorClientCertificateRequired = errors.New("the client certificate is required")
+	errorClientNotAllowed          = errors.New("the client is not allowed")
+	errorWmIdNotAllowed            = errors.New("the WMID is not allowed")
+	errorRequestIsIncorrect        = errors.New("the request is incorrect")
+	errorSignatureFailed           = errors.New("the signature failed")
+)
+
+// Server signs the data with the keys of WMIDs on request of the clients authenticated with TLS
+// client certificates. Each request is written to the audit log without the signed data
+type Server struct {
+	options *ServerOptions
+}
+
+type signRequest struct {
+	WmId string `json:"wmid"`
+	// The data is sent as bytes because it may be not valid UTF-8, e.g. windows-1251 description
+	Data []byte `json:"data"`
+}
+
+type signResponse struct {
+	Signature string `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func NewServer(opts ...ServerOption) (*Server, error) {
+	options := &ServerOptions{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if len(options.signers) == 0 {
+		return nil, ErrorSignersNotConfigured
+	}
+
+	if options.logger == nil {
+		options.logger = zap.NewNop()
+	}
+
+	return &Server{options: options}, nil
+}
+
+// ServerTLSConfig returns the TLS config of the server requiring the client certificates signed by the client CAs
+func ServerTLSConfig(certificate tls.Certificate, clientCAs *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+func (m *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != signPath {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	fields := []zap.Field{zap.String("remote_addr", r.RemoteAddr)}
+
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		m.write(w, http.StatusUnauthorized, nil, errorClientCertificateRequired, fields)
+		return
+	}
+
+	client := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	fields = append(fields, zap.String("client", client))
+
+	if _, ok := m.options.allowedClients[client]; !ok && len(m.options.allowedClients) > 0 {
+		m.write(w, http.StatusForbidden, nil, errorClientNotAllowed, fields)
+		return
+	}
+
+	req := new(signRequest)
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(req); err != nil {
+		m.write(w, http.StatusBadRequest, nil, errorRequestIsIncorrect, fields)
+		return
+	}
+
+	hash := sha256.Sum256(req.Data)
+	fields = append(fields, zap.String("wmid", req.WmId), zap.String("data_sha256", hex.EncodeToString(hash[:])))
+	sig, ok := m.options.signers[req.WmId]
+
+	if _, allowed := m.options.allowedWmIds[req.WmId]; !ok || (!allowed && len(m.options.allowedWmIds) > 0) {
+		m.write(w, http.StatusForbidden, nil, errorWmIdNotAllowed, fields)
+		return
+	}
+
+	signature, err := sig.Sign(string(req.Data))
+
+	// The signer errors may reveal the key storage details, so they are only logged
+	if err != nil {
+		m.write(w, http.StatusInternalServerError, nil, errorSignatureFailed, append(fields, zap.NamedError("reason", err)))
+		return
+	}
+
+	m.write(w, http.StatusOK, &signResponse{Signature: signature}, nil, fields)
+}
+
+func (m *Server) write(w http.ResponseWriter, status int, rsp *signResponse, err error, fields []zap.Field) {
+	fields = append(fields, zap.Int("status", status))
+
+	if err != nil {
+		rsp = &signResponse{Error: err.Error()}
+		m.options.logger.Warn("webmoney signature request rejected", append(fields, zap.Error(err))...)
+	} else {
+		m.options.logger.Info("webmoney signature request completed", fields...)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(rsp)
+}
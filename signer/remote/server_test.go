@@ -0,0 +1,251 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const (
+	testWmId      = "123456789012"
+	testOtherWmId = "210987654321"
+)
+
+// testPki is the CA with the server certificate and the certificates of clients signed by it
+type testPki struct {
+	t      *testing.T
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	serial int64
+	pool   *x509.CertPool
+}
+
+func newTestPki(t *testing.T) *testPki {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatalf("CA key generation failed: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+
+	if err != nil {
+		t.Fatalf("CA certificate creation failed: %v", err)
+	}
+
+	ca, err := x509.ParseCertificate(der)
+
+	if err != nil {
+		t.Fatalf("CA certificate parsing failed: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	return &testPki{t: t, ca: ca, caKey: key, serial: 1, pool: pool}
+}
+
+func (m *testPki) certificate(commonName string, usage x509.ExtKeyUsage) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		m.t.Fatalf("Key generation failed: %v", err)
+	}
+
+	m.serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(m.serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, m.ca, &key.PublicKey, m.caKey)
+
+	if err != nil {
+		m.t.Fatalf("Certificate creation failed: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// client returns HTTP client with the client certificate of the common name, without certificate if it is empty
+func (m *testPki) client(commonName string) *http.Client {
+	config := &tls.Config{RootCAs: m.pool}
+
+	if commonName != "" {
+		config.Certificates = []tls.Certificate{m.certificate(commonName, x509.ExtKeyUsageClientAuth)}
+	}
+
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+}
+
+type ServerTestSuite struct {
+	suite.Suite
+	pki    *testPki
+	signer *staticSigner
+	logs   *observer.ObservedLogs
+	server *httptest.Server
+}
+
+func Test_Server(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
+
+func (suite *ServerTestSuite) SetupTest() {
+	suite.pki = newTestPki(suite.T())
+	suite.signer = &staticSigner{signature: "signature"}
+
+	core, logs := observer.New(zap.InfoLevel)
+	suite.logs = logs
+
+	opts := []ServerOption{
+		Signer(testWmId, suite.signer),
+		Signer(testOtherWmId, suite.signer),
+		AllowedWmIds(testWmId),
+		AllowedClients("billing"),
+		Logger(zap.New(core)),
+	}
+	server, err := NewServer(opts...)
+
+	if err != nil {
+		suite.FailNow("Signing server initialization failed", "%v", err)
+	}
+
+	suite.server = httptest.NewUnstartedServer(server)
+	// The client certificate is requested but verified by the handler to check the missing certificate response
+	suite.server.TLS = ServerTLSConfig(suite.pki.certificate("signer", x509.ExtKeyUsageServerAuth), suite.pki.pool)
+	suite.server.TLS.ClientAuth = tls.VerifyClientCertIfGiven
+	suite.server.StartTLS()
+}
+
+func (suite *ServerTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *ServerTestSuite) post(client *http.Client, body string) int {
+	rsp, err := client.Post(suite.server.URL+signPath, "application/json", bytes.NewBufferString(body))
+
+	if err != nil {
+		suite.FailNow("Signature request failed", "%v", err)
+	}
+
+	_ = rsp.Body.Close()
+
+	return rsp.StatusCode
+}
+
+func (suite *ServerTestSuite) TestServer_NewServer_SignersNotConfigured_Error() {
+	server, err := NewServer(AllowedWmIds(testWmId))
+	assert.Equal(suite.T(), ErrorSignersNotConfigured, err)
+	assert.Nil(suite.T(), server)
+}
+
+func (suite *ServerTestSuite) TestServer_ServerTLSConfig_Ok() {
+	config := ServerTLSConfig(tls.Certificate{}, suite.pki.pool)
+	assert.Equal(suite.T(), tls.RequireAndVerifyClientCert, config.ClientAuth)
+	assert.Equal(suite.T(), suite.pki.pool, config.ClientCAs)
+	assert.Len(suite.T(), config.Certificates, 1)
+}
+
+func (suite *ServerTestSuite) TestServer_Sign_Ok() {
+	status := suite.post(suite.pki.client("billing"), `{"wmid":"123456789012","data":"MTIz"}`)
+	assert.Equal(suite.T(), http.StatusOK, status)
+	assert.Equal(suite.T(), []string{"123"}, suite.signer.data)
+
+	entries := suite.logs.All()
+	assert.Len(suite.T(), entries, 1)
+
+	hash := sha256.Sum256([]byte("123"))
+	fields := entries[0].ContextMap()
+	assert.Equal(suite.T(), "billing", fields["client"])
+	assert.Equal(suite.T(), testWmId, fields["wmid"])
+	assert.Equal(suite.T(), hex.EncodeToString(hash[:]), fields["data_sha256"])
+	assert.EqualValues(suite.T(), http.StatusOK, fields["status"])
+	assert.NotContains(suite.T(), fields, "data")
+}
+
+func (suite *ServerTestSuite) TestServer_Sign_Rejected_Error() {
+	requests := []struct {
+		client string
+		body   string
+		status int
+	}{
+		{client: "", body: `{"wmid":"123456789012","data":"MTIz"}`, status: http.StatusUnauthorized},
+		{client: "shop", body: `{"wmid":"123456789012","data":"MTIz"}`, status: http.StatusForbidden},
+		{client: "billing", body: `{"wmid":"210987654321","data":"MTIz"}`, status: http.StatusForbidden},
+		{client: "billing", body: `{"wmid":"111111111111","data":"MTIz"}`, status: http.StatusForbidden},
+		{client: "billing", body: `not json`, status: http.StatusBadRequest},
+	}
+
+	for _, req := range requests {
+		assert.Equal(suite.T(), req.status, suite.post(suite.pki.client(req.client), req.body), req)
+	}
+
+	assert.Empty(suite.T(), suite.signer.data)
+	assert.Len(suite.T(), suite.logs.FilterMessage("webmoney signature request rejected").All(), len(requests))
+}
+
+func (suite *ServerTestSuite) TestServer_Sign_SignerError() {
+	suite.signer.err = errors.New("TestServer_Sign_SignerError")
+
+	rsp, err := suite.pki.client("billing").Post(
+		suite.server.URL+signPath,
+		"application/json",
+		bytes.NewBufferString(`{"wmid":"123456789012","data":"MTIz"}`),
+	)
+	assert.NoError(suite.T(), err)
+
+	body, err := ioutil.ReadAll(rsp.Body)
+	_ = rsp.Body.Close()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusInternalServerError, rsp.StatusCode)
+	assert.JSONEq(suite.T(), `{"error":"the signature failed"}`, string(body))
+
+	entries := suite.logs.FilterMessage("webmoney signature request rejected").All()
+	assert.Len(suite.T(), entries, 1)
+	assert.Equal(suite.T(), "TestServer_Sign_SignerError", entries[0].ContextMap()["reason"])
+}
+
+func (suite *ServerTestSuite) TestServer_Routing_Error() {
+	client := suite.pki.client("billing")
+
+	rsp, err := client.Get(suite.server.URL + signPath)
+	assert.NoError(suite.T(), err)
+	_ = rsp.Body.Close()
+	assert.Equal(suite.T(), http.StatusMethodNotAllowed, rsp.StatusCode)
+
+	rsp, err = client.Post(suite.server.URL+"/unknown", "application/json", nil)
+	assert.NoError(suite.T(), err)
+	_ = rsp.Body.Close()
+	assert.Equal(suite.T(), http.StatusNotFound, rsp.StatusCode)
+}
@@ -2,11 +2,12 @@ package signer
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
-	"encoding/base64"
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"golang.org/x/crypto/md4"
 	"io"
 	"math/big"
@@ -14,15 +15,21 @@ import (
 )
 
 const (
-	keyLength = 164
+	keyLength   = 164
+	keySignFlag = 1
 )
 
 var (
-	ErrorKeyFileBroken         = errors.New("TestKey file is broken")
-	ErrorWmIdNotConfigured     = errors.New("the WebMoney's WMID identifier not configured")
-	ErrorWmIdIsIncorrect       = errors.New("the WebMoney's WMID identifier is incorrect")
-	ErrorKeyNotConfigured      = errors.New("the WebMoney Pro *.kvm TestKey not configured")
-	ErrorPasswordNotConfigured = errors.New("the TestPassword to WebMoney Pro *.kvm TestKey not configured")
+	ErrorKeyFileBroken          = errors.New("TestKey file is broken")
+	ErrorWmIdNotConfigured      = errors.New("the WebMoney's WMID identifier not configured")
+	ErrorWmIdIsIncorrect        = errors.New("the WebMoney's WMID identifier is incorrect")
+	ErrorKeyNotConfigured       = errors.New("the WebMoney Pro *.kvm TestKey not configured")
+	ErrorPasswordNotConfigured  = errors.New("the TestPassword to WebMoney Pro *.kvm TestKey not configured")
+	ErrorKeyLengthIsIncorrect   = fmt.Errorf("%w: the key length must be %d bytes", ErrorKeyFileBroken, keyLength)
+	ErrorKeyCrcMismatch         = fmt.Errorf("%w: the key checksum mismatch", ErrorKeyFileBroken)
+	ErrorKeyPasswordIncorrect   = errors.New("the WMID or password to WebMoney Pro *.kwm key is incorrect")
+	ErrorKeySignFlagUnsupported = errors.New("the sign flag of WebMoney Pro *.kwm key is not supported")
+	ErrorKeyReaderOnDemand      = errors.New("the key reader can not be read again to load the key on demand")
 
 	WmIdRegex = regexp.MustCompile("[0-9]{12}")
 )
@@ -49,6 +56,7 @@ type Signer struct {
 	power    *big.Int
 	modulus  *big.Int
 	external ExternalInterface
+	options  *Options
 }
 
 type crc [16]byte
@@ -83,36 +91,25 @@ func NewSigner(opts ...Option) (WebMoneySignerInterface, error) {
 		return nil, err
 	}
 
-	decodedKey, err := base64.StdEncoding.DecodeString(options.key)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if len(decodedKey) != keyLength {
-		return nil, ErrorKeyFileBroken
-	}
-
 	signer := &Signer{
 		external: newReader(),
+		options:  options,
 	}
-	keyContainer, err := options.newKeyContainerFn(decodedKey, options.wmId, options.password, signer.external)
+	power, modulus, err := signer.loadKey(context.Background())
 
 	if err != nil {
 		return nil, err
 	}
 
-	if !keyContainer.Verify() {
-		return nil, ErrorKeyFileBroken
+	// The key is checked on creation anyway to report the configuration errors early
+	if options.onDemand {
+		wipe(power, modulus)
+		return signer, nil
 	}
 
-	signer.power, signer.modulus, err = keyContainer.Extract()
-
-	if err != nil {
-		return nil, err
-	}
+	signer.power, signer.modulus = power, modulus
 
-	return signer, err
+	return signer, nil
 }
 
 func executeOptions(opts ...Option) (*Options, error) {
@@ -130,7 +127,16 @@ func executeOptions(opts ...Option) (*Options, error) {
 		return nil, ErrorWmIdIsIncorrect
 	}
 
-	if options.key == "" {
+	if options.newKeyContainerFn == nil {
+		options.newKeyContainerFn = newKeyContainer
+	}
+
+	// The key provider returns the password with the key
+	if options.provider != nil {
+		return options, nil
+	}
+
+	if options.key == "" && options.keyBytes == nil && options.keyFile == "" && options.keyReader == nil {
 		return nil, ErrorKeyNotConfigured
 	}
 
@@ -138,18 +144,112 @@ func executeOptions(opts ...Option) (*Options, error) {
 		return nil, ErrorPasswordNotConfigured
 	}
 
-	if options.newKeyContainerFn == nil {
-		options.newKeyContainerFn = newKeyContainer
+	// The reader key is not kept in memory, so it can be read only on creation
+	if options.onDemand && options.keyBytes == nil && options.keyFile == "" && options.keyReader != nil {
+		return nil, ErrorKeyReaderOnDemand
 	}
 
+	options.provider = &optionsKeyProvider{options: options}
+
 	return options, nil
 }
 
+// loadKey returns the private exponent and the modulus of the key returned by the key provider
+func (m *Signer) loadKey(ctx context.Context) (*big.Int, *big.Int, error) {
+	key, password, err := m.options.provider.Key(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer wipeBytes(key)
+
+	if password == "" {
+		password = m.options.password
+	}
+
+	if password == "" {
+		return nil, nil, ErrorPasswordNotConfigured
+	}
+
+	if err = checkKey(key); err != nil {
+		return nil, nil, err
+	}
+
+	container, err := m.options.newKeyContainerFn(key, m.options.wmId, password, m.external)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The decrypted key is not needed after the extraction of its values
+	if decrypted, ok := container.(*keyContainer); ok {
+		defer func() {
+			decrypted.key.Buffer = buffer{}
+		}()
+	}
+
+	if !container.Verify() {
+		return nil, nil, verifyError(container)
+	}
+
+	return container.Extract()
+}
+
+// checkKey checks the length and the sign flag of *.kwm key
+func checkKey(key []byte) error {
+	if len(key) != keyLength {
+		return ErrorKeyLengthIsIncorrect
+	}
+
+	if binary.LittleEndian.Uint16(key[2:4]) != keySignFlag {
+		return ErrorKeySignFlagUnsupported
+	}
+
+	return nil
+}
+
+// verifyError returns the reason of the key checksum mismatch. The wrong WMID or password produce
+// the garbage on decryption while the key corrupted in other way keeps the consistent structure
+func verifyError(container KeyContainerInterface) error {
+	keyContainer, ok := container.(*keyContainer)
+
+	if !ok {
+		return ErrorKeyFileBroken
+	}
+
+	data := new(keyData)
+	err := keyContainer.external.BinaryRead(bytes.NewReader(keyContainer.key.Buffer[:]), binary.LittleEndian, data)
+
+	if err != nil || keyContainer.key.Length != uint32(len(keyContainer.key.Buffer)) {
+		return ErrorKeyCrcMismatch
+	}
+
+	if data.ModulusBase != data.PowerBase {
+		return ErrorKeyPasswordIncorrect
+	}
+
+	return ErrorKeyCrcMismatch
+}
+
 func newReader() ExternalInterface {
 	return &external{}
 }
 
 func (m *Signer) Sign(data string) (string, error) {
+	power, modulus := m.power, m.modulus
+
+	if m.options != nil && m.options.onDemand {
+		var err error
+		power, modulus, err = m.loadKey(context.Background())
+
+		if err != nil {
+			return "", err
+		}
+
+		defer wipe(power, modulus)
+	}
+
 	hash := md4Hash([]byte(data))
 	base := hash[:]
 
@@ -166,7 +266,7 @@ func (m *Signer) Sign(data string) (string, error) {
 	base = append([]byte{baseLength, 0}, base...)
 	baseLength += 2
 	baseReversed := reverseBytes(base)
-	result := new(big.Int).Exp(new(big.Int).SetBytes(baseReversed), m.power, m.modulus)
+	result := new(big.Int).Exp(new(big.Int).SetBytes(baseReversed), power, modulus)
 	reversedResult, err := m.reverseBytesAsWords(result.Bytes())
 
 	if err != nil {
@@ -247,10 +347,12 @@ func (m *keyContainer) Extract() (*big.Int, *big.Int, error) {
 		return nil, nil, err
 	}
 
-	copy(data.Power[:], reverseBytes(data.Power[:]))
-	copy(data.Modulus[:], reverseBytes(data.Modulus[:]))
+	defer wipeBytes(data.Power[:], data.Modulus[:])
+
+	power, modulus := reverseBytes(data.Power[:]), reverseBytes(data.Modulus[:])
+	defer wipeBytes(power, modulus)
 
-	return new(big.Int).SetBytes(data.Power[:]), new(big.Int).SetBytes(data.Modulus[:]), nil
+	return new(big.Int).SetBytes(power), new(big.Int).SetBytes(modulus), nil
 }
 
 func (m *keyContainer) Encrypt(wmid, keyPassword string) {
@@ -259,6 +361,17 @@ func (m *keyContainer) Encrypt(wmid, keyPassword string) {
 }
 
 func (m *keyContainer) Verify() bool {
+	crc, err := m.checksum()
+
+	if err != nil {
+		return false
+	}
+
+	return crc == m.key.Crc
+}
+
+// checksum returns MD4 hash of the decrypted key with zero sign flag and CRC
+func (m *keyContainer) checksum() (crc, error) {
 	key := &keyContainerKey{
 		Reserved: m.key.Reserved,
 		Length:   m.key.Length,
@@ -268,12 +381,10 @@ func (m *keyContainer) Verify() bool {
 	err := m.external.BinaryWrite(buffer, binary.LittleEndian, key)
 
 	if err != nil {
-		return false
+		return crc{}, err
 	}
 
-	crc := md4Hash(buffer.Bytes())
-
-	return crc == m.key.Crc
+	return md4Hash(buffer.Bytes()), nil
 }
 
 func md4Hash(data []byte) crc {
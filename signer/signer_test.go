@@ -1,11 +1,16 @@
 package signer
 
 import (
+	"bytes"
+	"encoding/base64"
 	"errors"
 	"github.com/sidmal/webmoney/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -104,7 +109,86 @@ func (suite *SignerTestSuite) TestSigner_NewSigner_DecodedKeyLengthInvalid_Error
 	suite.defaultOptions = append(suite.defaultOptions, Key("MTIzNDU2Nzg5MA=="))
 	signer, err := NewSigner(suite.defaultOptions...)
 	assert.Error(suite.T(), err)
-	assert.Equal(suite.T(), ErrorKeyFileBroken, err)
+	assert.Equal(suite.T(), ErrorKeyLengthIsIncorrect, err)
+	assert.True(suite.T(), errors.Is(err, ErrorKeyFileBroken))
+	assert.Nil(suite.T(), signer)
+}
+
+func (suite *SignerTestSuite) keyBytes() []byte {
+	key, err := base64.StdEncoding.DecodeString(TestKey)
+
+	if err != nil {
+		suite.FailNow("Key decoding failed", "%v", err)
+	}
+
+	return key
+}
+
+func (suite *SignerTestSuite) TestSigner_NewSigner_KeyBytes_Ok() {
+	signer, err := NewSigner(WmId(TestWmId), KeyBytes(suite.keyBytes()), Password(TestPassword))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.signer.modulus, signer.(*Signer).modulus)
+}
+
+func (suite *SignerTestSuite) TestSigner_NewSigner_KeyFile_Ok() {
+	path := filepath.Join(suite.T().TempDir(), "405002833238.kwm")
+	assert.NoError(suite.T(), ioutil.WriteFile(path, suite.keyBytes(), 0600))
+
+	signer, err := NewSigner(WmId(TestWmId), KeyFile(path), Password(TestPassword))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.signer.power, signer.(*Signer).power)
+}
+
+func (suite *SignerTestSuite) TestSigner_NewSigner_KeyFile_NotExist_Error() {
+	path := filepath.Join(suite.T().TempDir(), "unknown.kwm")
+	signer, err := NewSigner(WmId(TestWmId), KeyFile(path), Password(TestPassword))
+	assert.True(suite.T(), errors.Is(err, os.ErrNotExist))
+	assert.Nil(suite.T(), signer)
+}
+
+func (suite *SignerTestSuite) TestSigner_NewSigner_KeyReader_Ok() {
+	signer, err := NewSigner(WmId(TestWmId), KeyReader(bytes.NewReader(suite.keyBytes())), Password(TestPassword))
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), signer)
+}
+
+func (suite *SignerTestSuite) TestSigner_NewSigner_KeyReader_TooLong_Error() {
+	key := append(suite.keyBytes(), make([]byte, 1024)...)
+	signer, err := NewSigner(WmId(TestWmId), KeyReader(bytes.NewReader(key)), Password(TestPassword))
+	assert.Equal(suite.T(), ErrorKeyLengthIsIncorrect, err)
+	assert.Nil(suite.T(), signer)
+}
+
+func (suite *SignerTestSuite) TestSigner_NewSigner_PasswordIncorrect_Error() {
+	signer, err := NewSigner(WmId(TestWmId), Key(TestKey), Password("password"))
+	assert.Equal(suite.T(), ErrorKeyPasswordIncorrect, err)
+	assert.False(suite.T(), errors.Is(err, ErrorKeyFileBroken))
+	assert.Nil(suite.T(), signer)
+
+	signer, err = NewSigner(WmId("123456789012"), Key(TestKey), Password(TestPassword))
+	assert.Equal(suite.T(), ErrorKeyPasswordIncorrect, err)
+	assert.Nil(suite.T(), signer)
+}
+
+func (suite *SignerTestSuite) TestSigner_NewSigner_KeyCorrupted_Error() {
+	// The bytes of CRC, key length and modulus
+	for _, i := range []int{5, 20, 140} {
+		key := suite.keyBytes()
+		key[i] ^= 0x01
+
+		signer, err := NewSigner(WmId(TestWmId), KeyBytes(key), Password(TestPassword))
+		assert.Equal(suite.T(), ErrorKeyCrcMismatch, err, i)
+		assert.True(suite.T(), errors.Is(err, ErrorKeyFileBroken))
+		assert.Nil(suite.T(), signer)
+	}
+}
+
+func (suite *SignerTestSuite) TestSigner_NewSigner_SignFlagUnsupported_Error() {
+	key := suite.keyBytes()
+	key[2] = 0
+
+	signer, err := NewSigner(WmId(TestWmId), KeyBytes(key), Password(TestPassword))
+	assert.Equal(suite.T(), ErrorKeySignFlagUnsupported, err)
 	assert.Nil(suite.T(), signer)
 }
 
@@ -0,0 +1,94 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+)
+
+const (
+	signatureLengthPrefix = 2
+)
+
+var (
+	ErrorPublicKeyIsIncorrect = errors.New("the WebMoney public key is incorrect")
+	ErrorSignatureIsIncorrect = errors.New("the signature is incorrect")
+)
+
+type WebMoneyVerifierInterface interface {
+	Verify(data, signature string) error
+}
+
+// Verifier checks the signatures made by Signer with the public key of WMID
+type Verifier struct {
+	exponent *big.Int
+	modulus  *big.Int
+}
+
+// NewVerifier returns the verifier for the public key of WMID given as the exponent and the modulus
+func NewVerifier(exponent, modulus *big.Int) (WebMoneyVerifierInterface, error) {
+	one := big.NewInt(1)
+
+	if exponent == nil || modulus == nil || exponent.Cmp(one) <= 0 || modulus.Cmp(one) <= 0 {
+		return nil, ErrorPublicKeyIsIncorrect
+	}
+
+	verifier := &Verifier{
+		exponent: new(big.Int).Set(exponent),
+		modulus:  new(big.Int).Set(modulus),
+	}
+
+	return verifier, nil
+}
+
+// Verify reverses the Sign algorithm: the signature is raised to the public exponent and the result
+// must be the length prefixed block started with MD4 digest of the data
+func (m *Verifier) Verify(data, signature string) error {
+	b, err := hex.DecodeString(signature)
+
+	if err != nil || len(b) == 0 || len(b)%2 != 0 {
+		return ErrorSignatureIsIncorrect
+	}
+
+	s := new(big.Int).SetBytes(reverseWords(b))
+
+	if s.Cmp(m.modulus) >= 0 {
+		return ErrorSignatureIsIncorrect
+	}
+
+	block := reverseBytes(new(big.Int).Exp(s, m.exponent, m.modulus).Bytes())
+
+	if len(block) < signatureLengthPrefix {
+		return ErrorSignatureIsIncorrect
+	}
+
+	length := int(binary.LittleEndian.Uint16(block))
+
+	if length < len(crc{}) || length+signatureLengthPrefix < len(block) {
+		return ErrorSignatureIsIncorrect
+	}
+
+	// The high zero bytes of the block are lost in the number
+	block = append(block, make([]byte, length+signatureLengthPrefix-len(block))...)
+	hash := md4Hash([]byte(data))
+
+	if !bytes.Equal(block[signatureLengthPrefix:signatureLengthPrefix+len(hash)], hash[:]) {
+		return ErrorSignatureIsIncorrect
+	}
+
+	return nil
+}
+
+// reverseWords reverses the order of two bytes words keeping the order of bytes in each word
+func reverseWords(data []byte) []byte {
+	length := len(data)
+	reversed := make([]byte, length)
+
+	for i := 0; i < length; i += 2 {
+		copy(reversed[length-i-2:length-i], data[i:i+2])
+	}
+
+	return reversed
+}
@@ -0,0 +1,137 @@
+package signer
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+type VerifierTestSuite struct {
+	suite.Suite
+	signer   *Signer
+	verifier WebMoneyVerifierInterface
+	exponent *big.Int
+	modulus  *big.Int
+}
+
+func Test_Verifier(t *testing.T) {
+	suite.Run(t, new(VerifierTestSuite))
+}
+
+func (suite *VerifierTestSuite) SetupTest() {
+	key, publicKey, err := GenerateKey(nil, TestWmId, TestPassword)
+
+	if err != nil {
+		suite.FailNow("Key generation failed", "%v", err)
+	}
+
+	signer, err := NewSigner(WmId(TestWmId), KeyBytes(key), Password(TestPassword))
+
+	if err != nil {
+		suite.FailNow("Signer initialization failed", "%v", err)
+	}
+
+	verifier, err := publicKey.Verifier()
+
+	if err != nil {
+		suite.FailNow("Verifier initialization failed", "%v", err)
+	}
+
+	suite.signer = signer.(*Signer)
+	suite.verifier = verifier
+	suite.exponent, suite.modulus = publicKey.Exponent, publicKey.Modulus
+}
+
+func (suite *VerifierTestSuite) TestVerifier_RoundTrip_Ok() {
+	data := []string{
+		"",
+		"405002833238",
+		"Z123456789012" + "20200901120000123",
+		strings.Repeat("Тестовое описание платежа ", 20),
+	}
+
+	for _, v := range data {
+		for i := 0; i < 10; i++ {
+			signature, err := suite.signer.Sign(v)
+			assert.NoError(suite.T(), err)
+			assert.NoError(suite.T(), suite.verifier.Verify(v, signature), v)
+		}
+	}
+}
+
+func (suite *VerifierTestSuite) TestVerifier_Verify_DataChanged_Error() {
+	signature, err := suite.signer.Sign("Z12345678901220200901120000123")
+	assert.NoError(suite.T(), err)
+
+	err = suite.verifier.Verify("Z12345678901220200901120000124", signature)
+	assert.Equal(suite.T(), ErrorSignatureIsIncorrect, err)
+}
+
+func (suite *VerifierTestSuite) TestVerifier_Verify_SignatureChanged_Error() {
+	signature, err := suite.signer.Sign("405002833238")
+	assert.NoError(suite.T(), err)
+
+	b, err := hex.DecodeString(signature)
+	assert.NoError(suite.T(), err)
+	b[len(b)/2] ^= 0x01
+
+	err = suite.verifier.Verify("405002833238", hex.EncodeToString(b))
+	assert.Equal(suite.T(), ErrorSignatureIsIncorrect, err)
+}
+
+func (suite *VerifierTestSuite) TestVerifier_Verify_OtherKey_Error() {
+	_, publicKey, err := GenerateKey(nil, TestWmId, TestPassword)
+	assert.NoError(suite.T(), err)
+
+	verifier, err := publicKey.Verifier()
+	assert.NoError(suite.T(), err)
+
+	signature, err := suite.signer.Sign("405002833238")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), ErrorSignatureIsIncorrect, verifier.Verify("405002833238", signature))
+}
+
+func (suite *VerifierTestSuite) TestVerifier_Verify_SignatureMalformed_Error() {
+	modulus := suite.modulus.Bytes()
+
+	if len(modulus)%2 != 0 {
+		modulus = append([]byte{0}, modulus...)
+	}
+
+	tooLarge := reverseWords(modulus)
+	signatures := []string{
+		"",
+		"not hex",
+		"abc",
+		hex.EncodeToString(tooLarge),
+		"0000",
+		"0100",
+	}
+
+	for _, signature := range signatures {
+		assert.Equal(suite.T(), ErrorSignatureIsIncorrect, suite.verifier.Verify("405002833238", signature), signature)
+	}
+}
+
+func (suite *VerifierTestSuite) TestVerifier_NewVerifier_Error() {
+	keys := [][2]*big.Int{
+		{nil, suite.modulus},
+		{suite.exponent, nil},
+		{big.NewInt(1), suite.modulus},
+		{suite.exponent, big.NewInt(0)},
+	}
+
+	for _, key := range keys {
+		verifier, err := NewVerifier(key[0], key[1])
+		assert.Equal(suite.T(), ErrorPublicKeyIsIncorrect, err)
+		assert.Nil(suite.T(), verifier)
+	}
+}
+
+func (suite *VerifierTestSuite) TestVerifier_ReverseWords_Ok() {
+	assert.Equal(suite.T(), []byte{5, 6, 3, 4, 1, 2}, reverseWords([]byte{1, 2, 3, 4, 5, 6}))
+	assert.Empty(suite.T(), reverseWords(nil))
+}
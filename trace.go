@@ -0,0 +1,65 @@
+package webmoney
+
+import (
+	"context"
+)
+
+type clientTraceContextKey struct{}
+
+// ClientTrace is a set of hooks to run at the stages of the request to WebMoney XML interface.
+// Any particular hook may be nil. It is intended to be used by interceptors, e.g. to trace
+// signing and HTTP round trip of the operation
+type ClientTrace struct {
+	// RequestNumberAssigned is called before signing with the request number (reqn) of the request
+	RequestNumberAssigned func(reqn string)
+	// SignStart is called before the request signing
+	SignStart func()
+	// SignDone is called after the request signing with the signing error if any
+	SignDone func(err error)
+	// RoundTripStart is called before the request is sent to WebMoney
+	RoundTripStart func()
+	// RoundTripDone is called after the response body is read with HTTP status code and the error if any
+	RoundTripDone func(statusCode int, err error)
+}
+
+// WithClientTrace returns new context based on the provided parent ctx.
+// Requests made with the returned context will use the provided trace hooks
+func WithClientTrace(ctx context.Context, trace *ClientTrace) context.Context {
+	return context.WithValue(ctx, clientTraceContextKey{}, trace)
+}
+
+// ContextClientTrace returns the ClientTrace associated with the provided context or nil
+func ContextClientTrace(ctx context.Context) *ClientTrace {
+	trace, _ := ctx.Value(clientTraceContextKey{}).(*ClientTrace)
+	return trace
+}
+
+func (m *ClientTrace) requestNumberAssigned(reqn string) {
+	if m != nil && m.RequestNumberAssigned != nil {
+		m.RequestNumberAssigned(reqn)
+	}
+}
+
+func (m *ClientTrace) signStart() {
+	if m != nil && m.SignStart != nil {
+		m.SignStart()
+	}
+}
+
+func (m *ClientTrace) signDone(err error) {
+	if m != nil && m.SignDone != nil {
+		m.SignDone(err)
+	}
+}
+
+func (m *ClientTrace) roundTripStart() {
+	if m != nil && m.RoundTripStart != nil {
+		m.RoundTripStart()
+	}
+}
+
+func (m *ClientTrace) roundTripDone(statusCode int, err error) {
+	if m != nil && m.RoundTripDone != nil {
+		m.RoundTripDone(statusCode, err)
+	}
+}
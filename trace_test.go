@@ -0,0 +1,121 @@
+package webmoney
+
+import (
+	"context"
+	"errors"
+	"github.com/sidmal/webmoney/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+)
+
+type TraceTestSuite struct {
+	suite.Suite
+	webmoney *WebMoney
+	events   []string
+	trace    *ClientTrace
+}
+
+func Test_Trace(t *testing.T) {
+	suite.Run(t, new(TraceTestSuite))
+}
+
+func (suite *TraceTestSuite) SetupTest() {
+	suite.events = nil
+	suite.trace = &ClientTrace{
+		RequestNumberAssigned: func(reqn string) {
+			assert.NotEmpty(suite.T(), reqn)
+			suite.events = append(suite.events, "reqn")
+		},
+		SignStart: func() {
+			suite.events = append(suite.events, "sign_start")
+		},
+		SignDone: func(err error) {
+			suite.events = append(suite.events, "sign_done")
+		},
+		RoundTripStart: func() {
+			suite.events = append(suite.events, "round_trip_start")
+		},
+		RoundTripDone: func(statusCode int, err error) {
+			suite.events = append(suite.events, "round_trip_done")
+		},
+	}
+
+	trace := InterceptorFunc(func(ctx context.Context, invocation *Invocation, next Handler) error {
+		return next(WithClientTrace(ctx, suite.trace), invocation)
+	})
+	opts := []Option{
+		WmId(TestWmId),
+		Key(TestKey),
+		Password(TestPassword),
+		httpClient(mocks.NewTransportStatusOk()),
+		Interceptors(trace),
+	}
+	wm, err := NewWebMoney(opts...)
+
+	if err != nil {
+		suite.FailNow("WebMoney handler initialization failed", "%v", err)
+	}
+
+	suite.webmoney = wm.(*WebMoney)
+}
+
+func (suite *TraceTestSuite) TestTrace_Hooks_Ok() {
+	var statusCode int
+
+	suite.trace.RoundTripDone = func(code int, err error) {
+		assert.NoError(suite.T(), err)
+		statusCode = code
+		suite.events = append(suite.events, "round_trip_done")
+	}
+
+	_, err := suite.webmoney.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"reqn", "sign_start", "sign_done", "round_trip_start", "round_trip_done"}, suite.events)
+	assert.Equal(suite.T(), http.StatusOK, statusCode)
+}
+
+func (suite *TraceTestSuite) TestTrace_Hooks_SignError() {
+	mockSigner := &mocks.WebMoneySignerInterface{}
+	mockSigner.On("Sign", mock.Anything).Return("", errors.New("TestTrace_Hooks_SignError"))
+	suite.webmoney.signer = mockSigner
+
+	_, err := suite.webmoney.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.EqualError(suite.T(), err, "TestTrace_Hooks_SignError")
+	assert.Equal(suite.T(), []string{"reqn", "sign_start", "sign_done"}, suite.events)
+}
+
+func (suite *TraceTestSuite) TestTrace_Hooks_TransportError() {
+	var roundTripErr error
+
+	suite.trace.RoundTripDone = func(code int, err error) {
+		assert.Zero(suite.T(), code)
+		roundTripErr = err
+	}
+	suite.webmoney.httpClient = mocks.NewTransportStatusError()
+
+	_, err := suite.webmoney.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), err, roundTripErr)
+}
+
+func (suite *TraceTestSuite) TestTrace_Hooks_NotSet_Ok() {
+	suite.trace.RequestNumberAssigned = nil
+	suite.trace.SignStart = nil
+	suite.trace.SignDone = nil
+	suite.trace.RoundTripStart = nil
+	suite.trace.RoundTripDone = nil
+
+	_, err := suite.webmoney.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), suite.events)
+}
+
+func TestTrace_ContextClientTrace_Ok(t *testing.T) {
+	assert.Nil(t, ContextClientTrace(context.Background()))
+
+	trace := &ClientTrace{}
+	assert.Equal(t, trace, ContextClientTrace(WithClientTrace(context.Background(), trace)))
+}
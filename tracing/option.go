@@ -0,0 +1,18 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Options struct {
+	// The OpenTelemetry tracer provider, the global provider is used if not set
+	tracerProvider trace.TracerProvider
+}
+
+type Option func(*Options)
+
+func TracerProvider(val trace.TracerProvider) Option {
+	return func(opts *Options) {
+		opts.tracerProvider = val
+	}
+}
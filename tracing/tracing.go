@@ -0,0 +1,145 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/sidmal/webmoney"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strconv"
+)
+
+const (
+	instrumentationName = "github.com/sidmal/webmoney/tracing"
+
+	spanNamePrefix = "webmoney."
+	spanNameSign   = "webmoney.sign"
+	spanNameHttp   = "webmoney.http"
+
+	attributeInterface      = attribute.Key("webmoney.interface")
+	attributeRequestNumber  = attribute.Key("webmoney.reqn")
+	attributeRetval         = attribute.Key("webmoney.retval")
+	attributeCurrency       = attribute.Key("webmoney.currency")
+	attributeTxnId          = attribute.Key("webmoney.tranid")
+	attributeHttpStatusCode = attribute.Key("http.status_code")
+)
+
+// Interceptor starts the OpenTelemetry span per operation with WebMoney XML interface and the child spans
+// for the request signing and HTTP round trip. Only non-sensitive data is recorded to spans attributes:
+// the signature, WMID, purses numbers and descriptions are never recorded.
+// It is plugged to the client with webmoney.Interceptors option
+type Interceptor struct {
+	tracer trace.Tracer
+}
+
+func NewInterceptor(opts ...Option) *Interceptor {
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.tracerProvider == nil {
+		options.tracerProvider = otel.GetTracerProvider()
+	}
+
+	return &Interceptor{
+		tracer: options.tracerProvider.Tracer(instrumentationName),
+	}
+}
+
+func (m *Interceptor) Intercept(ctx context.Context, invocation *webmoney.Invocation, next webmoney.Handler) error {
+	ctx, span := m.tracer.Start(
+		ctx,
+		spanNamePrefix+invocation.Interface,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributeInterface.String(invocation.Interface)),
+	)
+	defer span.End()
+
+	span.SetAttributes(requestAttributes(invocation.Request)...)
+
+	spanCtx := ctx
+	var childSpan trace.Span
+
+	clientTrace := &webmoney.ClientTrace{
+		RequestNumberAssigned: func(reqn string) {
+			span.SetAttributes(attributeRequestNumber.String(reqn))
+		},
+		SignStart: func() {
+			_, childSpan = m.tracer.Start(spanCtx, spanNameSign, trace.WithSpanKind(trace.SpanKindInternal))
+		},
+		SignDone: func(err error) {
+			endSpan(childSpan, err)
+		},
+		RoundTripStart: func() {
+			_, childSpan = m.tracer.Start(spanCtx, spanNameHttp, trace.WithSpanKind(trace.SpanKindClient))
+		},
+		RoundTripDone: func(statusCode int, err error) {
+			if statusCode > 0 {
+				childSpan.SetAttributes(attributeHttpStatusCode.Int(statusCode))
+
+				if err == nil && statusCode >= http.StatusBadRequest {
+					childSpan.SetStatus(codes.Error, http.StatusText(statusCode))
+				}
+			}
+
+			endSpan(childSpan, err)
+		},
+	}
+
+	err := next(webmoney.WithClientTrace(ctx, clientTrace), invocation)
+
+	var rspErr *webmoney.ResponseError
+
+	switch {
+	case errors.As(err, &rspErr):
+		// The retdesc text of WebMoney may contain the request data, so only the retval code is recorded
+		span.SetAttributes(attributeRetval.Int(rspErr.Code))
+		span.SetStatus(codes.Error, "retval "+strconv.Itoa(rspErr.Code))
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	default:
+		span.SetAttributes(attributeRetval.Int(0))
+	}
+
+	return err
+}
+
+func requestAttributes(request interface{}) []attribute.KeyValue {
+	var attributes []attribute.KeyValue
+
+	switch in := request.(type) {
+	case *webmoney.TransferMoneyRequest:
+		attributes = append(
+			attributes,
+			attributeCurrency.String(string(in.PurseSrc.Currency())),
+			attributeTxnId.Int64(int64(in.TxnId)),
+		)
+	case *webmoney.GetTransactionsHistoryRequest:
+		attributes = append(attributes, attributeCurrency.String(string(in.Purse.Currency())))
+
+		if in.TxnId > 0 {
+			attributes = append(attributes, attributeTxnId.Int64(in.TxnId))
+		}
+	}
+
+	return attributes
+}
+
+func endSpan(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,198 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/sidmal/webmoney"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type TracingTestSuite struct {
+	suite.Suite
+	recorder    *tracetest.SpanRecorder
+	interceptor *Interceptor
+}
+
+func Test_Tracing(t *testing.T) {
+	suite.Run(t, new(TracingTestSuite))
+}
+
+func (suite *TracingTestSuite) SetupTest() {
+	suite.recorder = tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(suite.recorder))
+	suite.interceptor = NewInterceptor(TracerProvider(provider))
+}
+
+func (suite *TracingTestSuite) spans() map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+
+	for _, span := range suite.recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	return spans
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	out := make(map[attribute.Key]attribute.Value)
+
+	for _, kv := range span.Attributes() {
+		out[kv.Key] = kv.Value
+	}
+
+	return out
+}
+
+func (suite *TracingTestSuite) TestTracing_Intercept_TransferMoney_Ok() {
+	invocation := &webmoney.Invocation{
+		Interface: webmoney.InterfaceX2,
+		Request: &webmoney.TransferMoneyRequest{
+			TxnId:     1234567890,
+			PurseSrc:  "Z123456789012",
+			PurseDest: "Z098765432109",
+			Amount:    1000,
+			Desc:      "Secret description",
+			PCode:     "Secret code",
+		},
+	}
+	err := suite.interceptor.Intercept(context.Background(), invocation, func(ctx context.Context, _ *webmoney.Invocation) error {
+		clientTrace := webmoney.ContextClientTrace(ctx)
+		assert.NotNil(suite.T(), clientTrace)
+		clientTrace.RequestNumberAssigned("20200901000000123")
+		clientTrace.SignStart()
+		clientTrace.SignDone(nil)
+		clientTrace.RoundTripStart()
+		clientTrace.RoundTripDone(http.StatusOK, nil)
+		return nil
+	})
+	assert.NoError(suite.T(), err)
+
+	spans := suite.spans()
+	assert.Len(suite.T(), spans, 3)
+
+	parent, ok := spans["webmoney.X2"]
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), codes.Unset, parent.Status().Code)
+
+	parentAttributes := attributes(parent)
+	assert.Equal(suite.T(), "X2", parentAttributes[attributeInterface].AsString())
+	assert.Equal(suite.T(), "20200901000000123", parentAttributes[attributeRequestNumber].AsString())
+	assert.EqualValues(suite.T(), 0, parentAttributes[attributeRetval].AsInt64())
+	assert.Equal(suite.T(), "WMZ", parentAttributes[attributeCurrency].AsString())
+	assert.EqualValues(suite.T(), 1234567890, parentAttributes[attributeTxnId].AsInt64())
+
+	for _, kv := range parent.Attributes() {
+		value := kv.Value.Emit()
+		assert.NotContains(suite.T(), value, "Z123456789012")
+		assert.NotContains(suite.T(), value, "Z098765432109")
+		assert.False(suite.T(), strings.Contains(value, "Secret"))
+	}
+
+	for _, name := range []string{"webmoney.sign", "webmoney.http"} {
+		child, ok := spans[name]
+		assert.True(suite.T(), ok, name)
+		assert.Equal(suite.T(), parent.SpanContext().SpanID(), child.Parent().SpanID(), name)
+		assert.Equal(suite.T(), parent.SpanContext().TraceID(), child.SpanContext().TraceID(), name)
+	}
+
+	assert.EqualValues(suite.T(), http.StatusOK, attributes(spans["webmoney.http"])[attributeHttpStatusCode].AsInt64())
+}
+
+func (suite *TracingTestSuite) TestTracing_Intercept_ResponseError() {
+	invocation := &webmoney.Invocation{
+		Interface: webmoney.InterfaceX3,
+		Request:   &webmoney.GetTransactionsHistoryRequest{Purse: "E123456789012", TxnId: 10},
+	}
+	rspErr := &webmoney.ResponseError{Code: -100, Reason: "Mock error"}
+	err := suite.interceptor.Intercept(context.Background(), invocation, func(ctx context.Context, _ *webmoney.Invocation) error {
+		clientTrace := webmoney.ContextClientTrace(ctx)
+		clientTrace.SignStart()
+		clientTrace.SignDone(nil)
+		clientTrace.RoundTripStart()
+		clientTrace.RoundTripDone(http.StatusOK, nil)
+		return rspErr
+	})
+	assert.Equal(suite.T(), rspErr, err)
+
+	spans := suite.spans()
+	parent := spans["webmoney.X3"]
+	assert.Equal(suite.T(), codes.Error, parent.Status().Code)
+	assert.Equal(suite.T(), "retval -100", parent.Status().Description)
+	assert.Empty(suite.T(), parent.Events())
+
+	parentAttributes := attributes(parent)
+	assert.EqualValues(suite.T(), -100, parentAttributes[attributeRetval].AsInt64())
+	assert.Equal(suite.T(), "WME", parentAttributes[attributeCurrency].AsString())
+	assert.EqualValues(suite.T(), 10, parentAttributes[attributeTxnId].AsInt64())
+	assert.Equal(suite.T(), codes.Unset, spans["webmoney.http"].Status().Code)
+}
+
+func (suite *TracingTestSuite) TestTracing_Intercept_TransportError() {
+	invocation := &webmoney.Invocation{
+		Interface: webmoney.InterfaceX9,
+		Request:   &webmoney.GetBalanceRequest{Wmid: "405002833238"},
+	}
+	transportErr := errors.New("TransportStatusError")
+	err := suite.interceptor.Intercept(context.Background(), invocation, func(ctx context.Context, _ *webmoney.Invocation) error {
+		clientTrace := webmoney.ContextClientTrace(ctx)
+		clientTrace.SignStart()
+		clientTrace.SignDone(nil)
+		clientTrace.RoundTripStart()
+		clientTrace.RoundTripDone(0, transportErr)
+		return transportErr
+	})
+	assert.Equal(suite.T(), transportErr, err)
+
+	spans := suite.spans()
+	assert.Equal(suite.T(), codes.Error, spans["webmoney.X9"].Status().Code)
+	assert.Equal(suite.T(), codes.Error, spans["webmoney.http"].Status().Code)
+	assert.Equal(suite.T(), codes.Unset, spans["webmoney.sign"].Status().Code)
+
+	_, ok := attributes(spans["webmoney.X9"])[attributeRetval]
+	assert.False(suite.T(), ok)
+
+	for _, kv := range spans["webmoney.X9"].Attributes() {
+		assert.NotContains(suite.T(), kv.Value.Emit(), "405002833238")
+	}
+}
+
+func (suite *TracingTestSuite) TestTracing_Intercept_SignError() {
+	invocation := &webmoney.Invocation{Interface: webmoney.InterfaceX9}
+	signErr := errors.New("SignError")
+	err := suite.interceptor.Intercept(context.Background(), invocation, func(ctx context.Context, _ *webmoney.Invocation) error {
+		clientTrace := webmoney.ContextClientTrace(ctx)
+		clientTrace.SignStart()
+		clientTrace.SignDone(signErr)
+		return signErr
+	})
+	assert.Equal(suite.T(), signErr, err)
+
+	spans := suite.spans()
+	assert.Len(suite.T(), spans, 2)
+	assert.Equal(suite.T(), codes.Error, spans["webmoney.sign"].Status().Code)
+}
+
+func (suite *TracingTestSuite) TestTracing_Intercept_HttpStatusError() {
+	invocation := &webmoney.Invocation{Interface: webmoney.InterfaceX9}
+	_ = suite.interceptor.Intercept(context.Background(), invocation, func(ctx context.Context, _ *webmoney.Invocation) error {
+		clientTrace := webmoney.ContextClientTrace(ctx)
+		clientTrace.RoundTripStart()
+		clientTrace.RoundTripDone(http.StatusBadGateway, nil)
+		return errors.New("EOF")
+	})
+
+	assert.Equal(suite.T(), codes.Error, suite.spans()["webmoney.http"].Status().Code)
+}
+
+func TestTracing_NewInterceptor_GlobalProvider_Ok(t *testing.T) {
+	interceptor := NewInterceptor()
+	assert.NotNil(t, interceptor.tracer)
+}
@@ -0,0 +1,159 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/sidmal/webmoney"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Checkpoint is the payments of the purse delivered by the watcher
+type Checkpoint struct {
+	// The last delivered payment
+	OperationId string    `json:"operation_id"`
+	Date        time.Time `json:"date"`
+	// The operation ids of the payments delivered within the lookback window with their dates
+	Delivered map[string]time.Time `json:"delivered,omitempty"`
+	// The operation ids of the protected payments not completed yet with their dates
+	Pending map[string]time.Time `json:"pending,omitempty"`
+}
+
+// CheckpointStore keeps the checkpoints of purses between the watcher runs. Load returns nil checkpoint
+// for the purse without checkpoint
+type CheckpointStore interface {
+	Load(ctx context.Context, purse webmoney.Purse) (*Checkpoint, error)
+	Save(ctx context.Context, purse webmoney.Purse, checkpoint *Checkpoint) error
+}
+
+// MemoryCheckpointStore keeps the checkpoints in memory, they are lost on restart
+type MemoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[webmoney.Purse]Checkpoint
+}
+
+// FileCheckpointStore keeps the checkpoints of all purses in JSON file, the file is replaced atomically on save
+type FileCheckpointStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewMemoryCheckpointStore() CheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: make(map[webmoney.Purse]Checkpoint)}
+}
+
+func NewFileCheckpointStore(path string) CheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+func (m *MemoryCheckpointStore) Load(_ context.Context, purse webmoney.Purse) (*Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	checkpoint, ok := m.checkpoints[purse]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return &checkpoint, nil
+}
+
+func (m *MemoryCheckpointStore) Save(_ context.Context, purse webmoney.Purse, checkpoint *Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saved := *checkpoint
+	saved.Delivered = copyDates(checkpoint.Delivered)
+	saved.Pending = copyDates(checkpoint.Pending)
+	m.checkpoints[purse] = saved
+
+	return nil
+}
+
+func (m *FileCheckpointStore) Load(_ context.Context, purse webmoney.Purse) (*Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	checkpoints, err := m.read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return checkpoints[purse], nil
+}
+
+func (m *FileCheckpointStore) Save(_ context.Context, purse webmoney.Purse, checkpoint *Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	checkpoints, err := m.read()
+
+	if err != nil {
+		return err
+	}
+
+	checkpoints[purse] = checkpoint
+	b, err := json.MarshalIndent(checkpoints, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(m.path), filepath.Base(m.path)+".*")
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err = tmp.Write(b); err == nil {
+		err = tmp.Sync()
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), m.path)
+}
+
+func (m *FileCheckpointStore) read() (map[webmoney.Purse]*Checkpoint, error) {
+	checkpoints := make(map[webmoney.Purse]*Checkpoint)
+	b, err := ioutil.ReadFile(m.path)
+
+	if os.IsNotExist(err) {
+		return checkpoints, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(b, &checkpoints); err != nil {
+		return nil, err
+	}
+
+	return checkpoints, nil
+}
+
+func copyDates(dates map[string]time.Time) map[string]time.Time {
+	out := make(map[string]time.Time, len(dates))
+
+	for id, date := range dates {
+		out[id] = date
+	}
+
+	return out
+}
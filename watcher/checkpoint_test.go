@@ -0,0 +1,75 @@
+package watcher
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher_FileCheckpointStore_Ok(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+	store := NewFileCheckpointStore(path)
+	date := time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	checkpoint, err := store.Load(context.Background(), TestPurse)
+	assert.NoError(t, err)
+	assert.Nil(t, checkpoint)
+
+	assert.NoError(t, store.Save(context.Background(), TestPurse, &Checkpoint{OperationId: "10", Date: date}))
+	assert.NoError(t, store.Save(context.Background(), TestPayerPurse, &Checkpoint{OperationId: "11", Date: date}))
+	checkpoint = &Checkpoint{
+		OperationId: "12",
+		Date:        date,
+		Delivered:   map[string]time.Time{"10": date, "12": date},
+		Pending:     map[string]time.Time{"11": date},
+	}
+	assert.NoError(t, store.Save(context.Background(), TestPurse, checkpoint))
+
+	loaded, err := NewFileCheckpointStore(path).Load(context.Background(), TestPurse)
+	assert.NoError(t, err)
+	assert.Equal(t, checkpoint, loaded)
+
+	checkpoint, err = NewFileCheckpointStore(path).Load(context.Background(), TestPayerPurse)
+	assert.NoError(t, err)
+	assert.Equal(t, "11", checkpoint.OperationId)
+
+	files, err := ioutil.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestWatcher_FileCheckpointStore_Error(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("broken"), 0600))
+
+	store := NewFileCheckpointStore(path)
+	_, err := store.Load(context.Background(), TestPurse)
+	assert.Error(t, err)
+	assert.Error(t, store.Save(context.Background(), TestPurse, &Checkpoint{}))
+
+	store = NewFileCheckpointStore(filepath.Join(t.TempDir(), "unknown", "checkpoints.json"))
+	assert.Error(t, store.Save(context.Background(), TestPurse, &Checkpoint{}))
+}
+
+func TestWatcher_MemoryCheckpointStore_Ok(t *testing.T) {
+	store := NewMemoryCheckpointStore()
+	checkpoint := &Checkpoint{
+		OperationId: "10",
+		Delivered:   map[string]time.Time{"10": {}},
+		Pending:     map[string]time.Time{"9": {}},
+	}
+	assert.NoError(t, store.Save(context.Background(), TestPurse, checkpoint))
+
+	// The saved checkpoint is copied
+	checkpoint.OperationId = "11"
+	checkpoint.Delivered["11"] = time.Time{}
+	delete(checkpoint.Pending, "9")
+	loaded, err := store.Load(context.Background(), TestPurse)
+	assert.NoError(t, err)
+	assert.Equal(t, "10", loaded.OperationId)
+	assert.Len(t, loaded.Delivered, 1)
+	assert.Len(t, loaded.Pending, 1)
+}
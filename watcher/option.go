@@ -0,0 +1,68 @@
+package watcher
+
+import (
+	"github.com/sidmal/webmoney"
+	"go.uber.org/zap"
+	"time"
+)
+
+type Options struct {
+	// The purses watched for incoming payments
+	purses []webmoney.Purse
+	// The interval between polls
+	interval time.Duration
+	// The period checked on each poll, the operations appeared in the history later than it are not delivered,
+	// the protected payments found in it are checked until they are completed
+	lookback time.Duration
+	// The store of the delivered payments of purses
+	store CheckpointStore
+	// The handler of incoming payments
+	handler Handler
+	// The logger
+	logger *zap.Logger
+}
+
+type Option func(*Options)
+
+func Purses(val ...webmoney.Purse) Option {
+	return func(opts *Options) {
+		opts.purses = append(opts.purses, val...)
+	}
+}
+
+func Interval(val time.Duration) Option {
+	return func(opts *Options) {
+		opts.interval = val
+	}
+}
+
+func Lookback(val time.Duration) Option {
+	return func(opts *Options) {
+		opts.lookback = val
+	}
+}
+
+func Store(val CheckpointStore) Option {
+	return func(opts *Options) {
+		opts.store = val
+	}
+}
+
+func OnPayment(val Handler) Option {
+	return func(opts *Options) {
+		opts.handler = val
+	}
+}
+
+// Channel sends incoming payments to the channel, the payment is delivered when the channel receives it
+func Channel(val chan<- *IncomingPayment) Option {
+	return func(opts *Options) {
+		opts.handler = ChannelHandler(val)
+	}
+}
+
+func Logger(val *zap.Logger) Option {
+	return func(opts *Options) {
+		opts.logger = val
+	}
+}
@@ -0,0 +1,43 @@
+package watcher
+
+import (
+	"context"
+	"github.com/sidmal/webmoney"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestWatcherOptions_Setters(t *testing.T) {
+	store := NewMemoryCheckpointStore()
+	logger := zap.NewNop()
+	handler := func(ctx context.Context, payment *IncomingPayment) error {
+		return nil
+	}
+
+	opts := []Option{
+		Purses(TestPurse),
+		Purses(TestPayerPurse),
+		Interval(time.Second),
+		Lookback(time.Hour),
+		Store(store),
+		OnPayment(handler),
+		Logger(logger),
+	}
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	assert.Equal(t, []webmoney.Purse{TestPurse, TestPayerPurse}, options.purses)
+	assert.Equal(t, time.Second, options.interval)
+	assert.Equal(t, time.Hour, options.lookback)
+	assert.Equal(t, store, options.store)
+	assert.NotNil(t, options.handler)
+	assert.Equal(t, logger, options.logger)
+
+	Channel(make(chan *IncomingPayment))(options)
+	assert.NotNil(t, options.handler)
+}
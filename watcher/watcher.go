@@ -0,0 +1,306 @@
+// Package watcher detects incoming payments to WebMoney purses by polling the transactions history
+package watcher
+
+import (
+	"context"
+	"errors"
+	"github.com/sidmal/webmoney"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	defaultInterval = time.Minute
+	defaultLookback = 24 * time.Hour
+
+	// The opertype of the completed transfer, the protected transfer is not completed until the code is entered
+	// or the protection period is over
+	operationTypeCompleted = "0"
+	// The opertype of the protected transfer returned to the payer, it is never completed
+	operationTypeReturned = "12"
+
+	// The margin of the period of X3 request of the pending payment around its creation date
+	pendingLookupMargin = time.Minute
+)
+
+var (
+	ErrorPursesNotConfigured  = errors.New("the purses to watch are not configured")
+	ErrorHandlerNotConfigured = errors.New("the handler of incoming payments is not configured")
+)
+
+// IncomingPayment is the operation transferring money to the watched purse
+type IncomingPayment struct {
+	// The watched purse receiving the payment
+	Purse       webmoney.Purse
+	OperationId string
+	TxnId       int64
+	PurseSrc    webmoney.Purse
+	// The WMID of the payer
+	CorrWm  string
+	Amount  webmoney.Amount
+	Desc    string
+	WmInvId int
+	Date    time.Time
+	// The operation as it is returned by WebMoney
+	Operation *webmoney.TransferMoneyResponse
+}
+
+// Handler processes the incoming payment. The payment is delivered again if the handler returns an error
+// or the watcher stops before the checkpoint is saved, so the handler must be idempotent by the operation id
+type Handler func(ctx context.Context, payment *IncomingPayment) error
+
+// Watcher polls the transactions history of purses and delivers the completed incoming payments to the handler
+// at least once. The payments delivered within the lookback window and the protected payments not completed yet
+// are kept in the checkpoint store
+type Watcher struct {
+	client  webmoney.XMLInterface
+	options *Options
+	now     func() time.Time
+}
+
+// ChannelHandler returns the handler sending the payments to the channel, it waits for the receiver
+// until the context is done
+func ChannelHandler(ch chan<- *IncomingPayment) Handler {
+	return func(ctx context.Context, payment *IncomingPayment) error {
+		select {
+		case ch <- payment:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func NewWatcher(client webmoney.XMLInterface, opts ...Option) (*Watcher, error) {
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if len(options.purses) == 0 {
+		return nil, ErrorPursesNotConfigured
+	}
+
+	for _, purse := range options.purses {
+		if err := purse.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.handler == nil {
+		return nil, ErrorHandlerNotConfigured
+	}
+
+	if options.interval <= 0 {
+		options.interval = defaultInterval
+	}
+
+	if options.lookback <= 0 {
+		options.lookback = defaultLookback
+	}
+
+	if options.store == nil {
+		options.store = NewMemoryCheckpointStore()
+	}
+
+	if options.logger == nil {
+		options.logger = zap.NewNop()
+	}
+
+	return &Watcher{client: client, options: options, now: time.Now}, nil
+}
+
+// Run polls the purses with the interval until the context is done. The poll errors are logged
+// and the failed purses are polled again on the next tick. It returns nil on the graceful shutdown
+func (m *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.options.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Poll(ctx); err != nil && ctx.Err() == nil {
+			m.options.logger.Warn("webmoney incoming payments poll failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll delivers the incoming payments of all purses since their checkpoints once, e.g. to run it from cron.
+// It returns the first error, other purses are polled anyway
+func (m *Watcher) Poll(ctx context.Context) error {
+	var firstErr error
+
+	for _, purse := range m.options.purses {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := m.poll(ctx, purse); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// poll requests the operations of the lookback window on each poll and skips the delivered ones by the operation
+// id, so the operations appeared in the history after the later ones are delivered too. The protected payments
+// left the window before they are completed are requested by the operation id until they are completed or returned
+func (m *Watcher) poll(ctx context.Context, purse webmoney.Purse) error {
+	checkpoint, err := m.options.store.Load(ctx, purse)
+
+	if err != nil {
+		return err
+	}
+
+	now := m.now()
+	from := now.Add(-m.options.lookback)
+	state := &pollState{purse: purse, checkpoint: &Checkpoint{}, delivered: make(map[string]time.Time)}
+
+	if checkpoint != nil {
+		state.checkpoint = checkpoint
+
+		for id, date := range checkpoint.Delivered {
+			if !date.Before(from) {
+				state.delivered[id] = date
+			}
+		}
+	}
+
+	state.pending = make(map[string]time.Time, len(state.checkpoint.Pending))
+
+	for id, date := range state.checkpoint.Pending {
+		state.pending[id] = date
+	}
+
+	cursor := webmoney.IterateTransactions(ctx, m.client, purse, from, now)
+
+	for cursor.Next() {
+		operation := cursor.Operation()
+
+		if operation.PurseDest != purse {
+			continue
+		}
+
+		if err = m.handle(ctx, state, operation); err != nil {
+			return err
+		}
+	}
+
+	if err = cursor.Err(); err != nil {
+		return err
+	}
+
+	for id, date := range state.pending {
+		if !date.Before(from) {
+			continue
+		}
+
+		operation, err := m.pendingOperation(purse, id, date)
+
+		if err != nil {
+			return err
+		}
+
+		if operation == nil {
+			continue
+		}
+
+		if err = m.handle(ctx, state, operation); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pollState is the checkpoint of the purse updated by the poll
+type pollState struct {
+	purse      webmoney.Purse
+	checkpoint *Checkpoint
+	delivered  map[string]time.Time
+	pending    map[string]time.Time
+}
+
+// handle delivers the completed payment, the protected payment is kept pending until it is completed or returned
+func (m *Watcher) handle(ctx context.Context, state *pollState, operation *webmoney.TransferMoneyResponse) error {
+	if _, ok := state.delivered[operation.Id]; ok {
+		return nil
+	}
+
+	_, pending := state.pending[operation.Id]
+
+	switch operation.OperationType {
+	case operationTypeCompleted:
+		if err := m.options.handler(ctx, newIncomingPayment(state.purse, operation)); err != nil {
+			return err
+		}
+
+		delete(state.pending, operation.Id)
+		state.delivered[operation.Id] = operation.DateCrt.Time
+		state.checkpoint.OperationId, state.checkpoint.Date = operation.Id, operation.DateCrt.Time
+	case operationTypeReturned:
+		if !pending {
+			return nil
+		}
+
+		delete(state.pending, operation.Id)
+	default:
+		if pending {
+			return nil
+		}
+
+		state.pending[operation.Id] = operation.DateCrt.Time
+	}
+
+	state.checkpoint.Delivered, state.checkpoint.Pending = state.delivered, state.pending
+
+	return m.options.store.Save(ctx, state.purse, state.checkpoint)
+}
+
+// pendingOperation requests the pending payment by the operation id, it returns nil if the operation is not found
+func (m *Watcher) pendingOperation(
+	purse webmoney.Purse,
+	id string,
+	date time.Time,
+) (*webmoney.TransferMoneyResponse, error) {
+	req := &webmoney.GetTransactionsHistoryRequest{
+		Purse:      purse,
+		WmTranId:   id,
+		DateStart:  webmoney.NewWMTime(date.Add(-pendingLookupMargin)),
+		DateFinish: webmoney.NewWMTime(date.Add(pendingLookupMargin)),
+	}
+	rsp, err := m.client.GetTransactionsHistory(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, operation := range rsp.OperationList {
+		if operation.Id == id && operation.PurseDest == purse {
+			return operation, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func newIncomingPayment(purse webmoney.Purse, operation *webmoney.TransferMoneyResponse) *IncomingPayment {
+	return &IncomingPayment{
+		Purse:       purse,
+		OperationId: operation.Id,
+		TxnId:       operation.TxnId,
+		PurseSrc:    operation.PurseSrc,
+		CorrWm:      operation.CorrWm,
+		Amount:      operation.Amount,
+		Desc:        operation.Desc,
+		WmInvId:     operation.WmInvId,
+		Date:        operation.DateCrt.Time,
+		Operation:   operation,
+	}
+}
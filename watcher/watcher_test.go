@@ -0,0 +1,371 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"github.com/sidmal/webmoney"
+	"github.com/sidmal/webmoney/signer"
+	"github.com/sidmal/webmoney/wmtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	TestWmId       = "405002833238"
+	TestPassword   = "FvGqPdAy8reVWw789"
+	TestPurse      = webmoney.Purse("Z405002833238")
+	TestPayerWmId  = "123456789012"
+	TestPayerPurse = webmoney.Purse("Z123456789012")
+)
+
+// historyClient returns the history with the hidden operations missing and the operation types replaced,
+// as the history of the operations appeared late or not completed yet
+type historyClient struct {
+	webmoney.XMLInterface
+	hidden   map[string]bool
+	types    map[string]string
+	requests []*webmoney.GetTransactionsHistoryRequest
+}
+
+func (m *historyClient) GetTransactionsHistory(
+	in *webmoney.GetTransactionsHistoryRequest,
+) (*webmoney.GetTransactionsHistoryResponse, error) {
+	m.requests = append(m.requests, in)
+	out, err := m.XMLInterface.GetTransactionsHistory(in)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var operations []*webmoney.TransferMoneyResponse
+
+	for _, operation := range out.OperationList {
+		if m.hidden[operation.Id] {
+			continue
+		}
+
+		if operationType, ok := m.types[operation.Id]; ok {
+			operation.OperationType = operationType
+		}
+
+		operations = append(operations, operation)
+	}
+
+	out.OperationList = operations
+
+	return out, nil
+}
+
+type WatcherTestSuite struct {
+	suite.Suite
+	server *wmtest.Server
+	client webmoney.XMLInterface
+	payer  webmoney.XMLInterface
+	txnId  int
+
+	mu       sync.Mutex
+	payments []*IncomingPayment
+}
+
+func Test_Watcher(t *testing.T) {
+	suite.Run(t, new(WatcherTestSuite))
+}
+
+func (suite *WatcherTestSuite) newClient(wmId string) webmoney.XMLInterface {
+	key, _, err := signer.GenerateKey(nil, wmId, TestPassword)
+
+	if err != nil {
+		suite.FailNow("Key generation failed", "%v", err)
+	}
+
+	opts := []webmoney.Option{
+		webmoney.WmId(wmId),
+		webmoney.KeyBytes(key),
+		webmoney.Password(TestPassword),
+		webmoney.Endpoint(suite.server.URL),
+	}
+	client, err := webmoney.NewWebMoney(opts...)
+
+	if err != nil {
+		suite.FailNow("WebMoney client initialization failed", "%v", err)
+	}
+
+	return client
+}
+
+func (suite *WatcherTestSuite) SetupTest() {
+	suite.server = wmtest.NewServer()
+	suite.server.AddPurse(TestWmId, TestPurse, 10000)
+	suite.server.AddPurse(TestPayerWmId, TestPayerPurse, 10000)
+	suite.client = suite.newClient(TestWmId)
+	suite.payer = suite.newClient(TestPayerWmId)
+	suite.payments = nil
+	suite.txnId = 0
+}
+
+func (suite *WatcherTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *WatcherTestSuite) handler(_ context.Context, payment *IncomingPayment) error {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+
+	suite.payments = append(suite.payments, payment)
+
+	return nil
+}
+
+func (suite *WatcherTestSuite) transfer(client webmoney.XMLInterface, src, dest webmoney.Purse, amount webmoney.Amount) {
+	suite.txnId++
+	in := &webmoney.TransferMoneyRequest{TxnId: suite.txnId, PurseSrc: src, PurseDest: dest, Amount: amount, Desc: "Order"}
+
+	if _, err := client.TransferMoney(in); err != nil {
+		suite.FailNow("Transfer failed", "%v", err)
+	}
+}
+
+func (suite *WatcherTestSuite) TestWatcher_Poll_Ok() {
+	store := NewMemoryCheckpointStore()
+	watcher, err := NewWatcher(suite.client, Purses(TestPurse), Store(store), OnPayment(suite.handler))
+	assert.NoError(suite.T(), err)
+
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 100)
+	suite.transfer(suite.client, TestPurse, TestPayerPurse, 50)
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 200)
+
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Len(suite.T(), suite.payments, 2)
+	assert.Equal(suite.T(), TestPurse, suite.payments[0].Purse)
+	assert.Equal(suite.T(), TestPayerPurse, suite.payments[0].PurseSrc)
+	assert.Equal(suite.T(), TestPayerWmId, suite.payments[0].CorrWm)
+	assert.EqualValues(suite.T(), 100, suite.payments[0].Amount)
+	assert.EqualValues(suite.T(), 1, suite.payments[0].TxnId)
+	assert.Equal(suite.T(), "Order", suite.payments[0].Desc)
+	assert.EqualValues(suite.T(), 200, suite.payments[1].Amount)
+
+	checkpoint, err := store.Load(context.Background(), TestPurse)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.payments[1].OperationId, checkpoint.OperationId)
+
+	// The payments of the same second are delivered once
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 300)
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Len(suite.T(), suite.payments, 3)
+	assert.EqualValues(suite.T(), 300, suite.payments[2].Amount)
+
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Len(suite.T(), suite.payments, 3)
+}
+
+func (suite *WatcherTestSuite) TestWatcher_Poll_LateOperation_Ok() {
+	client := &historyClient{XMLInterface: suite.client, hidden: map[string]bool{"1": true}}
+	watcher, err := NewWatcher(client, Purses(TestPurse), OnPayment(suite.handler))
+	assert.NoError(suite.T(), err)
+
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 100)
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 200)
+
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Len(suite.T(), suite.payments, 1)
+	assert.Equal(suite.T(), "2", suite.payments[0].OperationId)
+
+	// The operation appeared after the later one is delivered by the operation id, not by the order
+	client.hidden = nil
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Len(suite.T(), suite.payments, 2)
+	assert.Equal(suite.T(), "1", suite.payments[1].OperationId)
+
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Len(suite.T(), suite.payments, 2)
+}
+
+func (suite *WatcherTestSuite) TestWatcher_Poll_NotCompleted_Skipped() {
+	client := &historyClient{XMLInterface: suite.client, types: map[string]string{"1": "4"}}
+	store := NewMemoryCheckpointStore()
+	watcher, err := NewWatcher(client, Purses(TestPurse), Store(store), OnPayment(suite.handler))
+	assert.NoError(suite.T(), err)
+
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 100)
+
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Empty(suite.T(), suite.payments)
+
+	// The protected transfer is delivered once it is completed
+	client.types = nil
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Len(suite.T(), suite.payments, 1)
+	assert.Equal(suite.T(), "0", suite.payments[0].Operation.OperationType)
+
+	checkpoint, err := store.Load(context.Background(), TestPurse)
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), checkpoint.Delivered, "1")
+	assert.Empty(suite.T(), checkpoint.Pending)
+}
+
+func (suite *WatcherTestSuite) TestWatcher_Poll_ProtectedOutOfLookback_Delivered() {
+	client := &historyClient{XMLInterface: suite.client, types: map[string]string{"1": "4", "2": "4", "3": "4"}}
+	store := NewMemoryCheckpointStore()
+	watcher, err := NewWatcher(client, Purses(TestPurse), Store(store), OnPayment(suite.handler))
+	assert.NoError(suite.T(), err)
+
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 100)
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 200)
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 300)
+
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Empty(suite.T(), suite.payments)
+
+	checkpoint, err := store.Load(context.Background(), TestPurse)
+	assert.NoError(suite.T(), err)
+	assert.ElementsMatch(suite.T(), []string{"1", "2", "3"}, mapKeys(checkpoint.Pending))
+
+	// The payments are completed, returned or still protected after the lookback window is over
+	now := time.Now().Add(defaultLookback + time.Hour)
+	watcher.now = func() time.Time {
+		return now
+	}
+	client.types = map[string]string{"2": "12", "3": "4"}
+	client.requests = nil
+
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Len(suite.T(), suite.payments, 1)
+	assert.Equal(suite.T(), "1", suite.payments[0].OperationId)
+	assert.Len(suite.T(), client.requests, 4)
+
+	checkpoint, err = store.Load(context.Background(), TestPurse)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"3"}, mapKeys(checkpoint.Pending))
+	assert.Equal(suite.T(), "1", checkpoint.OperationId)
+
+	// The delivered payment is not requested again, the protected one is requested until it is completed
+	client.types = nil
+	client.requests = nil
+
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Len(suite.T(), suite.payments, 2)
+	assert.Equal(suite.T(), "3", suite.payments[1].OperationId)
+	assert.Len(suite.T(), client.requests, 2)
+	assert.Equal(suite.T(), "3", client.requests[1].WmTranId)
+
+	checkpoint, err = store.Load(context.Background(), TestPurse)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), checkpoint.Pending)
+
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Len(suite.T(), suite.payments, 2)
+}
+
+func (suite *WatcherTestSuite) TestWatcher_Poll_DeliveredOutOfLookback_Dropped() {
+	store := NewMemoryCheckpointStore()
+	watcher, err := NewWatcher(suite.client, Purses(TestPurse), Store(store), OnPayment(suite.handler))
+	assert.NoError(suite.T(), err)
+
+	old := time.Now().Add(-48 * time.Hour)
+	checkpoint := &Checkpoint{OperationId: "100", Date: old, Delivered: map[string]time.Time{"100": old}}
+	assert.NoError(suite.T(), store.Save(context.Background(), TestPurse, checkpoint))
+
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 100)
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Len(suite.T(), suite.payments, 1)
+
+	checkpoint, err = store.Load(context.Background(), TestPurse)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"1"}, mapKeys(checkpoint.Delivered))
+}
+
+func (suite *WatcherTestSuite) TestWatcher_Poll_HandlerError_Redelivered() {
+	fail := true
+	handler := func(ctx context.Context, payment *IncomingPayment) error {
+		if fail && payment.Amount == 200 {
+			return errors.New("handler failed")
+		}
+
+		return suite.handler(ctx, payment)
+	}
+	watcher, err := NewWatcher(suite.client, Purses(TestPurse), OnPayment(handler))
+	assert.NoError(suite.T(), err)
+
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 100)
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 200)
+
+	assert.EqualError(suite.T(), watcher.Poll(context.Background()), "handler failed")
+	assert.Len(suite.T(), suite.payments, 1)
+
+	fail = false
+	assert.NoError(suite.T(), watcher.Poll(context.Background()))
+	assert.Len(suite.T(), suite.payments, 2)
+	assert.EqualValues(suite.T(), 200, suite.payments[1].Amount)
+}
+
+func (suite *WatcherTestSuite) TestWatcher_Run_Channel_Ok() {
+	ch := make(chan *IncomingPayment)
+	watcher, err := NewWatcher(suite.client, Purses(TestPurse), Interval(10*time.Millisecond), Channel(ch))
+	assert.NoError(suite.T(), err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() {
+		done <- watcher.Run(ctx)
+	}()
+
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 100)
+
+	select {
+	case payment := <-ch:
+		assert.EqualValues(suite.T(), 100, payment.Amount)
+	case <-time.After(5 * time.Second):
+		suite.Fail("The payment is not delivered")
+	}
+
+	cancel()
+
+	select {
+	case err = <-done:
+		assert.NoError(suite.T(), err)
+	case <-time.After(5 * time.Second):
+		suite.Fail("The watcher is not stopped")
+	}
+}
+
+func (suite *WatcherTestSuite) TestWatcher_Poll_Error() {
+	watcher, err := NewWatcher(suite.client, Purses("Z999999999999", TestPurse), OnPayment(suite.handler))
+	assert.NoError(suite.T(), err)
+
+	suite.transfer(suite.payer, TestPayerPurse, TestPurse, 100)
+
+	// The purse error does not stop the poll of other purses
+	var rspErr *webmoney.ResponseError
+	assert.True(suite.T(), errors.As(watcher.Poll(context.Background()), &rspErr))
+	assert.Len(suite.T(), suite.payments, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(suite.T(), context.Canceled, watcher.Poll(ctx))
+	assert.Equal(suite.T(), context.Canceled, ChannelHandler(make(chan *IncomingPayment))(ctx, &IncomingPayment{}))
+}
+
+func (suite *WatcherTestSuite) TestWatcher_NewWatcher_Error() {
+	_, err := NewWatcher(suite.client, OnPayment(suite.handler))
+	assert.Equal(suite.T(), ErrorPursesNotConfigured, err)
+
+	_, err = NewWatcher(suite.client, Purses("purse"), OnPayment(suite.handler))
+	assert.Equal(suite.T(), webmoney.ErrorPurseIsIncorrect, err)
+
+	_, err = NewWatcher(suite.client, Purses(TestPurse))
+	assert.Equal(suite.T(), ErrorHandlerNotConfigured, err)
+}
+
+func mapKeys(m map[string]time.Time) []string {
+	var keys []string
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
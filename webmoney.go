@@ -2,6 +2,7 @@ package webmoney
 
 import (
 	"bytes"
+	"context"
 	"crypto/x509"
 	"encoding/xml"
 	"errors"
@@ -13,6 +14,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,7 +23,18 @@ const (
 	operationGetTransactionsHistory = "Operations"
 	operationGetBalance             = "Purses"
 
-	apiUrlMask = "https://w3s.webmoney.ru/asp/XML%s.asp"
+	apiUrlMask = "%s/asp/XML%s.asp"
+
+	defaultEndpoint = "https://w3s.webmoney.ru"
+
+	defaultHistoryWindow = 90 * 24 * time.Hour
+)
+
+var (
+	ErrorAmountIsIncorrect     = errors.New("the amount is incorrect")
+	ErrorPurseIsIncorrect      = errors.New("the purse is incorrect")
+	ErrorPurseCurrencyUnknown  = errors.New("the purse currency is unknown")
+	ErrorPurseCurrencyMismatch = errors.New("the source and destination purses have different currencies")
 )
 
 type XMLInterface interface {
@@ -31,11 +44,15 @@ type XMLInterface interface {
 }
 
 type WebMoney struct {
-	options     *Options
-	signer      signer.WebMoneySignerInterface
-	marshalFn   func(v interface{}) ([]byte, error)
-	unMarshalFn func(data []byte, v interface{}) error
-	httpClient  *http.Client
+	options      *Options
+	interceptors []Interceptor
+	signer       signer.WebMoneySignerInterface
+	marshalFn    func(v interface{}) ([]byte, error)
+	unMarshalFn  func(data []byte, v interface{}) error
+	httpClient   *http.Client
+
+	requestNumberMu   sync.Mutex
+	lastRequestNumber int64
 }
 
 type BaseRequest struct {
@@ -45,6 +62,8 @@ type BaseRequest struct {
 	Signature       string      `xml:"sign"`
 	Request         interface{} `xml:",>"`
 	SignatureString string      `xml:"-"`
+	// The specification of the signature string built when the request number is assigned
+	signatureSpec *SignatureSpec
 }
 
 type BaseResponse struct {
@@ -56,12 +75,18 @@ type BaseResponse struct {
 	Response      interface{} `xml:",any"`
 }
 
+// ResponseError is the error returned by WebMoney XML interface with non zero retval code
+type ResponseError struct {
+	Code   int
+	Reason string
+}
+
 type TransferMoneyRequest struct {
 	XMLName   xml.Name `xml:"trans"`
 	TxnId     int      `xml:"tranid"`
-	PurseSrc  string   `xml:"pursesrc"`
-	PurseDest string   `xml:"pursedest"`
-	Amount    string   `xml:"amount"`
+	PurseSrc  Purse    `xml:"pursesrc"`
+	PurseDest Purse    `xml:"pursedest"`
+	Amount    Amount   `xml:"amount"`
 	Period    int      `xml:"period"`
 	Desc      string   `xml:"desc"`
 	PCode     string   `xml:"pcode"`
@@ -74,30 +99,30 @@ type TransferMoneyResponse struct {
 	Id            string   `xml:"id,attr"`
 	Ts            string   `xml:"ts,attr"`
 	TxnId         int64    `xml:"tranid"`
-	PurseSrc      string   `xml:"pursesrc"`
-	PurseDest     string   `xml:"pursedest"`
-	Amount        string   `xml:"amount"`
-	Commission    string   `xml:"comiss"`
+	PurseSrc      Purse    `xml:"pursesrc"`
+	PurseDest     Purse    `xml:"pursedest"`
+	Amount        Amount   `xml:"amount"`
+	Commission    Amount   `xml:"comiss"`
 	OperationType string   `xml:"opertype"`
 	Period        int      `xml:"period"`
 	WmInvId       int      `xml:"wminvid"`
 	Desc          string   `xml:"desc"`
-	DateCrt       string   `xml:"datecrt"`
-	DateUpd       string   `xml:"dateupd"`
+	DateCrt       WMTime   `xml:"datecrt"`
+	DateUpd       WMTime   `xml:"dateupd"`
 	CorrWm        string   `xml:"corrwm"`
-	Rest          string   `xml:"rest"`
+	Rest          Amount   `xml:"rest"`
 	TimeLock      bool     `xml:"timelock"`
 }
 
 type GetTransactionsHistoryRequest struct {
 	XMLName    xml.Name `xml:"getoperations"`
-	Purse      string   `xml:"purse"`
+	Purse      Purse    `xml:"purse"`
 	WmTranId   string   `xml:"wmtranid"`
 	TxnId      int64    `xml:"tranid"`
 	WmInvId    string   `xml:"wminvid"`
 	OrderId    string   `xml:"orderid"`
-	DateStart  string   `xml:"datestart"`
-	DateFinish string   `xml:"datefinish"`
+	DateStart  WMTime   `xml:"datestart"`
+	DateFinish WMTime   `xml:"datefinish"`
 }
 
 type GetTransactionsHistoryResponse struct {
@@ -119,8 +144,8 @@ type GetBalanceResponse struct {
 
 type GetBalanceResponsePurse struct {
 	XMLName          xml.Name `xml:"purse"`
-	PurseName        string   `xml:"pursename"`
-	Amount           float32  `xml:"amount"`
+	PurseName        Purse    `xml:"pursename"`
+	Amount           Amount   `xml:"amount"`
 	Desc             string   `xml:"desc"`
 	OutsideOpen      string   `xml:"outsideopen"`
 	LastIncomeTxmId  string   `xml:"lastintr"`
@@ -134,15 +159,24 @@ func NewWebMoney(opts ...Option) (XMLInterface, error) {
 		return nil, err
 	}
 
-	signerOpts := []signer.Option{
-		signer.WmId(options.wmId),
-		signer.Key(options.key),
-		signer.Password(options.password),
-	}
-	sig, err := signer.NewSigner(signerOpts...)
+	sig := options.signer
+
+	if sig == nil {
+		signerOpts := []signer.Option{
+			signer.WmId(options.wmId),
+			signer.Key(options.key),
+			signer.KeyBytes(options.keyBytes),
+			signer.KeyFile(options.keyFile),
+			signer.KeyReader(options.keyReader),
+			signer.Password(options.password),
+			signer.Provider(options.keyProvider),
+			signer.OnDemand(options.keyOnDemand),
+		}
+		sig, err = signer.NewSigner(signerOpts...)
 
-	if err != nil {
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	webmoney := &WebMoney{
@@ -152,10 +186,14 @@ func NewWebMoney(opts ...Option) (XMLInterface, error) {
 		unMarshalFn: func(data []byte, v interface{}) error {
 			decoder := xml.NewDecoder(bytes.NewReader(data))
 			decoder.CharsetReader = charset.NewReaderLabel
-			err = decoder.Decode(v)
-			return err
+			return decoder.Decode(v)
 		},
-		httpClient: options.httpClient,
+		httpClient:   options.httpClient,
+		interceptors: append([]Interceptor{}, options.interceptors...),
+	}
+
+	if options.metrics != nil {
+		webmoney.interceptors = append(webmoney.interceptors, options.metrics)
 	}
 
 	if options.rootCaReader == nil {
@@ -179,7 +217,7 @@ func NewWebMoney(opts ...Option) (XMLInterface, error) {
 
 		webmoney.httpClient = &http.Client{
 			Timeout:   10 * time.Second,
-			Transport: newHttpTransport(options.logger, options.logClearFn, rootCAs),
+			Transport: newHttpTransport(options, rootCAs),
 		}
 	}
 
@@ -201,32 +239,147 @@ func executeOptions(opts ...Option) (*Options, error) {
 		return nil, signer.ErrorWmIdIsIncorrect
 	}
 
-	if options.key == "" {
-		return nil, signer.ErrorKeyNotConfigured
+	if options.keyProvider == nil && options.signer == nil {
+		if options.key == "" && options.keyBytes == nil && options.keyFile == "" && options.keyReader == nil {
+			return nil, signer.ErrorKeyNotConfigured
+		}
+
+		if options.password == "" {
+			return nil, signer.ErrorPasswordNotConfigured
+		}
+	}
+
+	if options.endpoint == "" {
+		options.endpoint = defaultEndpoint
+	}
+
+	options.endpoint = strings.TrimRight(options.endpoint, "/")
+
+	if options.historyWindow <= 0 {
+		options.historyWindow = defaultHistoryWindow
+	}
+
+	if options.redactionRules == nil {
+		options.redactionRules = make(map[string]RedactMode)
+	}
+
+	for field, mode := range defaultRedactionRules {
+		if _, ok := options.redactionRules[field]; !ok {
+			options.redactionRules[field] = mode
+		}
 	}
 
-	if options.password == "" {
-		return nil, signer.ErrorPasswordNotConfigured
+	if options.logLevels == nil {
+		options.logLevels = make(map[LogEvent]LogLevel)
+	}
+
+	for event, level := range defaultLogLevels {
+		if _, ok := options.logLevels[event]; !ok {
+			options.logLevels[event] = level
+		}
 	}
 
 	return options, nil
 }
 
 func (m *WebMoney) TransferMoney(in *TransferMoneyRequest) (*TransferMoneyResponse, error) {
+	return m.transferMoney(context.Background(), in)
+}
+
+func (m *WebMoney) GetTransactionsHistory(in *GetTransactionsHistoryRequest) (*GetTransactionsHistoryResponse, error) {
+	return m.getTransactionsHistory(context.Background(), in)
+}
+
+func (m *WebMoney) GetBalance(in *GetBalanceRequest) (*GetBalanceResponse, error) {
+	return m.getBalance(context.Background(), in)
+}
+
+func (m *WebMoney) transferMoney(ctx context.Context, in *TransferMoneyRequest) (*TransferMoneyResponse, error) {
+	out, err := m.invoke(ctx, InterfaceX2, in, func(ctx context.Context) (interface{}, error) {
+		return m.doTransferMoney(ctx, in)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, ok := out.(*TransferMoneyResponse)
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrorInvocationResponseType, out)
+	}
+
+	return rsp, nil
+}
+
+func (m *WebMoney) getTransactionsHistory(
+	ctx context.Context,
+	in *GetTransactionsHistoryRequest,
+) (*GetTransactionsHistoryResponse, error) {
+	out, err := m.invoke(ctx, InterfaceX3, in, func(ctx context.Context) (interface{}, error) {
+		return m.doGetTransactionsHistory(ctx, in)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, ok := out.(*GetTransactionsHistoryResponse)
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrorInvocationResponseType, out)
+	}
+
+	return rsp, nil
+}
+
+func (m *WebMoney) getBalance(ctx context.Context, in *GetBalanceRequest) (*GetBalanceResponse, error) {
+	out, err := m.invoke(ctx, InterfaceX9, in, func(ctx context.Context) (interface{}, error) {
+		return m.doGetBalance(ctx, in)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, ok := out.(*GetBalanceResponse)
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrorInvocationResponseType, out)
+	}
+
+	return rsp, nil
+}
+
+func (m *WebMoney) doTransferMoney(ctx context.Context, in *TransferMoneyRequest) (*TransferMoneyResponse, error) {
+	if in.Amount <= 0 {
+		return nil, ErrorAmountIsIncorrect
+	}
+
+	if err := in.PurseSrc.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := in.PurseDest.Validate(); err != nil {
+		return nil, err
+	}
+
+	if in.PurseSrc.Currency() != in.PurseDest.Currency() {
+		return nil, ErrorPurseCurrencyMismatch
+	}
+
 	if in.Desc != "" {
 		in.Desc = m.Utf8ToWin(in.Desc)
 	}
 
 	req := &BaseRequest{
-		RequestNumber: m.getRequestNumber(),
 		WmId:          m.options.wmId,
 		Request:       in,
+		signatureSpec: signatureSpecs[InterfaceX2],
 	}
-	req.SignatureString = req.RequestNumber + strconv.Itoa(in.TxnId) + in.PurseSrc + in.PurseDest + in.Amount +
-		strconv.Itoa(in.Period) + in.PCode + in.Desc + strconv.Itoa(in.WmInvId)
 
-	url := fmt.Sprintf(apiUrlMask, operationTransferMoney)
-	result, err := m.sendRequest(url, req, new(TransferMoneyResponse))
+	url := m.getUrl(operationTransferMoney)
+	result, err := m.sendRequest(ctx, InterfaceX2, url, req, new(TransferMoneyResponse))
 
 	if err != nil {
 		return nil, err
@@ -235,16 +388,22 @@ func (m *WebMoney) TransferMoney(in *TransferMoneyRequest) (*TransferMoneyRespon
 	return result.Response.(*TransferMoneyResponse), nil
 }
 
-func (m *WebMoney) GetTransactionsHistory(in *GetTransactionsHistoryRequest) (*GetTransactionsHistoryResponse, error) {
+func (m *WebMoney) doGetTransactionsHistory(
+	ctx context.Context,
+	in *GetTransactionsHistoryRequest,
+) (*GetTransactionsHistoryResponse, error) {
+	if err := in.Purse.Validate(); err != nil {
+		return nil, err
+	}
+
 	req := &BaseRequest{
-		RequestNumber: m.getRequestNumber(),
 		WmId:          m.options.wmId,
 		Request:       in,
+		signatureSpec: signatureSpecs[InterfaceX3],
 	}
-	req.SignatureString = in.Purse + req.RequestNumber
 
-	url := fmt.Sprintf(apiUrlMask, operationGetTransactionsHistory)
-	result, err := m.sendRequest(url, req, new(GetTransactionsHistoryResponse))
+	url := m.getUrl(operationGetTransactionsHistory)
+	result, err := m.sendRequest(ctx, InterfaceX3, url, req, new(GetTransactionsHistoryResponse))
 
 	if err != nil {
 		return nil, err
@@ -253,16 +412,15 @@ func (m *WebMoney) GetTransactionsHistory(in *GetTransactionsHistoryRequest) (*G
 	return result.Response.(*GetTransactionsHistoryResponse), nil
 }
 
-func (m *WebMoney) GetBalance(in *GetBalanceRequest) (*GetBalanceResponse, error) {
+func (m *WebMoney) doGetBalance(ctx context.Context, in *GetBalanceRequest) (*GetBalanceResponse, error) {
 	req := &BaseRequest{
-		RequestNumber: m.getRequestNumber(),
 		WmId:          m.options.wmId,
 		Request:       in,
+		signatureSpec: signatureSpecs[InterfaceX9],
 	}
-	req.SignatureString = in.Wmid + req.RequestNumber
 
-	url := fmt.Sprintf(apiUrlMask, operationGetBalance)
-	result, err := m.sendRequest(url, req, new(GetBalanceResponse))
+	url := m.getUrl(operationGetBalance)
+	result, err := m.sendRequest(ctx, InterfaceX9, url, req, new(GetBalanceResponse))
 
 	if err != nil {
 		return nil, err
@@ -271,14 +429,66 @@ func (m *WebMoney) GetBalance(in *GetBalanceRequest) (*GetBalanceResponse, error
 	return result.Response.(*GetBalanceResponse), nil
 }
 
+func (m *WebMoney) getUrl(operation string) string {
+	return fmt.Sprintf(apiUrlMask, m.options.endpoint, operation)
+}
+
+// getRequestNumber returns the request number (reqn) based on the current time in milliseconds.
+// WebMoney requires each next request number to be greater than the previous one, so the number
+// is incremented when several requests are sent during the same millisecond
 func (m *WebMoney) getRequestNumber() string {
-	nanoseconds := fmt.Sprintf("%03.f", float64(time.Now().Nanosecond()/1000000))
-	return time.Now().Local().Format("20060102150405") + nanoseconds
+	now := time.Now().Local()
+	reqn, _ := strconv.ParseInt(now.Format("20060102150405")+fmt.Sprintf("%03d", now.Nanosecond()/1000000), 10, 64)
+
+	m.requestNumberMu.Lock()
+	defer m.requestNumberMu.Unlock()
+
+	if reqn <= m.lastRequestNumber {
+		reqn = m.lastRequestNumber + 1
+	}
+
+	m.lastRequestNumber = reqn
+
+	return strconv.FormatInt(reqn, 10)
 }
 
-func (m *WebMoney) sendRequest(url string, payload *BaseRequest, receiver interface{}) (*BaseResponse, error) {
+func (m *WebMoney) sendRequest(
+	ctx context.Context,
+	iface string,
+	url string,
+	payload *BaseRequest,
+	receiver interface{},
+) (*BaseResponse, error) {
 	var err error
+
+	if m.options.rateLimiter != nil {
+		if m.options.rateLimitMode == RateLimitFailFast {
+			if !m.options.rateLimiter.Allow(payload.WmId, iface) {
+				return nil, ErrorRateLimitExceeded
+			}
+		} else if err = m.options.rateLimiter.Wait(ctx, payload.WmId, iface); err != nil {
+			return nil, err
+		}
+	}
+
+	// The request number is assigned after waiting for rate limit to keep the request numbers
+	// of the concurrent requests increasing in order of sending
+	payload.RequestNumber = m.getRequestNumber()
+
+	if payload.signatureSpec != nil {
+		payload.SignatureString, err = payload.signatureSpec.String(payload.RequestNumber, payload.Request)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	trace := ContextClientTrace(ctx)
+	trace.requestNumberAssigned(payload.RequestNumber)
+
+	trace.signStart()
 	payload.Signature, err = m.signer.Sign(payload.SignatureString)
+	trace.signDone(err)
 
 	if err != nil {
 		return nil, err
@@ -290,27 +500,30 @@ func (m *WebMoney) sendRequest(url string, payload *BaseRequest, receiver interf
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
 
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Add("Content-Type", "text/xml")
+
+	trace.roundTripStart()
 	rsp, err := m.httpClient.Do(req)
 
 	if err != nil {
+		trace.roundTripDone(0, err)
 		return nil, err
 	}
 
 	rspBody, err := ioutil.ReadAll(rsp.Body)
+	_ = rsp.Body.Close()
+	trace.roundTripDone(rsp.StatusCode, err)
 
 	if err != nil {
 		return nil, err
 	}
 
-	_ = rsp.Body.Close()
-
 	out := &BaseResponse{
 		Response: receiver,
 	}
@@ -321,12 +534,16 @@ func (m *WebMoney) sendRequest(url string, payload *BaseRequest, receiver interf
 	}
 
 	if out.Code != 0 {
-		return nil, errors.New(out.Reason)
+		return nil, &ResponseError{Code: out.Code, Reason: out.Reason}
 	}
 
 	return out, nil
 }
 
+func (m *ResponseError) Error() string {
+	return m.Reason
+}
+
 func (m *WebMoney) Utf8ToWin(str string) string {
 	enc := charmap.Windows1251.NewEncoder()
 	out, _ := enc.String(str)
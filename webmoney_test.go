@@ -1,6 +1,9 @@
 package webmoney
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
 	"errors"
 	"github.com/sidmal/webmoney/mocks"
 	"github.com/sidmal/webmoney/signer"
@@ -10,7 +13,9 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
+	"io/ioutil"
 	"net/url"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -110,6 +115,56 @@ func (suite *WebmoneyTestSuite) TestWebMoney_NewWebMoney_NewSigner_Error() {
 	assert.Nil(suite.T(), wm)
 }
 
+func (suite *WebmoneyTestSuite) TestWebMoney_NewWebMoney_KeyFile_Ok() {
+	key, err := base64.StdEncoding.DecodeString(TestKey)
+	assert.NoError(suite.T(), err)
+
+	path := filepath.Join(suite.T().TempDir(), TestWmId+".kwm")
+	assert.NoError(suite.T(), ioutil.WriteFile(path, key, 0600))
+
+	wm, err := NewWebMoney(WmId(TestWmId), KeyFile(path), Password(TestPassword))
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), wm)
+}
+
+func (suite *WebmoneyTestSuite) TestWebMoney_NewWebMoney_KeyPasswordIncorrect_Error() {
+	key, err := base64.StdEncoding.DecodeString(TestKey)
+	assert.NoError(suite.T(), err)
+
+	wm, err := NewWebMoney(WmId(TestWmId), KeyReader(bytes.NewReader(key)), Password("password"))
+	assert.Equal(suite.T(), signer.ErrorKeyPasswordIncorrect, err)
+	assert.Nil(suite.T(), wm)
+}
+
+func (suite *WebmoneyTestSuite) TestWebMoney_NewWebMoney_KeyProvider_Ok() {
+	suite.T().Setenv("TEST_WEBMONEY_KEY", TestKey)
+	suite.T().Setenv("TEST_WEBMONEY_PASSWORD", TestPassword)
+
+	opts := []Option{
+		WmId(TestWmId),
+		KeyProvider(signer.NewEnvKeyProvider("TEST_WEBMONEY_KEY", "TEST_WEBMONEY_PASSWORD")),
+		KeyOnDemand(true),
+		httpClient(mocks.NewTransportStatusOk()),
+	}
+	wm, err := NewWebMoney(opts...)
+	assert.NoError(suite.T(), err)
+
+	_, err = wm.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *WebmoneyTestSuite) TestWebMoney_NewWebMoney_Signer_Ok() {
+	mockSigner := &mocks.WebMoneySignerInterface{}
+	mockSigner.On("Sign", mock.Anything).Return("signature", nil)
+
+	wm, err := NewWebMoney(WmId(TestWmId), Signer(mockSigner), httpClient(mocks.NewTransportStatusOk()))
+	assert.NoError(suite.T(), err)
+
+	_, err = wm.GetBalance(&GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+	mockSigner.AssertCalled(suite.T(), "Sign", mock.Anything)
+}
+
 func (suite *WebmoneyTestSuite) TestWebMoney_NewWebMoney_CaCert_IoUtil_ReadAll_Error() {
 	suite.defaultOptions = append(suite.defaultOptions, rootCaReader(&mocks.IoReaderError{}))
 	suite.defaultOptions = append(suite.defaultOptions, httpClient(nil))
@@ -123,8 +178,8 @@ func (suite *WebmoneyTestSuite) TestWebMoney_TransferMoney_Ok() {
 	in := &TransferMoneyRequest{
 		TxnId:     1234567890,
 		PurseSrc:  "Z123456789012",
-		PurseDest: "Z0987654321098",
-		Amount:    "10.00",
+		PurseDest: "Z098765432109",
+		Amount:    1000,
 		Period:    0,
 		Desc:      "Тестовая операция",
 		PCode:     "",
@@ -135,12 +190,12 @@ func (suite *WebmoneyTestSuite) TestWebMoney_TransferMoney_Ok() {
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), result)
 	assert.NotZero(suite.T(), result.Id)
-	assert.NotZero(suite.T(), result.Ts)
+	assert.Equal(suite.T(), "456", result.Ts)
 	assert.NotZero(suite.T(), result.TxnId)
 	assert.NotZero(suite.T(), result.PurseSrc)
 	assert.NotZero(suite.T(), result.PurseDest)
-	assert.NotZero(suite.T(), result.Amount)
-	assert.NotZero(suite.T(), result.Commission)
+	assert.EqualValues(suite.T(), 10000, result.Amount)
+	assert.EqualValues(suite.T(), 80, result.Commission)
 	assert.NotZero(suite.T(), result.Desc)
 	assert.NotZero(suite.T(), result.DateCrt)
 	assert.NotZero(suite.T(), result.DateUpd)
@@ -151,8 +206,8 @@ func (suite *WebmoneyTestSuite) TestWebMoney_TransferMoney_Error() {
 	in := &TransferMoneyRequest{
 		TxnId:     1234567890,
 		PurseSrc:  "Z123456789012",
-		PurseDest: "Z0987654321098",
-		Amount:    "10.00",
+		PurseDest: "Z098765432109",
+		Amount:    1000,
 		Period:    0,
 		Desc:      "Тестовая операция",
 		PCode:     "",
@@ -165,8 +220,67 @@ func (suite *WebmoneyTestSuite) TestWebMoney_TransferMoney_Error() {
 	assert.Nil(suite.T(), result)
 }
 
+func (suite *WebmoneyTestSuite) TestWebMoney_TransferMoney_AmountIsIncorrect_Error() {
+	in := &TransferMoneyRequest{
+		TxnId:     1234567890,
+		PurseSrc:  "Z123456789012",
+		PurseDest: "Z098765432109",
+		Amount:    0,
+	}
+	result, err := suite.webmoney.TransferMoney(in)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), ErrorAmountIsIncorrect, err)
+	assert.Nil(suite.T(), result)
+}
+
+func (suite *WebmoneyTestSuite) TestWebMoney_TransferMoney_PurseIsIncorrect_Error() {
+	in := &TransferMoneyRequest{
+		TxnId:     1234567890,
+		PurseSrc:  "Z123456789012",
+		PurseDest: "Z0987654321098",
+		Amount:    1000,
+	}
+	result, err := suite.webmoney.TransferMoney(in)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), ErrorPurseIsIncorrect, err)
+	assert.Nil(suite.T(), result)
+
+	in.PurseSrc, in.PurseDest = in.PurseDest, "Z098765432109"
+	result, err = suite.webmoney.TransferMoney(in)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), ErrorPurseIsIncorrect, err)
+	assert.Nil(suite.T(), result)
+}
+
+func (suite *WebmoneyTestSuite) TestWebMoney_TransferMoney_PurseCurrencyMismatch_Error() {
+	mockSigner := &mocks.WebMoneySignerInterface{}
+	suite.webmoney.signer = mockSigner
+	in := &TransferMoneyRequest{
+		TxnId:     1234567890,
+		PurseSrc:  "Z123456789012",
+		PurseDest: "E098765432109",
+		Amount:    1000,
+	}
+	result, err := suite.webmoney.TransferMoney(in)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), ErrorPurseCurrencyMismatch, err)
+	assert.Nil(suite.T(), result)
+	mockSigner.AssertNotCalled(suite.T(), "Sign", mock.Anything)
+}
+
+func (suite *WebmoneyTestSuite) TestWebMoney_GetTransactionsHistory_PurseIsIncorrect_Error() {
+	in := &GetTransactionsHistoryRequest{
+		Purse: "Z1234",
+		TxnId: 1234567890,
+	}
+	result, err := suite.webmoney.GetTransactionsHistory(in)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), ErrorPurseIsIncorrect, err)
+	assert.Nil(suite.T(), result)
+}
+
 func (suite *WebmoneyTestSuite) TestWebMoney_GetTransactionsHistory_Ok() {
-	t := time.Now().Format("20060102 15:04:05")
+	t := NewWMTime(time.Now())
 	in := &GetTransactionsHistoryRequest{
 		Purse:      "Z123456789012",
 		TxnId:      1234567890,
@@ -181,7 +295,7 @@ func (suite *WebmoneyTestSuite) TestWebMoney_GetTransactionsHistory_Ok() {
 	assert.Len(suite.T(), result.OperationList, 1)
 	assert.NotNil(suite.T(), result.OperationList[0])
 	assert.NotZero(suite.T(), result.OperationList[0].Id)
-	assert.NotZero(suite.T(), result.OperationList[0].Ts)
+	assert.Equal(suite.T(), "456", result.OperationList[0].Ts)
 	assert.NotZero(suite.T(), result.OperationList[0].TxnId)
 	assert.NotZero(suite.T(), result.OperationList[0].PurseSrc)
 	assert.NotZero(suite.T(), result.OperationList[0].PurseDest)
@@ -194,7 +308,7 @@ func (suite *WebmoneyTestSuite) TestWebMoney_GetTransactionsHistory_Ok() {
 
 func (suite *WebmoneyTestSuite) TestWebMoney_GetTransactionsHistory_Error() {
 	suite.webmoney.httpClient = mocks.NewTransportStatusWmError()
-	t := time.Now().Format("20060102 15:04:05")
+	t := NewWMTime(time.Now())
 	in := &GetTransactionsHistoryRequest{
 		Purse:      "Z123456789012",
 		TxnId:      1234567890,
@@ -219,7 +333,7 @@ func (suite *WebmoneyTestSuite) TestWebMoney_GetBalance_Ok() {
 	assert.Len(suite.T(), result.PurseList, 1)
 	assert.NotNil(suite.T(), result.PurseList[0])
 	assert.NotNil(suite.T(), result.PurseList[0].PurseName)
-	assert.NotNil(suite.T(), result.PurseList[0].Amount)
+	assert.EqualValues(suite.T(), 11234545, result.PurseList[0].Amount)
 	assert.NotNil(suite.T(), result.PurseList[0].Desc)
 	assert.NotNil(suite.T(), result.PurseList[0].OutsideOpen)
 	assert.NotNil(suite.T(), result.PurseList[0].LastIncomeTxmId)
@@ -235,6 +349,10 @@ func (suite *WebmoneyTestSuite) TestWebMoney_GetBalance_Error() {
 	assert.Error(suite.T(), err)
 	assert.EqualError(suite.T(), err, "Mock error")
 	assert.Nil(suite.T(), result)
+
+	rspErr, ok := err.(*ResponseError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), -999, rspErr.Code)
 }
 
 func (suite *WebmoneyTestSuite) TestWebMoney_GetBalance_SendRequest_Signer_Sign_Error() {
@@ -303,7 +421,18 @@ func (suite *WebmoneyTestSuite) TestWebMoney_GetBalance_SendRequest_XmlUnMarshal
 }
 
 func (suite *WebmoneyTestSuite) TestWebMoney_SendRequest_Http_NewRequest_Error() {
-	result, err := suite.webmoney.sendRequest("\n", new(BaseRequest), new(GetBalanceResponse))
+	result, err := suite.webmoney.sendRequest(context.Background(), InterfaceX9, "\n", new(BaseRequest), new(GetBalanceResponse))
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
 }
+
+func (suite *WebmoneyTestSuite) TestWebMoney_GetRequestNumber_Increasing_Ok() {
+	previous := suite.webmoney.getRequestNumber()
+	assert.Len(suite.T(), previous, 17)
+
+	for i := 0; i < 100; i++ {
+		reqn := suite.webmoney.getRequestNumber()
+		assert.True(suite.T(), reqn > previous)
+		previous = reqn
+	}
+}
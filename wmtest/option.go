@@ -0,0 +1,24 @@
+package wmtest
+
+import "time"
+
+type Options struct {
+	// The func returning the current time of the server
+	clock func() time.Time
+	// The commission rate charged from the source purse of transfer, e.g. 0.008
+	commissionRate float64
+}
+
+type Option func(*Options)
+
+func Clock(val func() time.Time) Option {
+	return func(opts *Options) {
+		opts.clock = val
+	}
+}
+
+func CommissionRate(val float64) Option {
+	return func(opts *Options) {
+		opts.commissionRate = val
+	}
+}
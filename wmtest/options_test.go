@@ -0,0 +1,34 @@
+package wmtest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWmtestOptions_Setters(t *testing.T) {
+	now := time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		return now
+	}
+
+	opts := []Option{
+		Clock(clock),
+		CommissionRate(0.008),
+	}
+
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	assert.Equal(t, now, options.clock())
+	assert.Equal(t, 0.008, options.commissionRate)
+}
+
+func TestWmtestOptions_ExecuteOptions_Defaults(t *testing.T) {
+	options := executeOptions()
+	assert.NotNil(t, options.clock)
+	assert.Zero(t, options.commissionRate)
+}
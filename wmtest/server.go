@@ -0,0 +1,528 @@
+// Package wmtest provides the in-memory stateful simulator of WebMoney XML interfaces
+// to test the flows working with WebMoney without access to the real service
+package wmtest
+
+import (
+	"encoding/xml"
+	"github.com/sidmal/webmoney"
+	"golang.org/x/text/encoding/charmap"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// The retval codes returned by the server
+const (
+	CodeOk                   = 0
+	CodeReqnIsIncorrect      = -1
+	CodeTxnIdIsIncorrect     = -3
+	CodePurseSrcIsIncorrect  = -4
+	CodePurseDestIsIncorrect = -5
+	CodeAmountIsIncorrect    = -6
+	CodeRequestIsIncorrect   = -100
+	CodeInsufficientFunds    = 5
+	CodePurseNotFound        = 7
+	CodePurseAccessDenied    = 15
+	CodeCurrencyMismatch     = 35
+	CodeReqnNotIncreasing    = 102
+	CodeTxnIdDuplicate       = 103
+)
+
+// Fault is the scripted behaviour of the server on the request to the interface.
+// The request is not executed if the fault has the code, HTTP status or drops the connection
+type Fault struct {
+	// The retval code returned instead of the request execution
+	Code int
+	// The retdesc returned with the code
+	Reason string
+	// The HTTP status code returned without the body
+	StatusCode int
+	// The delay before the request processing, the request is processed as usual after it if there is no other fault
+	Delay time.Duration
+	// Drop closes the connection without response
+	Drop bool
+}
+
+// Server is the HTTP server simulating WebMoney XML interfaces. It keeps the purses with their balances
+// and the operations between them, checks the tranid uniqueness and the reqn increasing. The client is
+// pointed to the server with webmoney.Endpoint(server.URL) option
+type Server struct {
+	// The base URL of the server
+	URL string
+
+	options  *Options
+	server   *httptest.Server
+	handlers map[string]*handler
+
+	mu              sync.Mutex
+	purses          map[webmoney.Purse]*purse
+	operations      []*operation
+	txnIds          map[txnIdKey]struct{}
+	requestNumbers  map[string]int64
+	faults          map[string][]Fault
+	lastOperationId int64
+}
+
+type handler struct {
+	iface string
+	fn    func(req *request) (interface{}, *webmoney.ResponseError)
+}
+
+type request struct {
+	XMLName       xml.Name                                `xml:"w3s.request"`
+	RequestNumber string                                  `xml:"reqn"`
+	WmId          string                                  `xml:"wmid"`
+	Signature     string                                  `xml:"sign"`
+	Trans         *webmoney.TransferMoneyRequest          `xml:"trans"`
+	GetOperations *webmoney.GetTransactionsHistoryRequest `xml:"getoperations"`
+	GetPurses     *webmoney.GetBalanceRequest             `xml:"getpurses"`
+}
+
+type purse struct {
+	wmId          string
+	amount        webmoney.Amount
+	lastIncomeId  string
+	lastOutcomeId string
+}
+
+type operation struct {
+	transfer *webmoney.TransferMoneyResponse
+	wmIdSrc  string
+	wmIdDest string
+	restSrc  webmoney.Amount
+	restDest webmoney.Amount
+}
+
+type txnIdKey struct {
+	purse webmoney.Purse
+	txnId int
+}
+
+// NewServer starts the server, it must be closed by the caller
+func NewServer(opts ...Option) *Server {
+	server := &Server{
+		options:        executeOptions(opts...),
+		purses:         make(map[webmoney.Purse]*purse),
+		txnIds:         make(map[txnIdKey]struct{}),
+		requestNumbers: make(map[string]int64),
+		faults:         make(map[string][]Fault),
+	}
+	server.handlers = map[string]*handler{
+		"/asp/XMLTrans.asp":      {iface: webmoney.InterfaceX2, fn: server.transferMoney},
+		"/asp/XMLOperations.asp": {iface: webmoney.InterfaceX3, fn: server.getTransactionsHistory},
+		"/asp/XMLPurses.asp":     {iface: webmoney.InterfaceX9, fn: server.getBalance},
+	}
+	server.server = httptest.NewServer(server)
+	server.URL = server.server.URL
+
+	return server
+}
+
+func executeOptions(opts ...Option) *Options {
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.clock == nil {
+		options.clock = time.Now
+	}
+
+	return options
+}
+
+// Close shuts down the server
+func (m *Server) Close() {
+	m.server.Close()
+}
+
+// AddPurse adds the purse of WMID with the initial balance or resets the balance of existing purse
+func (m *Server) AddPurse(wmId string, name webmoney.Purse, amount webmoney.Amount) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if p, ok := m.purses[name]; ok {
+		p.wmId, p.amount = wmId, amount
+		return
+	}
+
+	m.purses[name] = &purse{wmId: wmId, amount: amount}
+}
+
+// Balance returns the current balance of the purse and false if the purse does not exist
+func (m *Server) Balance(name webmoney.Purse) (webmoney.Amount, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.purses[name]
+
+	if !ok {
+		return 0, false
+	}
+
+	return p.amount, true
+}
+
+// Operations returns the copies of all completed transfers in order of execution
+func (m *Server) Operations() []*webmoney.TransferMoneyResponse {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]*webmoney.TransferMoneyResponse, 0, len(m.operations))
+
+	for _, op := range m.operations {
+		transfer := *op.transfer
+		out = append(out, &transfer)
+	}
+
+	return out
+}
+
+// Script queues the faults for the next requests to the interface, e.g. webmoney.InterfaceX2.
+// Each request consumes one fault, the zero Fault lets the request to be processed as usual
+func (m *Server) Script(iface string, faults ...Fault) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.faults[iface] = append(m.faults[iface], faults...)
+}
+
+func (m *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h, ok := m.handlers[r.URL.Path]
+
+	if !ok || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+
+	fault, ok := m.nextFault(h.iface)
+
+	if ok {
+		if !m.applyFault(w, r, fault) {
+			return
+		}
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// The client encodes the description to windows-1251 without XML declaration
+	if !utf8.Valid(body) {
+		body, _ = charmap.Windows1251.NewDecoder().Bytes(body)
+	}
+
+	req := new(request)
+
+	if err := xml.Unmarshal(body, req); err != nil {
+		m.write(w, req, nil, &webmoney.ResponseError{Code: CodeRequestIsIncorrect, Reason: err.Error()})
+		return
+	}
+
+	if rspErr := m.checkRequestNumber(req); rspErr != nil {
+		m.write(w, req, nil, rspErr)
+		return
+	}
+
+	out, rspErr := h.fn(req)
+	m.write(w, req, out, rspErr)
+}
+
+func (m *Server) nextFault(iface string) (Fault, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	faults := m.faults[iface]
+
+	if len(faults) == 0 {
+		return Fault{}, false
+	}
+
+	m.faults[iface] = faults[1:]
+
+	return faults[0], true
+}
+
+// applyFault writes the scripted fault and returns true if the request must be processed further
+func (m *Server) applyFault(w http.ResponseWriter, r *http.Request, fault Fault) bool {
+	if fault.Delay > 0 {
+		timer := time.NewTimer(fault.Delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return false
+		}
+	}
+
+	if fault.Drop {
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				_ = conn.Close()
+				return false
+			}
+		}
+
+		panic(http.ErrAbortHandler)
+	}
+
+	if fault.StatusCode != 0 {
+		w.WriteHeader(fault.StatusCode)
+		return false
+	}
+
+	if fault.Code != CodeOk {
+		m.write(w, &request{}, nil, &webmoney.ResponseError{Code: fault.Code, Reason: fault.Reason})
+		return false
+	}
+
+	return true
+}
+
+// checkRequestNumber checks that the request number is greater than the previous request number of WMID
+func (m *Server) checkRequestNumber(req *request) *webmoney.ResponseError {
+	reqn, err := strconv.ParseInt(req.RequestNumber, 10, 64)
+
+	if err != nil || reqn <= 0 {
+		return &webmoney.ResponseError{Code: CodeReqnIsIncorrect, Reason: "the reqn is incorrect"}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if reqn <= m.requestNumbers[req.WmId] {
+		return &webmoney.ResponseError{Code: CodeReqnNotIncreasing, Reason: "the reqn is not greater than the previous one"}
+	}
+
+	m.requestNumbers[req.WmId] = reqn
+
+	return nil
+}
+
+func (m *Server) transferMoney(req *request) (interface{}, *webmoney.ResponseError) {
+	in := req.Trans
+
+	if in == nil {
+		return nil, &webmoney.ResponseError{Code: CodeRequestIsIncorrect, Reason: "the trans element is missing"}
+	}
+
+	if in.TxnId <= 0 {
+		return nil, &webmoney.ResponseError{Code: CodeTxnIdIsIncorrect, Reason: "the tranid is incorrect"}
+	}
+
+	if in.PurseSrc.Validate() != nil {
+		return nil, &webmoney.ResponseError{Code: CodePurseSrcIsIncorrect, Reason: "the pursesrc is incorrect"}
+	}
+
+	if in.PurseDest.Validate() != nil {
+		return nil, &webmoney.ResponseError{Code: CodePurseDestIsIncorrect, Reason: "the pursedest is incorrect"}
+	}
+
+	if in.Amount <= 0 {
+		return nil, &webmoney.ResponseError{Code: CodeAmountIsIncorrect, Reason: "the amount is incorrect"}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	src, ok := m.purses[in.PurseSrc]
+
+	if !ok {
+		return nil, &webmoney.ResponseError{Code: CodePurseNotFound, Reason: "the pursesrc is not found"}
+	}
+
+	if src.wmId != req.WmId {
+		return nil, &webmoney.ResponseError{Code: CodePurseAccessDenied, Reason: "the pursesrc does not belong to wmid"}
+	}
+
+	dest, ok := m.purses[in.PurseDest]
+
+	if !ok {
+		return nil, &webmoney.ResponseError{Code: CodePurseNotFound, Reason: "the pursedest is not found"}
+	}
+
+	if in.PurseSrc.Currency() != in.PurseDest.Currency() {
+		return nil, &webmoney.ResponseError{Code: CodeCurrencyMismatch, Reason: "the purses currencies are different"}
+	}
+
+	key := txnIdKey{purse: in.PurseSrc, txnId: in.TxnId}
+
+	if _, ok := m.txnIds[key]; ok {
+		return nil, &webmoney.ResponseError{Code: CodeTxnIdDuplicate, Reason: "the transfer with tranid already exists"}
+	}
+
+	commission := m.commission(in.Amount)
+
+	if src.amount < in.Amount+commission {
+		return nil, &webmoney.ResponseError{Code: CodeInsufficientFunds, Reason: "the funds are insufficient"}
+	}
+
+	src.amount -= in.Amount + commission
+	dest.amount += in.Amount
+	m.txnIds[key] = struct{}{}
+	m.lastOperationId++
+
+	now := webmoney.NewWMTime(m.options.clock().Truncate(time.Second))
+	id := strconv.FormatInt(m.lastOperationId, 10)
+	transfer := &webmoney.TransferMoneyResponse{
+		Id:            id,
+		Ts:            id,
+		TxnId:         int64(in.TxnId),
+		PurseSrc:      in.PurseSrc,
+		PurseDest:     in.PurseDest,
+		Amount:        in.Amount,
+		Commission:    commission,
+		OperationType: "0",
+		Period:        in.Period,
+		WmInvId:       in.WmInvId,
+		Desc:          in.Desc,
+		DateCrt:       now,
+		DateUpd:       now,
+		CorrWm:        dest.wmId,
+	}
+	m.operations = append(m.operations, &operation{
+		transfer: transfer,
+		wmIdSrc:  src.wmId,
+		wmIdDest: dest.wmId,
+		restSrc:  src.amount,
+		restDest: dest.amount,
+	})
+	src.lastOutcomeId, dest.lastIncomeId = id, id
+
+	out := *transfer
+	return &out, nil
+}
+
+func (m *Server) getTransactionsHistory(req *request) (interface{}, *webmoney.ResponseError) {
+	in := req.GetOperations
+
+	if in == nil {
+		return nil, &webmoney.ResponseError{Code: CodeRequestIsIncorrect, Reason: "the getoperations element is missing"}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.purses[in.Purse]
+
+	if !ok {
+		return nil, &webmoney.ResponseError{Code: CodePurseNotFound, Reason: "the purse is not found"}
+	}
+
+	if p.wmId != req.WmId {
+		return nil, &webmoney.ResponseError{Code: CodePurseAccessDenied, Reason: "the purse does not belong to wmid"}
+	}
+
+	out := &webmoney.GetTransactionsHistoryResponse{}
+
+	// WebMoney returns the newest operations first
+	for i := len(m.operations) - 1; i >= 0; i-- {
+		op := m.operations[i]
+		transfer := *op.transfer
+
+		switch in.Purse {
+		case transfer.PurseSrc:
+			transfer.Rest, transfer.CorrWm = op.restSrc, op.wmIdDest
+		case transfer.PurseDest:
+			transfer.Rest, transfer.CorrWm = op.restDest, op.wmIdSrc
+		default:
+			continue
+		}
+
+		if transfer.DateCrt.Before(in.DateStart.Time) || transfer.DateCrt.After(in.DateFinish.Time) {
+			continue
+		}
+
+		if (in.TxnId != 0 && in.TxnId != transfer.TxnId) || (in.WmTranId != "" && in.WmTranId != transfer.Id) {
+			continue
+		}
+
+		out.OperationList = append(out.OperationList, &transfer)
+	}
+
+	out.Count = int64(len(out.OperationList))
+
+	return out, nil
+}
+
+func (m *Server) getBalance(req *request) (interface{}, *webmoney.ResponseError) {
+	in := req.GetPurses
+
+	if in == nil {
+		return nil, &webmoney.ResponseError{Code: CodeRequestIsIncorrect, Reason: "the getpurses element is missing"}
+	}
+
+	wmId := in.Wmid
+
+	if wmId == "" {
+		wmId = req.WmId
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := &webmoney.GetBalanceResponse{}
+
+	for name, p := range m.purses {
+		if p.wmId != wmId {
+			continue
+		}
+
+		out.PurseList = append(out.PurseList, &webmoney.GetBalanceResponsePurse{
+			PurseName:        name,
+			Amount:           p.amount,
+			OutsideOpen:      "0",
+			LastIncomeTxmId:  p.lastIncomeId,
+			LastOutcomeTxnId: p.lastOutcomeId,
+		})
+	}
+
+	sort.Slice(out.PurseList, func(i, j int) bool {
+		return out.PurseList[i].PurseName < out.PurseList[j].PurseName
+	})
+	out.Count = strconv.Itoa(len(out.PurseList))
+
+	return out, nil
+}
+
+// commission returns the commission of the transfer rounded up to the minor unit
+func (m *Server) commission(amount webmoney.Amount) webmoney.Amount {
+	if m.options.commissionRate <= 0 {
+		return 0
+	}
+
+	return webmoney.Amount(math.Ceil(float64(amount) * m.options.commissionRate))
+}
+
+func (m *Server) write(w http.ResponseWriter, req *request, out interface{}, rspErr *webmoney.ResponseError) {
+	rsp := &webmoney.BaseResponse{
+		RequestNumber: req.RequestNumber,
+		Reason:        "Ok",
+		Response:      out,
+	}
+
+	if rspErr != nil {
+		rsp.Code, rsp.Reason, rsp.Response = rspErr.Code, rspErr.Reason, nil
+	}
+
+	b, err := xml.Marshal(rsp)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,284 @@
+package wmtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sidmal/webmoney"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	TestWmId     = "405002833238"
+	TestKey      = "gQABADCWZW2w1EMgHCYswfVPdf6MAAAAAAAAAEIADHN9yDTlBIQnJd4W/Rk+UDGhrYiYoC5yVGjSkV9GFSkLFKgMk2r2bJDnFUAub2sc9vjXbpkcUlS8QX60Ti83ECQXbomCybZS4zN/pO0IJU77H3FBeFOvjh32PLswJaEqKGCIgU7lydVsT7KBJd9vfNhYaRNVnbH5NQdF+nmDv373G+Ovt9Y="
+	TestPassword = "FvGqPdAy8reVWw789"
+
+	TestCorrWmId = "123456789012"
+
+	TestPurseSrc  webmoney.Purse = "Z123456789012"
+	TestPurseDest webmoney.Purse = "Z098765432109"
+	TestPurseWmr  webmoney.Purse = "R123456789012"
+)
+
+type ServerTestSuite struct {
+	suite.Suite
+	server   *Server
+	webmoney webmoney.XMLInterface
+	now      time.Time
+}
+
+func Test_Server(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
+
+func (suite *ServerTestSuite) SetupTest() {
+	suite.now = time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC)
+	suite.server = NewServer(
+		CommissionRate(0.008),
+		Clock(func() time.Time {
+			return suite.now
+		}),
+	)
+	suite.server.AddPurse(TestWmId, TestPurseSrc, 10000)
+	suite.server.AddPurse(TestWmId, TestPurseWmr, 10000)
+	suite.server.AddPurse(TestCorrWmId, TestPurseDest, 500)
+
+	suite.webmoney = suite.newWebMoney()
+}
+
+func (suite *ServerTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *ServerTestSuite) newWebMoney() webmoney.XMLInterface {
+	wm, err := webmoney.NewWebMoney(
+		webmoney.WmId(TestWmId),
+		webmoney.Key(TestKey),
+		webmoney.Password(TestPassword),
+		webmoney.Endpoint(suite.server.URL),
+	)
+
+	if err != nil {
+		suite.FailNow("WebMoney handler initialization failed", "%v", err)
+	}
+
+	return wm
+}
+
+func (suite *ServerTestSuite) transfer(txnId int, amount webmoney.Amount) (*webmoney.TransferMoneyResponse, error) {
+	return suite.webmoney.TransferMoney(&webmoney.TransferMoneyRequest{
+		TxnId:     txnId,
+		PurseSrc:  TestPurseSrc,
+		PurseDest: TestPurseDest,
+		Amount:    amount,
+		Desc:      "Test transfer",
+	})
+}
+
+func (suite *ServerTestSuite) assertResponseError(err error, code int) {
+	var rspErr *webmoney.ResponseError
+
+	if assert.True(suite.T(), errors.As(err, &rspErr), "%v", err) {
+		assert.Equal(suite.T(), code, rspErr.Code)
+	}
+}
+
+func (suite *ServerTestSuite) TestServer_Flow_Ok() {
+	out, err := suite.transfer(1, 1000)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "1", out.Id)
+	assert.EqualValues(suite.T(), 1, out.TxnId)
+	assert.EqualValues(suite.T(), 1000, out.Amount)
+	assert.EqualValues(suite.T(), 8, out.Commission)
+	assert.Equal(suite.T(), "Test transfer", out.Desc)
+	assert.Equal(suite.T(), "0", out.OperationType)
+	assert.True(suite.T(), out.DateCrt.Equal(suite.now))
+
+	suite.now = suite.now.Add(time.Hour)
+	_, err = suite.transfer(2, 250)
+	assert.NoError(suite.T(), err)
+
+	balance, ok := suite.server.Balance(TestPurseSrc)
+	assert.True(suite.T(), ok)
+	assert.EqualValues(suite.T(), 10000-1008-252, balance)
+
+	balance, ok = suite.server.Balance(TestPurseDest)
+	assert.True(suite.T(), ok)
+	assert.EqualValues(suite.T(), 500+1000+250, balance)
+
+	purses, err := suite.webmoney.GetBalance(&webmoney.GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "2", purses.Count)
+	assert.Len(suite.T(), purses.PurseList, 2)
+	assert.Equal(suite.T(), TestPurseWmr, purses.PurseList[0].PurseName)
+	assert.Equal(suite.T(), TestPurseSrc, purses.PurseList[1].PurseName)
+	assert.EqualValues(suite.T(), 10000-1008-252, purses.PurseList[1].Amount)
+	assert.Equal(suite.T(), "2", purses.PurseList[1].LastOutcomeTxnId)
+
+	history, err := suite.webmoney.GetTransactionsHistory(&webmoney.GetTransactionsHistoryRequest{
+		Purse:      TestPurseSrc,
+		DateStart:  webmoney.NewWMTime(suite.now.Add(-24 * time.Hour)),
+		DateFinish: webmoney.NewWMTime(suite.now),
+	})
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 2, history.Count)
+	assert.Equal(suite.T(), "2", history.OperationList[0].Id)
+	assert.EqualValues(suite.T(), 8740, history.OperationList[0].Rest)
+	assert.Equal(suite.T(), TestCorrWmId, history.OperationList[0].CorrWm)
+	assert.Equal(suite.T(), "1", history.OperationList[1].Id)
+	assert.EqualValues(suite.T(), 8992, history.OperationList[1].Rest)
+
+	assert.Len(suite.T(), suite.server.Operations(), 2)
+}
+
+func (suite *ServerTestSuite) TestServer_IterateTransactions_Ok() {
+	for i := 1; i <= 3; i++ {
+		_, err := suite.transfer(i, 100)
+		assert.NoError(suite.T(), err)
+		suite.now = suite.now.Add(24 * time.Hour)
+	}
+
+	cursor := webmoney.IterateTransactions(context.Background(), suite.webmoney, TestPurseSrc, suite.now.Add(-72*time.Hour), suite.now)
+	var ids []string
+
+	for cursor.Next() {
+		ids = append(ids, cursor.Operation().Id)
+	}
+
+	assert.NoError(suite.T(), cursor.Err())
+	assert.Equal(suite.T(), []string{"1", "2", "3"}, ids)
+}
+
+func (suite *ServerTestSuite) TestServer_TransferMoney_TxnIdDuplicate_Error() {
+	_, err := suite.transfer(1, 100)
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.transfer(1, 100)
+	suite.assertResponseError(err, CodeTxnIdDuplicate)
+	assert.Len(suite.T(), suite.server.Operations(), 1)
+}
+
+func (suite *ServerTestSuite) TestServer_TransferMoney_InsufficientFunds_Error() {
+	_, err := suite.transfer(1, 10000)
+	suite.assertResponseError(err, CodeInsufficientFunds)
+
+	balance, _ := suite.server.Balance(TestPurseSrc)
+	assert.EqualValues(suite.T(), 10000, balance)
+
+	// The failed transfer does not reserve the tranid
+	_, err = suite.transfer(1, 100)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ServerTestSuite) TestServer_TransferMoney_PurseErrors() {
+	_, err := suite.webmoney.TransferMoney(&webmoney.TransferMoneyRequest{
+		TxnId:     1,
+		PurseSrc:  TestPurseDest,
+		PurseDest: TestPurseSrc,
+		Amount:    100,
+	})
+	suite.assertResponseError(err, CodePurseAccessDenied)
+
+	_, err = suite.webmoney.TransferMoney(&webmoney.TransferMoneyRequest{
+		TxnId:     1,
+		PurseSrc:  TestPurseSrc,
+		PurseDest: "Z111111111111",
+		Amount:    100,
+	})
+	suite.assertResponseError(err, CodePurseNotFound)
+
+	_, err = suite.webmoney.GetTransactionsHistory(&webmoney.GetTransactionsHistoryRequest{Purse: TestPurseDest})
+	suite.assertResponseError(err, CodePurseAccessDenied)
+}
+
+func (suite *ServerTestSuite) TestServer_TransferMoney_PurseIsIncorrect_Error() {
+	// The client does not send the purses of unknown currency, other HTTP clients can
+	body := `<w3s.request><reqn>%s</reqn><wmid>` + TestWmId + `</wmid><trans><tranid>1</tranid>` +
+		`<pursesrc>%s</pursesrc><pursedest>%s</pursedest><amount>1.00</amount></trans></w3s.request>`
+
+	rsp := suite.post("/asp/XMLTrans.asp", fmt.Sprintf(body, "1", "Q123456789012", TestPurseDest))
+	assert.Contains(suite.T(), rsp, "<retval>-4</retval>")
+
+	rsp = suite.post("/asp/XMLTrans.asp", fmt.Sprintf(body, "2", TestPurseSrc, "Q123456789012"))
+	assert.Contains(suite.T(), rsp, "<retval>-5</retval>")
+	assert.Empty(suite.T(), suite.server.Operations())
+}
+
+func (suite *ServerTestSuite) TestServer_RequestNumberNotIncreasing_Error() {
+	body := `<w3s.request><reqn>%s</reqn><wmid>` + TestWmId + `</wmid><getpurses><wmid>` + TestWmId + `</wmid></getpurses></w3s.request>`
+
+	rsp := suite.post("/asp/XMLPurses.asp", strings.Replace(body, "%s", "99999999999999999", 1))
+	assert.Contains(suite.T(), rsp, "<retval>0</retval>")
+
+	rsp = suite.post("/asp/XMLPurses.asp", strings.Replace(body, "%s", "99999999999999999", 1))
+	assert.Contains(suite.T(), rsp, "<retval>102</retval>")
+
+	rsp = suite.post("/asp/XMLPurses.asp", strings.Replace(body, "%s", "abc", 1))
+	assert.Contains(suite.T(), rsp, "<retval>-1</retval>")
+
+	// The request numbers are checked per WMID
+	_, err := suite.webmoney.GetBalance(&webmoney.GetBalanceRequest{Wmid: TestWmId})
+	suite.assertResponseError(err, CodeReqnNotIncreasing)
+}
+
+func (suite *ServerTestSuite) TestServer_Script_Ok() {
+	suite.server.Script(
+		webmoney.InterfaceX2,
+		Fault{Code: -100, Reason: "Scripted error"},
+		Fault{StatusCode: http.StatusBadGateway},
+		Fault{Drop: true},
+		Fault{Delay: time.Millisecond},
+	)
+
+	_, err := suite.transfer(1, 100)
+	suite.assertResponseError(err, -100)
+	assert.EqualError(suite.T(), err, "Scripted error")
+
+	_, err = suite.transfer(1, 100)
+	assert.Error(suite.T(), err)
+
+	_, err = suite.transfer(1, 100)
+	assert.Error(suite.T(), err)
+
+	_, err = suite.transfer(1, 100)
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.transfer(2, 100)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), suite.server.Operations(), 2)
+
+	// The faults are scripted per interface
+	_, err = suite.webmoney.GetBalance(&webmoney.GetBalanceRequest{Wmid: TestWmId})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ServerTestSuite) TestServer_NotFound() {
+	rsp, err := http.Get(suite.server.URL + "/asp/XMLPurses.asp")
+	assert.NoError(suite.T(), err)
+	_ = rsp.Body.Close()
+	assert.Equal(suite.T(), http.StatusNotFound, rsp.StatusCode)
+
+	rsp, err = http.Post(suite.server.URL+"/asp/XMLUnknown.asp", "text/xml", strings.NewReader(""))
+	assert.NoError(suite.T(), err)
+	_ = rsp.Body.Close()
+	assert.Equal(suite.T(), http.StatusNotFound, rsp.StatusCode)
+}
+
+func (suite *ServerTestSuite) post(path, body string) string {
+	rsp, err := http.Post(suite.server.URL+path, "text/xml", strings.NewReader(body))
+
+	if err != nil {
+		suite.FailNow("request failed", "%v", err)
+	}
+
+	b, _ := ioutil.ReadAll(rsp.Body)
+	_ = rsp.Body.Close()
+
+	return string(b)
+}
@@ -0,0 +1,67 @@
+package webmoney
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	wmTimeLayout   = "20060102 15:04:05"
+	wmTimeLocation = "Europe/Moscow"
+)
+
+var (
+	moscowLocation = loadMoscowLocation()
+)
+
+// WMTime is the WebMoney timestamp in the "YYYYMMDD HH:MM:SS" format of the Moscow time
+type WMTime struct {
+	time.Time
+}
+
+// NewWMTime returns WebMoney timestamp for the given time
+func NewWMTime(t time.Time) WMTime {
+	return WMTime{Time: t}
+}
+
+func (m WMTime) String() string {
+	if m.IsZero() {
+		return ""
+	}
+
+	return m.In(moscowLocation).Format(wmTimeLayout)
+}
+
+func (m WMTime) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *WMTime) UnmarshalText(data []byte) error {
+	val := strings.TrimSpace(string(data))
+
+	if val == "" {
+		m.Time = time.Time{}
+		return nil
+	}
+
+	t, err := time.ParseInLocation(wmTimeLayout, val, moscowLocation)
+
+	if err != nil {
+		return err
+	}
+
+	m.Time = t
+	return nil
+}
+
+// Moscow has the permanent UTC+3 offset since 2014, so the fixed zone is used
+// when the time zone database is not available
+func loadMoscowLocation() *time.Location {
+	location, err := time.LoadLocation(wmTimeLocation)
+
+	if err != nil {
+		return time.FixedZone("MSK", 3*60*60)
+	}
+
+	return location
+}
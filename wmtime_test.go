@@ -0,0 +1,45 @@
+package webmoney
+
+import (
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWMTime_Xml_Ok(t *testing.T) {
+	type operation struct {
+		XMLName xml.Name `xml:"operation"`
+		DateCrt WMTime   `xml:"datecrt"`
+		DateUpd WMTime   `xml:"dateupd"`
+	}
+
+	in := &operation{
+		DateCrt: NewWMTime(time.Date(2020, 9, 1, 0, 30, 15, 0, time.FixedZone("UTC+3", 3*60*60))),
+	}
+	b, err := xml.Marshal(in)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`<operation><datecrt>20200901 00:30:15</datecrt><dateupd></dateupd></operation>`,
+		string(b),
+	)
+
+	out := new(operation)
+	err = xml.Unmarshal(b, out)
+	assert.NoError(t, err)
+	assert.True(t, in.DateCrt.Equal(out.DateCrt.Time))
+	assert.True(t, out.DateUpd.IsZero())
+	assert.Equal(t, time.Date(2020, 8, 31, 21, 30, 15, 0, time.UTC), out.DateCrt.UTC())
+}
+
+func TestWMTime_UnmarshalText_Error(t *testing.T) {
+	out := new(WMTime)
+	err := out.UnmarshalText([]byte("2020-09-01 00:30:15"))
+	assert.Error(t, err)
+	assert.True(t, out.IsZero())
+}
+
+func TestWMTime_String_Zero_Ok(t *testing.T) {
+	assert.Empty(t, WMTime{}.String())
+}